@@ -0,0 +1,151 @@
+package tpl
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// numberScale holds the short and long names of a power of 1000, the long
+// names are singular and plural.
+type numberScale struct {
+	short string
+	long  [2]string
+}
+
+// numberScales are the names of thousands, millions, billions, and
+// trillions per language.
+var numberScales = map[string][4]numberScale{
+	"en": {
+		{"K", [2]string{"thousand", "thousand"}},
+		{"M", [2]string{"million", "million"}},
+		{"B", [2]string{"billion", "billion"}},
+		{"T", [2]string{"trillion", "trillion"}},
+	},
+	"fr": {
+		{" k", [2]string{"mille", "mille"}},
+		{" M", [2]string{"million", "millions"}},
+		{" Md", [2]string{"milliard", "milliards"}},
+		{" Bn", [2]string{"billion", "billions"}},
+	},
+	"de": {
+		{" Tsd.", [2]string{"Tausend", "Tausend"}},
+		{" Mio.", [2]string{"Million", "Millionen"}},
+		{" Mrd.", [2]string{"Milliarde", "Milliarden"}},
+		{" Bio.", [2]string{"Billion", "Billionen"}},
+	},
+	"es": {
+		{" mil", [2]string{"mil", "mil"}},
+		{" M", [2]string{"millón", "millones"}},
+		{" mil M", [2]string{"mil millones", "mil millones"}},
+		{" B", [2]string{"billón", "billones"}},
+	},
+}
+
+// scaleNumber returns the value divided by its power of 1000 and the index
+// of the scale, -1 below a thousand. The next scale is used when the value
+// rounded to the decimals reaches 1000, 1M instead of 1,000K for 999,999.
+func scaleNumber(f float64, decimals []int) (float64, int) {
+	i := -1
+	for math.Abs(f) >= 1000 && i < 3 {
+		f /= 1000
+		i++
+	}
+
+	p := math.Pow(10, float64(abbrevDecimals(decimals)))
+	if i >= 0 && i < 3 && math.Abs(math.Round(f*p)/p) >= 1000 {
+		f /= 1000
+		i++
+	}
+	return f, i
+}
+
+// abbrevDecimals returns the maximum decimals of an abbreviated number, 1 by
+// default.
+func abbrevDecimals(decimals []int) int {
+	if len(decimals) > 0 {
+		return max(decimals[0], 0)
+	}
+	return 1
+}
+
+// abbrev formats a scaled value with up to the decimals, without trailing
+// zeros.
+func abbrev(locale string, f float64, decimals []int) string {
+	d := abbrevDecimals(decimals)
+	s := formatDecimal(locale, f, d)
+	if d > 0 {
+		sep := decimalSeparator(locale)
+		if i := strings.LastIndex(s, sep); i >= 0 {
+			s = strings.TrimRight(s, "0")
+			s = strings.TrimSuffix(s, sep)
+		}
+	}
+	return s
+}
+
+func getNumberScales(locale string) [4]numberScale {
+	if s, ok := numberScales[localeLang(locale)]; ok {
+		return s
+	}
+	return numberScales["en"]
+}
+
+// AbbrevNum compresses a large number into a short form for counters and
+// badges, e.g. 1.2K in en-US and 3,4 M in fr-CA. Numbers below a thousand
+// are formatted like number. Up to 1 decimal is displayed, an optional
+// argument sets the maximum:
+//
+//	{{ abbrevnum .Locale .Data.Followers }}
+func AbbrevNum(locale string, v any, decimals ...int) string {
+	f, ok := toFloat64(v)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+
+	scaled, i := scaleNumber(f, decimals)
+	if i < 0 {
+		return Number(locale, f)
+	}
+
+	return abbrev(locale, scaled, decimals) + getNumberScales(locale)[i].short
+}
+
+// IntWord converts a large number into words, e.g. 1.2 million in en-US and
+// 2,5 millions in fr-CA. Numbers below a thousand are formatted like number:
+//
+//	{{ intword .Locale .Data.Downloads }}
+func IntWord(locale string, v any, decimals ...int) string {
+	f, ok := toFloat64(v)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+
+	scaled, i := scaleNumber(f, decimals)
+	if i < 0 {
+		return Number(locale, f)
+	}
+
+	num := abbrev(locale, scaled, decimals)
+
+	long := getNumberScales(locale)[i].long
+	name := long[1]
+	if !isPlural(localeLang(locale), int64(math.Abs(scaled))) {
+		name = long[0]
+	}
+	return num + " " + name
+}
+
+// IntComma groups the thousands of a number, with a comma by default like
+// 1,234,567. When a locale is given, the grouping and decimal separators of
+// the locale are used, e.g. 1 234 567 in fr-CA and 1.234.567 in de-DE.
+// Integers are grouped as is, int64 and uint64 values above 2^53 keep all
+// their digits:
+//
+//	{{ intcomma .Data.Views .Locale }}
+func IntComma(v any, locale ...string) string {
+	if len(locale) > 0 && len(locale[0]) > 0 {
+		return Number(locale[0], v)
+	}
+	return Number("en-US", v)
+}
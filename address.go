@@ -0,0 +1,92 @@
+package tpl
+
+import (
+	"html/template"
+	"strings"
+)
+
+// Address is a postal address.
+type Address struct {
+	Name         string
+	Organization string
+	// Street holds the street lines, i.e. the number, street, and unit.
+	Street []string
+	City   string
+	// Region is the state, province, or county.
+	Region     string
+	PostalCode string
+	// Country is the ISO 3166 country code.
+	Country string
+}
+
+// addressFormats are the line layouts of the countries, with %N the name, %O
+// the organization, %A the street lines, %C the city, %S the region, %Z the
+// postal code, and %n a line break.
+var addressFormats = map[string]string{
+	"US": "%N%n%O%n%A%n%C, %S %Z",
+	"CA": "%N%n%O%n%A%n%C %S %Z",
+	"AU": "%N%n%O%n%A%n%C %S %Z",
+	"GB": "%N%n%O%n%A%n%C%n%Z",
+	"IE": "%N%n%O%n%A%n%C%n%S%n%Z",
+	"FR": "%N%n%O%n%A%n%Z %C",
+	"DE": "%N%n%O%n%A%n%Z %C",
+	"BE": "%O%n%N%n%A%n%Z %C",
+	"CH": "%O%n%N%n%A%n%Z %C",
+	"NL": "%O%n%N%n%A%n%Z %C",
+	"ES": "%N%n%O%n%A%n%Z %C %S",
+	"IT": "%N%n%O%n%A%n%Z %C %S",
+	"BR": "%O%n%N%n%A%n%C-%S%n%Z",
+	"MX": "%N%n%O%n%A%n%Z %C, %S",
+	"JP": "%Z%n%S%C%n%A%n%O%n%N",
+}
+
+// defaultAddressFormat is used for the countries without a layout.
+const defaultAddressFormat = "%N%n%O%n%A%n%C %Z"
+
+// Lines returns the lines of the address in the conventional order of its
+// country. The country name, in the language of the locale, is added when
+// the locale is from another country, like for international shipping.
+func (a Address) Lines(locale string) []string {
+	country := strings.ToUpper(a.Country)
+
+	format, ok := addressFormats[country]
+	if !ok {
+		format = defaultAddressFormat
+	}
+
+	r := strings.NewReplacer(
+		"%N", a.Name,
+		"%O", a.Organization,
+		"%A", strings.Join(a.Street, "%n"),
+		"%C", a.City,
+		"%S", a.Region,
+		"%Z", a.PostalCode,
+	)
+
+	var lines []string
+	for _, l := range strings.Split(r.Replace(format), "%n") {
+		// the separators of missing fields are removed
+		l = strings.TrimSpace(strings.Trim(strings.TrimSpace(l), ",-"))
+		if len(l) > 0 {
+			lines = append(lines, l)
+		}
+	}
+
+	if _, region, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-"); len(country) > 0 && !strings.EqualFold(region, country) {
+		lines = append(lines, strings.ToUpper(CountryName(locale, country)))
+	}
+	return lines
+}
+
+// FormatAddress renders an address in the line layout of its country, i.e.
+// the postal code after the city in the US and before it in France, with a
+// <br> between the lines:
+//
+//	<address>{{ address .Locale .Data.ShippingAddress }}</address>
+func FormatAddress(locale string, a Address) template.HTML {
+	lines := a.Lines(locale)
+	for i, l := range lines {
+		lines[i] = template.HTMLEscapeString(l)
+	}
+	return template.HTML(strings.Join(lines, "<br>"))
+}
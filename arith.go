@@ -0,0 +1,148 @@
+package tpl
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// operands converts the numbers of an arithmetic func, ints if they're all
+// integers, floats otherwise.
+func operands(name string, values []any) (ints []int64, floats []float64, err error) {
+	isInt := true
+	for _, v := range values {
+		if _, ok := toInt64(v); !ok {
+			isInt = false
+			break
+		}
+	}
+
+	for _, v := range values {
+		if isInt {
+			n, _ := toInt64(v)
+			ints = append(ints, n)
+			continue
+		}
+
+		f, ok := toFloat64(v)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s: %v is not a number", name, v)
+		}
+		floats = append(floats, f)
+	}
+
+	if isInt {
+		return ints, nil, nil
+	}
+	return nil, floats, nil
+}
+
+// reduce applies an operation to the numbers, returning an int if they're all
+// integers and a float64 otherwise.
+func reduce(name string, values []any, ints func(a, b int64) int64, floats func(a, b float64) float64) (any, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("%s: no numbers", name)
+	}
+
+	is, fs, err := operands(name, values)
+	if err != nil {
+		return nil, err
+	}
+
+	if is != nil {
+		n := is[0]
+		for _, v := range is[1:] {
+			n = ints(n, v)
+		}
+		return int(n), nil
+	}
+
+	f := fs[0]
+	for _, v := range fs[1:] {
+		f = floats(f, v)
+	}
+	return f, nil
+}
+
+// Add returns the sum of numbers, an int if they're all integers and a
+// float64 otherwise:
+//
+//	{{ add .Data.Subtotal .Data.Tax }}
+func Add(a any, b ...any) (any, error) {
+	return reduce("add", append([]any{a}, b...), func(x, y int64) int64 { return x + y }, func(x, y float64) float64 { return x + y })
+}
+
+// Sub returns a minus b.
+func Sub(a, b any) (any, error) {
+	return reduce("sub", []any{a, b}, func(x, y int64) int64 { return x - y }, func(x, y float64) float64 { return x - y })
+}
+
+// Mul returns the product of numbers.
+func Mul(a any, b ...any) (any, error) {
+	return reduce("mul", append([]any{a}, b...), func(x, y int64) int64 { return x * y }, func(x, y float64) float64 { return x * y })
+}
+
+// Div returns a divided by b. Like in Go, the division of integers is
+// truncated, {{ div 7 2 }} is 3, use a float for 3.5: {{ div 7.0 2 }}.
+func Div(a, b any) (any, error) {
+	if f, ok := toFloat64(b); ok && f == 0 {
+		return nil, errors.New("div: division by zero")
+	}
+	return reduce("div", []any{a, b}, func(x, y int64) int64 { return x / y }, func(x, y float64) float64 { return x / y })
+}
+
+// Mod returns the remainder of a divided by b, i.e. for zebra rows:
+//
+//	<tr class="{{ if eq (mod $i 2) 0 }}even{{ end }}">
+func Mod(a, b any) (any, error) {
+	if f, ok := toFloat64(b); ok && f == 0 {
+		return nil, errors.New("mod: division by zero")
+	}
+	return reduce("mod", []any{a, b}, func(x, y int64) int64 { return x % y }, math.Mod)
+}
+
+// Min returns the smallest of numbers.
+func Min(a any, b ...any) (any, error) {
+	return reduce("min", append([]any{a}, b...), func(x, y int64) int64 { return min(x, y) }, math.Min)
+}
+
+// Max returns the largest of numbers.
+func Max(a any, b ...any) (any, error) {
+	return reduce("max", append([]any{a}, b...), func(x, y int64) int64 { return max(x, y) }, math.Max)
+}
+
+// Round rounds a number half away from zero to a number of decimal places, 0
+// by default:
+//
+//	{{ round .Data.Rating 1 }}
+func Round(v any, places ...int) (float64, error) {
+	f, ok := toFloat64(v)
+	if !ok {
+		return 0, fmt.Errorf("round: %v is not a number", v)
+	}
+
+	if len(places) == 0 || places[0] == 0 {
+		return math.Round(f), nil
+	}
+
+	p := math.Pow(10, float64(places[0]))
+	return math.Round(f*p) / p, nil
+}
+
+// Ceil returns the least integer value greater than or equal to a number.
+func Ceil(v any) (float64, error) {
+	f, ok := toFloat64(v)
+	if !ok {
+		return 0, fmt.Errorf("ceil: %v is not a number", v)
+	}
+	return math.Ceil(f), nil
+}
+
+// Floor returns the greatest integer value less than or equal to a number.
+func Floor(v any) (float64, error) {
+	f, ok := toFloat64(v)
+	if !ok {
+		return 0, fmt.Errorf("floor: %v is not a number", v)
+	}
+	return math.Floor(f), nil
+}
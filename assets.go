@@ -0,0 +1,175 @@
+package tpl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// manifestChunk is an entry of a Vite manifest. The entries of a flat esbuild
+// manifest only have a File.
+type manifestChunk struct {
+	File    string   `json:"file"`
+	CSS     []string `json:"css"`
+	Imports []string `json:"imports"`
+}
+
+var (
+	assetManifest   map[string]manifestChunk
+	assetManifestMu sync.Mutex
+)
+
+// resetAssetManifest drops the loaded manifest so the next asset reads the
+// one of the current options.
+func resetAssetManifest() {
+	assetManifestMu.Lock()
+	defer assetManifestMu.Unlock()
+
+	assetManifest = nil
+}
+
+// loadAssetManifest reads the AssetManifest once, or on every call in DevMode
+// so a build in watch mode is picked up.
+func loadAssetManifest() (map[string]manifestChunk, error) {
+	assetManifestMu.Lock()
+	defer assetManifestMu.Unlock()
+
+	if assetManifest != nil && !config.DevMode {
+		return assetManifest, nil
+	}
+
+	if len(config.AssetManifest) == 0 {
+		return nil, errors.New("the AssetManifest option is not set")
+	}
+
+	var b []byte
+	var err error
+	if config.AssetFS != nil {
+		b, err = fs.ReadFile(config.AssetFS, config.AssetManifest)
+	} else {
+		b, err = os.ReadFile(config.AssetManifest)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", config.AssetManifest, err)
+	}
+
+	m := make(map[string]manifestChunk, len(raw))
+	for name, v := range raw {
+		var chunk manifestChunk
+		if len(v) > 0 && v[0] == '"' {
+			err = json.Unmarshal(v, &chunk.File)
+		} else {
+			err = json.Unmarshal(v, &chunk)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: entry %s: %w", config.AssetManifest, name, err)
+		}
+		m[name] = chunk
+	}
+
+	assetManifest = m
+	return m, nil
+}
+
+// assetURL prepends the AssetBaseURL to an output of the manifest.
+func assetURL(file string) string {
+	base := config.AssetBaseURL
+	if len(base) == 0 {
+		base = "/"
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(file, "/")
+}
+
+// Asset returns the URL of the hashed build output of a logical name of the
+// AssetManifest, a source path for Vite or an entry point for esbuild:
+//
+//	<img src="{{ asset "images/logo.svg" }}" alt="">
+//	<script type="module" src="{{ asset "src/main.ts" }}"></script>
+//
+// An unknown name fails the render, a typo is caught before it reaches users.
+func Asset(name string) (string, error) {
+	m, err := loadAssetManifest()
+	if err != nil {
+		return "", err
+	}
+
+	chunk, ok := m[name]
+	if !ok {
+		return "", fmt.Errorf("asset: %s is not in the manifest %s", name, config.AssetManifest)
+	}
+	return assetURL(chunk.File), nil
+}
+
+// AssetTags returns the tags loading an entry of the AssetManifest: a
+// <script type="module"> for a script, with <link rel="modulepreload"> tags
+// for the chunks it imports, and a <link rel="stylesheet"> for its styles and
+// the ones of its imports.
+//
+//	<head>
+//		{{ assettags "src/main.ts" }}
+//	</head>
+func AssetTags(name string) (template.HTML, error) {
+	m, err := loadAssetManifest()
+	if err != nil {
+		return "", err
+	}
+
+	entry, ok := m[name]
+	if !ok {
+		return "", fmt.Errorf("assettags: %s is not in the manifest %s", name, config.AssetManifest)
+	}
+
+	var css, preloads []string
+	seen := make(map[string]bool)
+	var walk func(chunk manifestChunk)
+	walk = func(chunk manifestChunk) {
+		for _, f := range chunk.CSS {
+			if !seen[f] {
+				seen[f] = true
+				css = append(css, f)
+			}
+		}
+
+		for _, imp := range chunk.Imports {
+			if seen[imp] {
+				continue
+			}
+			seen[imp] = true
+
+			c := m[imp]
+			preloads = append(preloads, c.File)
+			walk(c)
+		}
+	}
+	walk(entry)
+
+	var sb strings.Builder
+	for _, f := range css {
+		fmt.Fprintf(&sb, `<link rel="stylesheet" href="%s">`+"\n", template.HTMLEscapeString(assetURL(f)))
+	}
+
+	src := template.HTMLEscapeString(assetURL(entry.File))
+	switch path.Ext(entry.File) {
+	case ".css":
+		fmt.Fprintf(&sb, `<link rel="stylesheet" href="%s">`, src)
+	case ".js", ".mjs":
+		fmt.Fprintf(&sb, `<script type="module" src="%s"></script>`, src)
+		for _, f := range preloads {
+			fmt.Fprintf(&sb, "\n"+`<link rel="modulepreload" href="%s">`, template.HTMLEscapeString(assetURL(f)))
+		}
+	default:
+		return "", fmt.Errorf("assettags: %s is neither a script nor a stylesheet", entry.File)
+	}
+	return template.HTML(sb.String()), nil
+}
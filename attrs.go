@@ -0,0 +1,92 @@
+package tpl
+
+import (
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+var attrName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:.-]*$`)
+
+// urlAttrs are the attributes whose value is a URL, the scripts are filtered
+// out like html/template does.
+var urlAttrs = map[string]bool{"href": true, "src": true, "action": true, "formaction": true, "poster": true, "cite": true}
+
+// Attrs outputs escaped key="value" HTML attributes from name/value pairs or
+// maps, i.e. for data-* and aria-* attributes:
+//
+//	<button {{ attrs "data-id" .ID "aria-expanded" .Open "disabled" .Disabled }}>
+//	<div {{ attrs .Data.Attributes }}>
+//
+// An attribute with a false, nil, or empty value is skipped, a true value
+// outputs the attribute name alone. The event handlers, i.e. onclick, are
+// rejected, and the javascript: URLs replaced by #ZgotmplZ.
+func Attrs(args ...any) (template.HTMLAttr, error) {
+	var sb strings.Builder
+	write := func(name string, v any) error {
+		if !attrName.MatchString(name) || strings.HasPrefix(strings.ToLower(name), "on") {
+			return fmt.Errorf("attrs: invalid attribute name %q", name)
+		}
+
+		var value string
+		switch x := v.(type) {
+		case nil:
+			return nil
+		case bool:
+			if x {
+				sb.WriteString(" " + name)
+			}
+			return nil
+		default:
+			value = fmt.Sprint(x)
+		}
+
+		if len(value) == 0 {
+			return nil
+		}
+
+		if urlAttrs[strings.ToLower(name)] && !safeURL(value) {
+			value = "#ZgotmplZ"
+		}
+
+		sb.WriteString(" " + name + `="` + template.HTMLEscapeString(value) + `"`)
+		return nil
+	}
+
+	for i := 0; i < len(args); i++ {
+		switch v := args[i].(type) {
+		case string:
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("attrs: attribute %s has no value", v)
+			}
+			if err := write(v, args[i+1]); err != nil {
+				return "", err
+			}
+			i++
+		case map[string]any, map[string]string, map[string]bool:
+			m, _ := MergeMaps(v)
+			keys, _ := Keys(m)
+			for _, k := range keys {
+				if err := write(k, m[k]); err != nil {
+					return "", err
+				}
+			}
+		default:
+			return "", fmt.Errorf("attrs: unexpected %T, expected an attribute name or a map", v)
+		}
+	}
+	return template.HTMLAttr(strings.TrimPrefix(sb.String(), " ")), nil
+}
+
+// safeURL reports whether a URL is relative or uses the http, https, or
+// mailto scheme.
+func safeURL(s string) bool {
+	scheme, _, found := strings.Cut(s, ":")
+	if !found || strings.ContainsAny(scheme, "/?#") {
+		return true
+	}
+
+	scheme = strings.ToLower(strings.TrimSpace(scheme))
+	return scheme == "http" || scheme == "https" || scheme == "mailto"
+}
@@ -0,0 +1,72 @@
+package tpl
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// The actions of the AuditEvent.
+const (
+	AuditTranslationsLoad = "translations.load"
+	AuditTranslationsAdd  = "translations.add"
+	AuditViewUpdate       = "view.update"
+	AuditEmailUpdate      = "email.update"
+	AuditRollback         = "template.rollback"
+)
+
+// AuditEvent describes a change to the translations or to the templates,
+// it's passed to the AuditSink option.
+type AuditEvent struct {
+	Time time.Time
+	// Actor is who made the change, set on the context via WithActor. It's
+	// empty for the translations loaded by Parse.
+	Actor  string
+	Action string
+	// Target is the language of the translations or the name of the view or
+	// email.
+	Target string
+	// Version is the version of the view or email after the change.
+	Version int
+	// Keys are the sorted translation keys loaded or added.
+	Keys []string
+}
+
+type actorKey struct{}
+
+// WithActor returns a context carrying who makes a change, i.e. a user ID,
+// for the AuditSink option:
+//
+//	ctx := tpl.WithActor(r.Context(), user.Email)
+//	version, err := templ.UpdateViewContext(ctx, "app/dashboard.html", src)
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// audit sends an event to the AuditSink option, if set.
+func audit(ctx context.Context, action, target string, version int, keys []string) {
+	if config.AuditSink == nil {
+		return
+	}
+
+	actor, _ := ctx.Value(actorKey{}).(string)
+
+	config.AuditSink(AuditEvent{
+		Time:    time.Now(),
+		Actor:   actor,
+		Action:  action,
+		Target:  target,
+		Version: version,
+		Keys:    keys,
+	})
+}
+
+// textKeys returns the sorted keys of translations.
+func textKeys(msgs []Text) []string {
+	keys := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		keys = append(keys, msg.Key)
+	}
+	sort.Strings(keys)
+	return keys
+}
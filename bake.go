@@ -0,0 +1,68 @@
+package tpl
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// bakedDir is the directory, under the template root, of the baked views.
+const bakedDir = "baked"
+
+// staticView reports whether a view is in the StaticViews option, the only
+// views served from their baked bytes.
+func staticView(view string) bool {
+	for _, v := range config.StaticViews {
+		if len(path.Ext(v)) == 0 {
+			v += ".html"
+		}
+		if v == view {
+			return true
+		}
+	}
+	return false
+}
+
+// loadBaked returns the baked views keyed by language and view name, i.e.
+// fr/app/landing.html. The files of views no longer in StaticViews are
+// ignored.
+func loadBaked(fsys fs.FS, root string) (map[string][]byte, error) {
+	root = path.Join(root, bakedDir)
+	if !exists(fsys, root) {
+		return nil, nil
+	}
+
+	baked := make(map[string][]byte)
+	err := fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		key := strings.TrimPrefix(p, root+"/")
+		if _, view, ok := strings.Cut(key, "/"); !ok || !staticView(view) {
+			return nil
+		}
+
+		b, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+
+		baked[key] = b
+		return nil
+	})
+	return baked, err
+}
+
+// bakedView returns the baked bytes of a view in a language.
+func (templ *Template) bakedView(lang, view string) ([]byte, bool) {
+	if !staticView(view) {
+		return nil, false
+	}
+
+	templ.mu.RLock()
+	defer templ.mu.RUnlock()
+
+	b, ok := templ.baked[path.Join(lang, view)]
+	return b, ok
+}
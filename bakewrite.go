@@ -0,0 +1,48 @@
+//go:build !js
+
+package tpl
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// Bake renders the views of the StaticViews option in every language and
+// writes them to the baked directory of dir, your templates directory. Once
+// embedded by the next build, Render serves the baked bytes of these views
+// instead of executing them, i.e. for landing pages. Run it from a small
+// program before building:
+//
+//	tpl.Set(tpl.Option{StaticViews: []string{"app/landing.html"}})
+//	templ, err := tpl.Parse(fs, nil)
+//	err = templ.Bake("templates", tpl.PageData{})
+//
+// The data is the same for every render, only its Lang and Locale change.
+func (templ *Template) Bake(dir string, data PageData) error {
+	// the views are executed, not served from a previous bake.
+	data.unbaked = true
+
+	for _, view := range config.StaticViews {
+		if len(path.Ext(view)) == 0 {
+			view += ".html"
+		}
+
+		out, err := templ.RenderAllLangs(view, data)
+		if err != nil {
+			return err
+		}
+
+		for lang, b := range out {
+			name := filepath.Join(dir, bakedDir, lang, filepath.FromSlash(view))
+			if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+				return err
+			}
+
+			if err := os.WriteFile(name, b, 0644); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
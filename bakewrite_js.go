@@ -0,0 +1,10 @@
+//go:build js
+
+package tpl
+
+import "errors"
+
+// Bake needs a file system to write to, it's not supported in the browser.
+func (templ *Template) Bake(dir string, data PageData) error {
+	return errors.ErrUnsupported
+}
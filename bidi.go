@@ -0,0 +1,32 @@
+package tpl
+
+// rtlLanguages are the languages written right to left, used when the
+// registry has no Direction for a language.
+var rtlLanguages = map[string]bool{
+	"ar": true, "he": true, "fa": true, "ur": true, "yi": true, "ps": true,
+	"dv": true, "ckb": true, "sd": true, "ug": true,
+}
+
+// Dir returns the text direction of a language, "rtl" or "ltr", from the
+// Direction of the Languages registry or else from the language itself:
+//
+//	<html lang="{{ .Lang }}" dir="{{ dir .Lang }}">
+func Dir(lang string) string {
+	if lc, ok := languageConfig(lang); ok && len(lc.Direction) > 0 {
+		return lc.Direction
+	}
+
+	if rtlLanguages[localeLang(lang)] {
+		return "rtl"
+	}
+	return "ltr"
+}
+
+// Bidi wraps user content with the Unicode first strong isolate (U+2068) and
+// pop directional isolate (U+2069) marks, so a name or a title in another
+// direction doesn't reorder the text around it:
+//
+//	<p>{{ t .Lang "posted-by" }} {{ bidi .Data.Author }}</p>
+func Bidi(s string) string {
+	return "\u2068" + s + "\u2069"
+}
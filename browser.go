@@ -0,0 +1,103 @@
+//go:build js && wasm
+
+package tpl
+
+import (
+	"bytes"
+	"encoding/json"
+	"syscall/js"
+)
+
+// ExposeJS makes the templates available to JavaScript under the name of a
+// global object, i.e. to live-preview the edits of an email in an admin UI
+// without a round trip to the server. Build your program with GOOS=js and
+// GOARCH=wasm, the same embedded templates and translations are rendered in
+// the browser:
+//
+//	templ, err := tpl.Parse(fs, nil)
+//	tpl.ExposeJS("templates", templ)
+//	select {}
+//
+// The object has the render(view, data), renderEmail(email, data), and
+// preview(name, src, data) methods. The data is a plain object, i.e.
+// {lang: "fr", data: {name: "Dominic"}} for a view, and they return an
+// {output, error} object. preview renders the candidate source of a view or
+// an email, like PreviewChange, without publishing it.
+func ExposeJS(name string, templ *Template) {
+	obj := js.Global().Get("Object").New()
+
+	obj.Set("render", js.FuncOf(func(this js.Value, args []js.Value) any {
+		var data PageData
+		if err := fromJS(arg(args, 1), &data); err != nil {
+			return jsResult("", err)
+		}
+
+		var buf bytes.Buffer
+		err := templ.Render(&buf, arg(args, 0).String(), data)
+		return jsResult(buf.String(), err)
+	}))
+
+	obj.Set("renderEmail", js.FuncOf(func(this js.Value, args []js.Value) any {
+		var data any
+		if err := fromJS(arg(args, 1), &data); err != nil {
+			return jsResult("", err)
+		}
+
+		var buf bytes.Buffer
+		err := templ.RenderEmail(&buf, arg(args, 0).String(), data)
+		return jsResult(buf.String(), err)
+	}))
+
+	obj.Set("preview", js.FuncOf(func(this js.Value, args []js.Value) any {
+		name := arg(args, 0).String()
+
+		templ.mu.RLock()
+		_, isView := templ.Views[name]
+		templ.mu.RUnlock()
+
+		var data any
+		if isView {
+			var pd PageData
+			if err := fromJS(arg(args, 2), &pd); err != nil {
+				return jsResult("", err)
+			}
+			data = pd
+		} else if err := fromJS(arg(args, 2), &data); err != nil {
+			return jsResult("", err)
+		}
+
+		p, err := templ.PreviewChange(name, []byte(arg(args, 1).String()), data)
+		if err != nil {
+			return jsResult("", err)
+		}
+		return jsResult(p.Output, nil)
+	}))
+
+	js.Global().Set(name, obj)
+}
+
+// arg returns the i-th argument, undefined if it's missing.
+func arg(args []js.Value, i int) js.Value {
+	if i >= len(args) {
+		return js.Undefined()
+	}
+	return args[i]
+}
+
+// fromJS decodes a JavaScript value into v via its JSON.
+func fromJS(val js.Value, v any) error {
+	if val.IsUndefined() || val.IsNull() {
+		return nil
+	}
+
+	s := js.Global().Get("JSON").Call("stringify", val).String()
+	return json.Unmarshal([]byte(s), v)
+}
+
+func jsResult(output string, err error) any {
+	res := map[string]any{"output": output, "error": nil}
+	if err != nil {
+		res["error"] = err.Error()
+	}
+	return res
+}
@@ -0,0 +1,38 @@
+//go:build js && wasm
+
+package tpl_test
+
+import (
+	"strings"
+	"syscall/js"
+	"testing"
+
+	"github.com/dstpierre/tpl"
+)
+
+func TestExposeJS(t *testing.T) {
+	templ := load(t)
+	tpl.ExposeJS("templates", templ)
+
+	obj := js.Global().Get("templates")
+	data := js.ValueOf(map[string]any{"data": map[string]any{"Text": "from js"}})
+
+	res := obj.Call("render", "app/report.html", data)
+	if !res.Get("error").IsNull() {
+		t.Fatal(res.Get("error").String())
+	} else if s := res.Get("output").String(); !strings.Contains(s, "<td>from js</td>") {
+		t.Errorf("expected the view rendered with the data: %s", s)
+	}
+
+	res = obj.Call("preview", "welcome_fr.html", "<p>Allo {{.Name}}</p>", js.ValueOf(map[string]any{"Name": "Zoé"}))
+	if !res.Get("error").IsNull() {
+		t.Fatal(res.Get("error").String())
+	} else if s := res.Get("output").String(); s != "<p>Allo Zoé</p>" {
+		t.Errorf("expected the candidate email rendered, got %q", s)
+	}
+
+	res = obj.Call("renderEmail", "missing.html", js.Null())
+	if res.Get("error").IsNull() {
+		t.Error("expected an error for a missing email")
+	}
+}
@@ -0,0 +1,142 @@
+package tpl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Cache stores rendered output, like the views rendered via RenderCached.
+// Set the Cache option to share your own store, i.e. Redis, across
+// instances. A MemoryCache is used if nil.
+type Cache interface {
+	// Get returns the value of a key and false if it's missing or expired.
+	Get(key string) ([]byte, bool)
+	// Set stores a value for the ttl, forever if the ttl is 0.
+	Set(key string, value []byte, ttl time.Duration)
+	Delete(key string)
+}
+
+// cacheSweepInterval is how often MemoryCache removes its expired entries.
+const cacheSweepInterval = time.Minute
+
+// MemoryCache is an in-process Cache, its zero value is ready to use. It's
+// unbounded, the entries stored forever stay until they're deleted, and the
+// expired ones are removed when they're read or by a sweep at most once a
+// minute.
+type MemoryCache struct {
+	mu        sync.RWMutex
+	entries   map[string]cacheEntry
+	lastSweep time.Time
+}
+
+type cacheEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+func (e cacheEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
+
+// Get returns the value of a key and false if it's missing or expired.
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.RLock()
+	e, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	if now := time.Now(); e.expired(now) {
+		c.mu.Lock()
+		if e, ok := c.entries[key]; ok && e.expired(now) {
+			delete(c.entries, key)
+		}
+		c.mu.Unlock()
+		return nil, false
+	}
+	return e.value, true
+}
+
+// Set stores a value for the ttl, forever if the ttl is 0.
+func (c *MemoryCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]cacheEntry)
+	}
+
+	now := time.Now()
+	if now.Sub(c.lastSweep) >= cacheSweepInterval {
+		for k, e := range c.entries {
+			if e.expired(now) {
+				delete(c.entries, k)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	e := cacheEntry{value: value}
+	if ttl > 0 {
+		e.expires = now.Add(ttl)
+	}
+	c.entries[key] = e
+}
+
+// Delete removes a key.
+func (c *MemoryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
+
+// defaultCache is used when the Cache option is nil.
+var defaultCache = &MemoryCache{}
+
+func cacheStore() Cache {
+	if config.Cache != nil {
+		return config.Cache
+	}
+	return defaultCache
+}
+
+// RenderCached renders a view like Render and caches its output under key
+// for the ttl, so pages that are the same for every visitor, like a pricing
+// page per language, are executed once:
+//
+//	templ.RenderCached(w, "app/pricing.html", "pricing-"+lang, time.Hour, data)
+//
+// The key is scoped to the view and its current version, so updating the
+// view via UpdateView or Rollback renders it again.
+func (templ *Template) RenderCached(w io.Writer, view, key string, ttl time.Duration, data PageData) error {
+	view, _, ok := templ.lookupView(view)
+	if !ok {
+		return errors.New("can't find view: " + view)
+	}
+
+	cache := cacheStore()
+	cacheKey := fmt.Sprintf("tpl:%s:%d:%s", view, templ.version(view), key)
+
+	if b, ok := cache.Get(cacheKey); ok {
+		templ.stats.cache(true)
+		_, err := w.Write(b)
+		return err
+	}
+	templ.stats.cache(false)
+
+	var buf bytes.Buffer
+	if err := templ.Render(&buf, view, data); err != nil {
+		return err
+	}
+
+	cache.Set(cacheKey, buf.Bytes(), ttl)
+	_, err := buf.WriteTo(w)
+	return err
+}
@@ -0,0 +1,177 @@
+package tpl
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// calendarNames holds the month and weekday names of a language.
+type calendarNames struct {
+	months      [12]string
+	shortMonths [12]string
+	days        [7]string
+	shortDays   [7]string
+	am, pm      string
+}
+
+var calendars = map[string]calendarNames{
+	"en": {
+		months:      [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		shortMonths: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		days:        [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		shortDays:   [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+		am:          "AM", pm: "PM",
+	},
+	"fr": {
+		months:      [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		shortMonths: [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		days:        [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		shortDays:   [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+		am:          "AM", pm: "PM",
+	},
+	"de": {
+		months:      [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		shortMonths: [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+		days:        [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		shortDays:   [7]string{"So.", "Mo.", "Di.", "Mi.", "Do.", "Fr.", "Sa."},
+		am:          "AM", pm: "PM",
+	},
+	"es": {
+		months:      [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		shortMonths: [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		days:        [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		shortDays:   [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+		am:          "a. m.", pm: "p. m.",
+	},
+	"it": {
+		months:      [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		shortMonths: [12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		days:        [7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		shortDays:   [7]string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+		am:          "AM", pm: "PM",
+	},
+	"pt": {
+		months:      [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		shortMonths: [12]string{"jan.", "fev.", "mar.", "abr.", "mai.", "jun.", "jul.", "ago.", "set.", "out.", "nov.", "dez."},
+		days:        [7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+		shortDays:   [7]string{"dom.", "seg.", "ter.", "qua.", "qui.", "sex.", "sáb."},
+		am:          "AM", pm: "PM",
+	},
+	"nl": {
+		months:      [12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		shortMonths: [12]string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		days:        [7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		shortDays:   [7]string{"zo", "ma", "di", "wo", "do", "vr", "za"},
+		am:          "a.m.", pm: "p.m.",
+	},
+	"ja": {
+		months:      [12]string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		shortMonths: [12]string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		days:        [7]string{"日曜日", "月曜日", "火曜日", "水曜日", "木曜日", "金曜日", "土曜日"},
+		shortDays:   [7]string{"日", "月", "火", "水", "木", "金", "土"},
+		am:          "午前", pm: "午後",
+	},
+}
+
+func getCalendar(locale string) calendarNames {
+	if c, ok := calendars[localeLang(locale)]; ok {
+		return c
+	}
+	return calendars["en"]
+}
+
+// formatPattern formats a time with a CLDR date pattern, e.g. "EEEE d MMMM y".
+// Text between single quotes is copied as-is.
+func formatPattern(locale string, t time.Time, pattern string) string {
+	cal := getCalendar(locale)
+
+	var sb strings.Builder
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+
+		if c == '\'' {
+			j := i + 1
+			for j < len(runes) && runes[j] != '\'' {
+				j++
+			}
+			if j == i+1 {
+				sb.WriteRune('\'')
+			} else {
+				sb.WriteString(string(runes[i+1 : j]))
+			}
+			i = j + 1
+			continue
+		}
+
+		if !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') {
+			sb.WriteRune(c)
+			i++
+			continue
+		}
+
+		n := 1
+		for i+n < len(runes) && runes[i+n] == c {
+			n++
+		}
+		i += n
+
+		switch c {
+		case 'y':
+			if n == 2 {
+				sb.WriteString(pad(t.Year()%100, 2))
+			} else {
+				sb.WriteString(pad(t.Year(), n))
+			}
+		case 'M', 'L':
+			switch {
+			case n >= 4:
+				sb.WriteString(cal.months[t.Month()-1])
+			case n == 3:
+				sb.WriteString(cal.shortMonths[t.Month()-1])
+			default:
+				sb.WriteString(pad(int(t.Month()), n))
+			}
+		case 'd':
+			sb.WriteString(pad(t.Day(), n))
+		case 'E', 'c':
+			if n >= 4 {
+				sb.WriteString(cal.days[t.Weekday()])
+			} else {
+				sb.WriteString(cal.shortDays[t.Weekday()])
+			}
+		case 'H':
+			sb.WriteString(pad(t.Hour(), n))
+		case 'h':
+			h := t.Hour() % 12
+			if h == 0 {
+				h = 12
+			}
+			sb.WriteString(pad(h, n))
+		case 'm':
+			sb.WriteString(pad(t.Minute(), n))
+		case 's':
+			sb.WriteString(pad(t.Second(), n))
+		case 'a':
+			if t.Hour() < 12 {
+				sb.WriteString(cal.am)
+			} else {
+				sb.WriteString(cal.pm)
+			}
+		case 'z', 'v', 'V':
+			sb.WriteString(t.Format("MST"))
+		default:
+			sb.WriteString(strings.Repeat(string(c), n))
+		}
+	}
+	return sb.String()
+}
+
+func pad(v, n int) string {
+	s := strconv.Itoa(v)
+	for len(s) < n {
+		s = "0" + s
+	}
+	return s
+}
@@ -0,0 +1,117 @@
+package tpl
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// catalogKeys returns the keys with a non-empty value for a language.
+func catalogKeys(lang string) map[string]bool {
+	keys := make(map[string]bool)
+
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+
+	for k, msg := range messages[lang] {
+		if len(msg.Value) > 0 {
+			keys[k] = true
+		}
+	}
+	return keys
+}
+
+// MissingKeys returns the sorted keys present in the base language that are
+// missing or empty in lang.
+func MissingKeys(lang, baseLang string) []string {
+	have := catalogKeys(lang)
+
+	var missing []string
+	for k := range catalogKeys(baseLang) {
+		if !have[k] {
+			missing = append(missing, k)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// Completeness returns, for every loaded language, the ratio between 0 and 1
+// of the default language's keys that are translated.
+func Completeness() map[string]float64 {
+	base := len(catalogKeys(defaultLang()))
+
+	c := make(map[string]float64)
+	for _, lang := range loadedLanguages() {
+		if base == 0 {
+			c[lang] = 1
+			continue
+		}
+
+		missing := len(MissingKeys(lang, defaultLang()))
+		c[lang] = float64(base-missing) / float64(base)
+	}
+	return c
+}
+
+// Languages returns the codes of the Languages registry option or, if it's not
+// set, the sorted languages of the loaded translation files, for instance
+// ["en", "fr"] for en.json and fr.json.
+func (templ *Template) Languages() []string {
+	return availableLanguages()
+}
+
+func availableLanguages() []string {
+	if len(config.Languages) > 0 {
+		var langs []string
+		for _, lc := range config.Languages {
+			langs = append(langs, lc.Code)
+		}
+		return langs
+	}
+
+	return loadedLanguages()
+}
+
+// loadedLanguages returns a copy of the languages of the translation files.
+func loadedLanguages() []string {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+
+	langs := make([]string, len(languages))
+	copy(langs, languages)
+	return langs
+}
+
+// RenderAllLangs renders a view once per language, only swapping the
+// Lang and Locale of the data. It's useful for prerendering, search indexing,
+// and translation QA.
+func (templ *Template) RenderAllLangs(view string, data PageData) (map[string][]byte, error) {
+	out := make(map[string][]byte)
+	for _, lang := range templ.Languages() {
+		d := data
+		d.Lang = lang
+		d.Locale = localeFor(lang, data.Locale)
+
+		var buf bytes.Buffer
+		if err := templ.Render(&buf, view, d); err != nil {
+			return nil, fmt.Errorf("rendering %s in %s: %w", view, lang, err)
+		}
+		out[lang] = buf.Bytes()
+	}
+	return out, nil
+}
+
+// localeFor returns the locale to use for a language, keeping the current
+// locale if it's for the same language, otherwise the registry's locale.
+func localeFor(lang, current string) string {
+	if current == lang || strings.HasPrefix(current, lang+"-") {
+		return current
+	}
+
+	if lc, ok := languageConfig(lang); ok && len(lc.Locale) > 0 {
+		return lc.Locale
+	}
+	return lang
+}
@@ -0,0 +1,107 @@
+package tpl
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+)
+
+// flushWriter flushes its writer after a write once RangeChunked asked for
+// it.
+type flushWriter struct {
+	w       io.Writer
+	flush   func()
+	pending atomic.Bool
+}
+
+// newFlushWriter wraps w if it can be flushed, like an http.ResponseWriter
+// or a bufio.Writer, otherwise it returns nil.
+func newFlushWriter(w io.Writer) *flushWriter {
+	switch f := w.(type) {
+	case http.Flusher:
+		return &flushWriter{w: w, flush: f.Flush}
+	case interface{ Flush() error }:
+		return &flushWriter{w: w, flush: func() { f.Flush() }}
+	}
+	return nil
+}
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if err == nil && fw.pending.CompareAndSwap(true, false) {
+		fw.flush()
+	}
+	return n, err
+}
+
+// chunkedOutput prepares the state of a render for RangeChunked and returns
+// the writer to execute the template with. The end of the state must be
+// called once the execution is done.
+func chunkedOutput(w io.Writer, state *renderState) io.Writer {
+	if fw := newFlushWriter(w); fw != nil {
+		state.flush = fw
+		return fw
+	}
+	return w
+}
+
+// RangeChunked ranges over the values of a slice or an iterator like
+// Collect, and flushes the output every n values, so very large tables and
+// reports rendered as HTML keep a flat memory and start streaming early:
+//
+//	{{ range rangechunked . .Data.Rows 500 }}<tr>...</tr>{{ end }}
+//
+// The output is flushed when the writer passed to Render is an http.Flusher,
+// like an http.ResponseWriter, or has a Flush() error method, like a
+// bufio.Writer. Views using stack are buffered and can't be flushed. A
+// panic or an error of the iterator fails the render.
+func RangeChunked(data PageData, seq any, n int) (<-chan any, error) {
+	if data.state == nil {
+		return nil, errors.New("rangechunked needs the PageData of the render")
+	}
+
+	if n <= 0 {
+		return nil, errors.New("rangechunked needs a positive number of values per chunk")
+	}
+
+	if !iterable(reflect.ValueOf(seq)) {
+		return nil, fmt.Errorf("%T is not an iterator or a slice", seq)
+	}
+
+	state := data.state
+	fw := state.flush
+
+	ch := make(chan any)
+	go func() {
+		defer close(ch)
+		defer func() {
+			if r := recover(); r != nil {
+				state.fail(fmt.Errorf("rangechunked: %v", r))
+			}
+		}()
+
+		i := 0
+		err := eachValue(seq, func(v any) bool {
+			// the template is done with the previous chunk once it asks for
+			// the next value
+			if i > 0 && i%n == 0 && fw != nil {
+				fw.pending.Store(true)
+			}
+			i++
+
+			select {
+			case ch <- v:
+				return true
+			case <-state.done:
+				return false
+			}
+		})
+		if err != nil {
+			state.fail(err)
+		}
+	}()
+	return ch, nil
+}
@@ -0,0 +1,57 @@
+package tpl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Class joins class names, the ones followed by a bool only if it's true,
+// like clsx:
+//
+//	<li class="{{ class "item" "active" (eq .ID $.Data.Current) "disabled" .Disabled }}">
+//
+// A map[string]bool adds its keys with a true value in sorted order. Empty
+// and duplicate names are skipped.
+func Class(args ...any) (string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(s string) {
+		for _, name := range strings.Fields(s) {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	for i := 0; i < len(args); i++ {
+		switch v := args[i].(type) {
+		case string:
+			if i+1 < len(args) {
+				if on, ok := args[i+1].(bool); ok {
+					i++
+					if !on {
+						continue
+					}
+				}
+			}
+			add(v)
+		case map[string]bool:
+			keys := make([]string, 0, len(v))
+			for k, on := range v {
+				if on {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				add(k)
+			}
+		case nil:
+		default:
+			return "", fmt.Errorf("class: unexpected %T %v, expected a class name or a map[string]bool", v, v)
+		}
+	}
+	return strings.Join(names, " "), nil
+}
@@ -0,0 +1,9 @@
+// Command tpl works on the templates and the Go sources of a program using
+// tpl. Run it without arguments for the list of its commands.
+package main
+
+import "github.com/dstpierre/tpl/tplcmd"
+
+func main() {
+	tplcmd.Main(tplcmd.Config{})
+}
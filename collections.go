@@ -0,0 +1,224 @@
+package tpl
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Group is a group of values sharing the same Key, see GroupBy.
+type Group struct {
+	Key   any
+	Items []any
+}
+
+// First returns the first value of an iterator or a slice, nil if it's empty.
+func First(seq any) (any, error) {
+	var first any
+	err := eachValue(seq, func(v any) bool {
+		first = v
+		return false
+	})
+	return first, err
+}
+
+// Last returns the last value of an iterator or a slice, nil if it's empty.
+func Last(seq any) (any, error) {
+	var last any
+	err := eachValue(seq, func(v any) bool {
+		last = v
+		return true
+	})
+	return last, err
+}
+
+// Reverse returns the values of an iterator or a slice in reverse order.
+func Reverse(seq any) ([]any, error) {
+	values, err := Collect(seq)
+	for i, j := 0, len(values)-1; i < j; i, j = i+1, j-1 {
+		values[i], values[j] = values[j], values[i]
+	}
+	return values, err
+}
+
+// Uniq returns the values of an iterator or a slice without their duplicates,
+// in the order they first appear.
+func Uniq(seq any) ([]any, error) {
+	var values []any
+	seen := make(map[any]bool)
+	err := eachValue(seq, func(v any) bool {
+		if v == nil || reflect.TypeOf(v).Comparable() {
+			if !seen[v] {
+				seen[v] = true
+				values = append(values, v)
+			}
+			return true
+		}
+
+		for _, u := range values {
+			if reflect.DeepEqual(u, v) {
+				return true
+			}
+		}
+		values = append(values, v)
+		return true
+	})
+	return values, err
+}
+
+// SortBy returns the values of an iterator or a slice sorted by a field, a
+// map key, or a method without arguments, a dotted path for nested ones. A
+// leading - sorts in descending order:
+//
+//	{{ range sortby "Name" .Data.Customers }}
+//	{{ range sortby "-Invoice.Date" .Data.Lines }}
+//
+// Numbers, strings, booleans, and times are compared, values with an equal
+// field keep their order.
+func SortBy(field string, seq any) ([]any, error) {
+	desc := strings.HasPrefix(field, "-")
+	field = strings.TrimPrefix(field, "-")
+
+	values, err := Collect(seq)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]any, len(values))
+	for i, v := range values {
+		if keys[i], err = fieldValue(v, field); err != nil {
+			return nil, err
+		}
+	}
+
+	idx := make([]int, len(values))
+	for i := range idx {
+		idx[i] = i
+	}
+
+	sort.SliceStable(idx, func(i, j int) bool {
+		a, b := keys[idx[i]], keys[idx[j]]
+		if desc {
+			a, b = b, a
+		}
+		return less(a, b)
+	})
+
+	sorted := make([]any, len(values))
+	for i, j := range idx {
+		sorted[i] = values[j]
+	}
+	return sorted, nil
+}
+
+// GroupBy groups the values of an iterator or a slice by a field, a map key,
+// or a method, in the order the keys first appear, i.e. the lines of an
+// invoice by category:
+//
+//	{{ range groupby "Category" .Data.Lines }}
+//		<h2>{{ .Key }}</h2>
+//		{{ range .Items }}{{ .Name }}{{ end }}
+//	{{ end }}
+func GroupBy(field string, seq any) ([]Group, error) {
+	var groups []Group
+	index := make(map[any]int)
+
+	var ferr error
+	err := eachValue(seq, func(v any) bool {
+		key, err := fieldValue(v, field)
+		if err != nil {
+			ferr = err
+			return false
+		}
+
+		if key != nil && !reflect.TypeOf(key).Comparable() {
+			key = fmt.Sprint(key)
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, Group{Key: key})
+		}
+		groups[i].Items = append(groups[i].Items, v)
+		return true
+	})
+	if ferr != nil {
+		return nil, ferr
+	}
+	return groups, err
+}
+
+// fieldValue returns the value of a dotted path of fields, map keys, or
+// methods without arguments of v.
+func fieldValue(v any, path string) (any, error) {
+	rv := reflect.ValueOf(v)
+	for _, name := range strings.Split(path, ".") {
+		for rv.Kind() == reflect.Interface || rv.Kind() == reflect.Pointer {
+			if m := rv.MethodByName(name); m.IsValid() {
+				break
+			}
+			if rv.IsNil() {
+				return nil, nil
+			}
+			rv = rv.Elem()
+		}
+
+		if m := rv.MethodByName(name); m.IsValid() && m.Type().NumIn() == 0 && m.Type().NumOut() >= 1 {
+			rv = m.Call(nil)[0]
+			continue
+		}
+
+		switch rv.Kind() {
+		case reflect.Struct:
+			f := rv.FieldByName(name)
+			if !f.IsValid() || !f.CanInterface() {
+				return nil, fmt.Errorf("%s has no field %s", rv.Type(), name)
+			}
+			rv = f
+		case reflect.Map:
+			if rv.Type().Key().Kind() != reflect.String {
+				return nil, fmt.Errorf("%s keys aren't strings", rv.Type())
+			}
+			rv = rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()))
+			if !rv.IsValid() {
+				return nil, nil
+			}
+		default:
+			return nil, fmt.Errorf("can't get %s of %s", name, rv.Type())
+		}
+	}
+
+	if !rv.IsValid() {
+		return nil, nil
+	}
+	return rv.Interface(), nil
+}
+
+// less compares numbers, strings, booleans, and times, nil values first.
+func less(a, b any) bool {
+	if a == nil || b == nil {
+		return a == nil && b != nil
+	}
+
+	if x, ok := toFloat64(a); ok {
+		y, _ := toFloat64(b)
+		return x < y
+	}
+
+	switch x := a.(type) {
+	case string:
+		y, _ := b.(string)
+		return x < y
+	case bool:
+		y, _ := b.(bool)
+		return !x && y
+	case time.Time:
+		y, _ := b.(time.Time)
+		return x.Before(y)
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
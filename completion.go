@@ -0,0 +1,99 @@
+package tpl
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"sort"
+)
+
+// CompletionData lists what's available inside the templates, for editor
+// plugins to offer completion and go-to-definition.
+type CompletionData struct {
+	Views           []string            `json:"views"`
+	Emails          []string            `json:"emails"`
+	Partials        []CompletionPartial `json:"partials"`
+	TranslationKeys []string            `json:"translationKeys"`
+	Funcs           []CompletionFunc    `json:"funcs"`
+}
+
+// CompletionPartial is a template defined in a file of the _partials
+// directory.
+type CompletionPartial struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+}
+
+// CompletionFunc is a function of the funcmap with its Go signature.
+type CompletionFunc struct {
+	Name      string `json:"name"`
+	Signature string `json:"signature"`
+}
+
+// CompletionData returns the view, email, partial names, translation keys, and
+// funcmap signatures of the parsed templates.
+func (templ *Template) CompletionData() CompletionData {
+	var data CompletionData
+
+	for name := range templ.Views {
+		data.Views = append(data.Views, name)
+	}
+	sort.Strings(data.Views)
+
+	for name := range templ.Emails {
+		data.Emails = append(data.Emails, name)
+	}
+	sort.Strings(data.Emails)
+
+	partialFiles := make(map[string]bool)
+	for _, p := range templ.partials {
+		partialFiles[p] = true
+	}
+
+	seen := make(map[string]bool)
+	for _, v := range templ.Views {
+		for _, t := range v.Templates() {
+			if t.Tree == nil || !partialFiles[t.Tree.ParseName] || seen[t.Name()] {
+				continue
+			}
+			seen[t.Name()] = true
+			data.Partials = append(data.Partials, CompletionPartial{Name: t.Name(), File: t.Tree.ParseName})
+		}
+	}
+	sort.Slice(data.Partials, func(i, j int) bool {
+		return data.Partials[i].Name < data.Partials[j].Name
+	})
+
+	keys := make(map[string]bool)
+	catalogMu.RLock()
+	for _, texts := range messages {
+		for k := range texts {
+			keys[k] = true
+		}
+	}
+	catalogMu.RUnlock()
+	for k := range keys {
+		data.TranslationKeys = append(data.TranslationKeys, k)
+	}
+	sort.Strings(data.TranslationKeys)
+
+	for name, fn := range templ.funcMap {
+		data.Funcs = append(data.Funcs, CompletionFunc{
+			Name:      name,
+			Signature: reflect.TypeOf(fn).String(),
+		})
+	}
+	sort.Slice(data.Funcs, func(i, j int) bool {
+		return data.Funcs[i].Name < data.Funcs[j].Name
+	})
+
+	return data
+}
+
+// WriteCompletionData writes the CompletionData as JSON, for instance from a
+// small command in your program that editor plugins can invoke.
+func (templ *Template) WriteCompletionData(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(templ.CompletionData())
+}
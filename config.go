@@ -1,7 +1,142 @@
 package tpl
 
+import (
+	"io/fs"
+	"log/slog"
+	"time"
+)
+
 type Option struct {
 	TemplateRootName string
+
+	// DefaultLang is the source language every translation key must exist
+	// in. If empty, it's the first of Languages or "en".
+	DefaultLang string
+
+	// Languages is the registry of the languages supported by your program.
+	// If empty, the languages are the ones of the translation files.
+	Languages []LanguageConfig
+
+	// LangCookieName is the cookie LanguageMiddleware uses to persist the
+	// language chosen by the user, "lang" if empty.
+	LangCookieName string
+
+	// LangURLMode is how the language is set in URLs built by langurl and
+	// hreflang, "prefix" (default) for /fr/about or "query" for
+	// /about?lang=fr.
+	LangURLMode string
+
+	// Timezone is the IANA time zone, i.e. America/Toronto, localtime and
+	// shortdate use when the PageData has no Timezone. If empty, times are
+	// formatted in their own location.
+	Timezone string
+
+	// Now returns the current time for the time helpers, like naturaltime,
+	// so golden tests and previews are deterministic. If nil, time.Now is
+	// used.
+	Now func() time.Time
+
+	// EmailCharset controls the output of HTML emails, "utf-8" (default) or
+	// "ascii" to write non-ASCII characters as numeric entities for legacy
+	// email clients.
+	EmailCharset string
+
+	// EarlyHints sends a 103 Early Hints response with the resources
+	// preloaded by a view when rendering via RenderHTTP.
+	EarlyHints bool
+
+	// PluralRules overrides, per language, the rule deciding if the plural
+	// value of a translation is used for a number. Languages without a rule
+	// use the built-in English or French rule.
+	PluralRules map[string]PluralRule
+
+	// Strict makes Parse return an error when a registered LintRule reports
+	// an issue.
+	Strict bool
+
+	// LintRules run with the rules added via RegisterLintRule, only while
+	// this option is set.
+	LintRules []LintRule
+
+	// DevMode enables development helpers, like the HTML comments indicating
+	// the template file and line that produced each region of the output.
+	DevMode bool
+
+	// ShowTranslationKeys appends, in DevMode, the key to every translated
+	// text, e.g. "Welcome [welcome]", so QA can tell the texts of the catalog
+	// from the hardcoded ones.
+	ShowTranslationKeys bool
+
+	// KeepVersions is the number of versions of a view or an email updated at
+	// runtime kept for Rollback, 10 if 0.
+	KeepVersions int
+
+	// ESIPartials are the partials, by their defined name, rendered as an
+	// <esi:include> tag instead of their content so a CDN can cache the page
+	// and fetch them from ESIHandler.
+	ESIPartials []string
+
+	// ESIPath is the path ESIHandler is served on, "/_esi" if empty.
+	ESIPath string
+
+	// SlowRenderThreshold, when set, logs the renders taking longer than it
+	// and the failed renders with their view, duration, data type, and
+	// language.
+	SlowRenderThreshold time.Duration
+
+	// Logger receives the slow and failed renders, slog.Default() if nil.
+	Logger *slog.Logger
+
+	// StaticViews are the views without per-request data, i.e. landing
+	// pages, rendered at build time via Bake.
+	StaticViews []string
+
+	// Sanitizer sanitizes the HTML of the sanitize func, i.e. a bluemonday
+	// Policy's Sanitize method. If nil, bluemonday's UGCPolicy is used.
+	Sanitizer func(html string) string
+
+	// MarkdownSanitizer sanitizes the HTML of the markdown func. If nil, the
+	// Sanitizer is used.
+	MarkdownSanitizer func(html string) string
+
+	// CodeStyle is the name of the chroma style of the highlightcode func,
+	// i.e. monokai or dracula, github if empty.
+	CodeStyle string
+
+	// WordsPerMinute is the reading speed of the readingtime func, 230 if
+	// zero.
+	WordsPerMinute int
+
+	// Cache stores the output of RenderCached, an in-process MemoryCache if
+	// nil. Set it to share the cache across instances.
+	Cache Cache
+
+	// AfterRender is called after each view or email render with its name,
+	// version, duration, and error.
+	AfterRender func(RenderInfo)
+
+	// AuditSink receives an event when translations are loaded or added, and
+	// when views or emails are updated or rolled back at runtime.
+	AuditSink func(AuditEvent)
+
+	// PseudoLocalize accents, pads, and wraps every translated text, e.g.
+	// "⟦Ĥéļļö ŵöŕļð····⟧", so hardcoded strings and layouts that can't handle
+	// longer texts are easy to spot before real translations exist. The
+	// values of the arguments are left as they are.
+	PseudoLocalize bool
+
+	// AssetManifest is the path of the manifest.json of your frontend build,
+	// Vite's or a flat {"app.js": "app-5f2c1a.js"} map for esbuild, the asset
+	// and assettags funcs resolve names with. It's read from AssetFS, or from
+	// disk if nil, once, or on every render in DevMode.
+	AssetManifest string
+
+	// AssetFS is the file system of the AssetManifest, i.e. an embed.FS.
+	AssetFS fs.FS
+
+	// AssetBaseURL is prepended to the outputs of the AssetManifest, i.e.
+	// /static/ or the URL of a CDN, "/" if empty.
+	AssetBaseURL string
 }
 
 var config Option
@@ -16,4 +151,45 @@ func init() {
 // `templates`.
 func Set(opts Option) {
 	config = opts
+	resetAssetManifest()
+}
+
+// LanguageConfig describes a supported language.
+type LanguageConfig struct {
+	// Code is the language code matching the translation file, e.g. fr.
+	Code string
+	// Locale is used to format dates and currencies, e.g. fr-CA.
+	Locale string
+	// Direction is the text direction, "ltr" if empty or "rtl".
+	Direction string
+	// Fallbacks are the languages looked up, in order, when a translation key
+	// is missing in this language.
+	Fallbacks []string
+}
+
+// now returns the current time of the Now option.
+func now() time.Time {
+	if config.Now != nil {
+		return config.Now()
+	}
+	return time.Now()
+}
+
+func defaultLang() string {
+	if len(config.DefaultLang) > 0 {
+		return config.DefaultLang
+	} else if len(config.Languages) > 0 {
+		return config.Languages[0].Code
+	}
+	return "en"
+}
+
+// languageConfig returns the registry entry of a language.
+func languageConfig(code string) (LanguageConfig, bool) {
+	for _, lc := range config.Languages {
+		if lc.Code == code {
+			return lc, true
+		}
+	}
+	return LanguageConfig{}, false
 }
@@ -0,0 +1,231 @@
+package tpl
+
+import (
+	"encoding/xml"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ContentPage is a markdown view in a language, as listed by Sitemap and
+// Feed.
+type ContentPage struct {
+	View    string
+	Lang    string
+	URL     string
+	Title   string
+	Summary string
+	// Published is the date of the front matter, Updated its updated date
+	// or, if it has none, Published.
+	Published time.Time
+	Updated   time.Time
+	// Alternates are the URLs of the page by language.
+	Alternates map[string]string
+}
+
+// ContentPages returns the markdown views in every language, sorted by URL.
+// The URL of a page is the path of its front matter, otherwise its file name
+// without the extension, i.e. app/terms.md is /terms, localized like LangURL
+// does and joined to baseURL:
+//
+//	---
+//	title: Hello world
+//	path: /blog/hello-world
+//	date: 2024-03-04
+//	updated: 2024-05-01
+//	description: Our first post.
+//	---
+//
+// A page with a lang in its front matter is only listed in that language.
+func (templ *Template) ContentPages(baseURL string) []ContentPage {
+	templ.mu.RLock()
+	fms := make(map[string]frontMatter, len(templ.frontMatters))
+	for view, fm := range templ.frontMatters {
+		if _, ok := templ.Views[view]; ok && isMarkdown(view) {
+			fms[view] = fm
+		}
+	}
+	templ.mu.RUnlock()
+
+	base := strings.TrimSuffix(baseURL, "/")
+
+	var pages []ContentPage
+	for view, fm := range fms {
+		p := fm["path"]
+		if len(p) == 0 {
+			p = strings.TrimSuffix(path.Base(view), path.Ext(view))
+		}
+		p = "/" + strings.TrimPrefix(p, "/")
+
+		langs := templ.Languages()
+		if lang := fm["lang"]; len(lang) > 0 {
+			langs = []string{lang}
+		}
+
+		alternates := make(map[string]string, len(langs))
+		for _, lang := range langs {
+			alternates[lang] = localizeURL(base+p, lang)
+		}
+
+		published := frontMatterDate(fm["date"])
+		updated := frontMatterDate(fm["updated"])
+		if updated.IsZero() {
+			updated = published
+		}
+
+		summary := fm["description"]
+		if len(summary) == 0 {
+			summary = fm["summary"]
+		}
+
+		for _, lang := range langs {
+			pages = append(pages, ContentPage{
+				View:       view,
+				Lang:       lang,
+				URL:        alternates[lang],
+				Title:      fm["title"],
+				Summary:    summary,
+				Published:  published,
+				Updated:    updated,
+				Alternates: alternates,
+			})
+		}
+	}
+
+	sort.Slice(pages, func(i, j int) bool { return pages[i].URL < pages[j].URL })
+	return pages
+}
+
+// frontMatterDate parses a date or a date and time of a front matter, the
+// zero time if it's empty or invalid.
+func frontMatterDate(s string) time.Time {
+	for _, layout := range []string{time.DateOnly, time.RFC3339, time.DateTime} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	NS      string       `xml:"xmlns,attr"`
+	XHTML   string       `xml:"xmlns:xhtml,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc        string        `xml:"loc"`
+	LastMod    string        `xml:"lastmod,omitempty"`
+	Alternates []sitemapLink `xml:"xhtml:link"`
+}
+
+type sitemapLink struct {
+	Rel      string `xml:"rel,attr"`
+	Hreflang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+// Sitemap writes the sitemap of the ContentPages, with the URLs of their
+// other languages as alternates and their updated date as lastmod:
+//
+//	http.HandleFunc("GET /sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+//		w.Header().Set("Content-Type", "application/xml")
+//		templ.Sitemap(w, "https://example.com")
+//	})
+func (templ *Template) Sitemap(w io.Writer, baseURL string) error {
+	set := sitemapURLSet{
+		NS:    "http://www.sitemaps.org/schemas/sitemap/0.9",
+		XHTML: "http://www.w3.org/1999/xhtml",
+	}
+
+	for _, p := range templ.ContentPages(baseURL) {
+		u := sitemapURL{Loc: p.URL}
+		if !p.Updated.IsZero() {
+			u.LastMod = p.Updated.Format(time.DateOnly)
+		}
+
+		if len(p.Alternates) > 1 {
+			for _, lang := range sortedKeys(p.Alternates) {
+				u.Alternates = append(u.Alternates, sitemapLink{Rel: "alternate", Hreflang: lang, Href: p.Alternates[lang]})
+			}
+		}
+		set.URLs = append(set.URLs, u)
+	}
+
+	return writeXML(w, set)
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Language    string    `xml:"language"`
+	Items       []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	Description string `xml:"description,omitempty"`
+	PubDate     string `xml:"pubDate"`
+}
+
+// Feed writes the RSS feed of the ContentPages of a language that have a
+// date in their front matter, like blog posts, newest first:
+//
+//	http.HandleFunc("GET /{lang}/feed.xml", func(w http.ResponseWriter, r *http.Request) {
+//		w.Header().Set("Content-Type", "application/rss+xml")
+//		templ.Feed(w, "https://example.com", r.PathValue("lang"), "Example blog")
+//	})
+func (templ *Template) Feed(w io.Writer, baseURL, lang, title string) error {
+	var pages []ContentPage
+	for _, p := range templ.ContentPages(baseURL) {
+		if p.Lang == lang && !p.Published.IsZero() {
+			pages = append(pages, p)
+		}
+	}
+
+	sort.SliceStable(pages, func(i, j int) bool { return pages[i].Published.After(pages[j].Published) })
+
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       title,
+			Link:        localizeURL(strings.TrimSuffix(baseURL, "/")+"/", lang),
+			Description: title,
+			Language:    lang,
+		},
+	}
+
+	for _, p := range pages {
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       p.Title,
+			Link:        p.URL,
+			GUID:        p.URL,
+			Description: p.Summary,
+			PubDate:     p.Published.Format(time.RFC1123Z),
+		})
+	}
+
+	return writeXML(w, feed)
+}
+
+func writeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(v)
+}
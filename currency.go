@@ -0,0 +1,278 @@
+package tpl
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/number"
+)
+
+type currencyInfo struct {
+	symbol   string
+	decimals int
+	// names holds the singular and plural display names per language.
+	names map[string][2]string
+}
+
+var currencies = map[string]currencyInfo{
+	"USD": {symbol: "$", decimals: 2, names: map[string][2]string{
+		"en": {"US dollar", "US dollars"},
+		"fr": {"dollar des États-Unis", "dollars des États-Unis"},
+	}},
+	"CAD": {symbol: "$", decimals: 2, names: map[string][2]string{
+		"en": {"Canadian dollar", "Canadian dollars"},
+		"fr": {"dollar canadien", "dollars canadiens"},
+	}},
+	"AUD": {symbol: "$", decimals: 2, names: map[string][2]string{
+		"en": {"Australian dollar", "Australian dollars"},
+		"fr": {"dollar australien", "dollars australiens"},
+	}},
+	"EUR": {symbol: "€", decimals: 2, names: map[string][2]string{
+		"en": {"euro", "euros"},
+		"fr": {"euro", "euros"},
+	}},
+	"GBP": {symbol: "£", decimals: 2, names: map[string][2]string{
+		"en": {"British pound", "British pounds"},
+		"fr": {"livre sterling", "livres sterling"},
+	}},
+	"CHF": {symbol: "CHF", decimals: 2, names: map[string][2]string{
+		"en": {"Swiss franc", "Swiss francs"},
+		"fr": {"franc suisse", "francs suisses"},
+	}},
+	"JPY": {symbol: "¥", decimals: 0, names: map[string][2]string{
+		"en": {"Japanese yen", "Japanese yen"},
+		"fr": {"yen japonais", "yens japonais"},
+	}},
+	"BRL": {symbol: "R$", decimals: 2, names: map[string][2]string{
+		"en": {"Brazilian real", "Brazilian reals"},
+		"fr": {"réal brésilien", "réals brésiliens"},
+	}},
+	"MXN": {symbol: "$", decimals: 2, names: map[string][2]string{
+		"en": {"Mexican peso", "Mexican pesos"},
+		"fr": {"peso mexicain", "pesos mexicains"},
+	}},
+}
+
+// isoCurrency returns the localized symbol and the number of decimals of an
+// ISO 4217 currency from the CLDR data of x/text. Unknown codes are displayed
+// as-is with 2 decimals.
+func isoCurrency(locale, code string) currencyInfo {
+	unit, err := currency.ParseISO(code)
+	if err != nil {
+		return currencyInfo{symbol: code, decimals: 2}
+	}
+
+	scale, _ := currency.Standard.Rounding(unit)
+	return currencyInfo{
+		symbol:   getPrinter(locale).Sprint(currency.Symbol(unit)),
+		decimals: scale,
+	}
+}
+
+// currencyOptions are the optional arguments of the currency func.
+type currencyOptions struct {
+	code    string
+	display string
+	// accounting wraps negative amounts in parentheses.
+	accounting bool
+}
+
+func parseCurrencyOptions(locale string, opts []string) currencyOptions {
+	co := currencyOptions{code: getLocaleFormat(locale).currency, display: "symbol"}
+	for _, o := range opts {
+		switch o {
+		case "symbol", "code", "name":
+			co.display = o
+		case "accounting":
+			co.accounting = true
+		default:
+			co.code = strings.ToUpper(o)
+		}
+	}
+	return co
+}
+
+// formatCurrency formats an amount in a currency for a locale, displaying the
+// currency as its symbol, ISO 4217 code, or localized name.
+func formatCurrency(locale string, amount float64, co currencyOptions) string {
+	info := currencyFor(locale, co.code)
+	num := formatDecimal(locale, math.Abs(amount), info.decimals)
+	return placeCurrency(locale, num, amount < 0, amount == 1 || amount == -1, info, co)
+}
+
+func currencyFor(locale, code string) currencyInfo {
+	if info, ok := currencies[code]; ok {
+		return info
+	}
+	return isoCurrency(locale, code)
+}
+
+// placeCurrency adds the currency and the sign to a formatted absolute
+// amount, one is true when the singular name of the currency is used.
+func placeCurrency(locale, num string, neg, one bool, info currencyInfo, co currencyOptions) string {
+	lf := getLocaleFormat(locale)
+
+	var s string
+	switch co.display {
+	case "code":
+		if lf.symbolAfter {
+			s = num + "\u00a0" + co.code
+		} else {
+			s = co.code + "\u00a0" + num
+		}
+	case "name":
+		names, ok := info.names[localeLang(locale)]
+		if !ok {
+			names, ok = info.names["en"]
+		}
+		if !ok {
+			names = [2]string{co.code, co.code}
+		}
+
+		name := names[1]
+		if one {
+			name = names[0]
+		}
+		s = num + " " + name
+	default:
+		r := []rune(info.symbol)
+		switch {
+		case lf.symbolAfter:
+			s = num + "\u00a0" + info.symbol
+		case lf.symbolSpace || len(r) > 0 && unicode.IsLetter(r[len(r)-1]):
+			// a symbol like CHF or BHD is separated from the amount
+			s = info.symbol + "\u00a0" + num
+		default:
+			s = info.symbol + num
+		}
+	}
+
+	if neg && co.accounting {
+		s = "(" + s + ")"
+	} else if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+// Accounting formats an amount like currency in accounting style: negative
+// amounts are in parentheses and wrapped in a span with the "negative" class
+// so financial dashboards and invoices can style them:
+//
+//	{{ accounting .Locale .Data.Balance }}
+//	<span class="negative">($1,234.56)</span>
+//
+// It accepts the same optional arguments as currency.
+func Accounting(locale string, amount float64, opts ...string) template.HTML {
+	co := parseCurrencyOptions(locale, opts)
+	co.accounting = true
+
+	s := template.HTMLEscapeString(formatCurrency(locale, amount, co))
+	if amount < 0 {
+		return template.HTML(`<span class="negative">` + s + `</span>`)
+	}
+	return template.HTML(s)
+}
+
+// CentsCurrency formats an integer amount of minor units, i.e. cents, in a
+// currency without floating-point rounding. The minor units of the currency
+// are used, so 1234 is $12.34 in USD and ¥1,234 in JPY:
+//
+//	{{ centscurrency .Locale .Data.TotalCents "USD" }}
+//
+// It accepts the same optional arguments as currency.
+func CentsCurrency(locale string, cents any, opts ...string) string {
+	n, ok := toInt64(cents)
+	if !ok {
+		return fmt.Sprint(cents)
+	}
+
+	co := parseCurrencyOptions(locale, opts)
+	info := currencyFor(locale, co.code)
+
+	num, one := formatMinorUnits(locale, n, info.decimals)
+	return placeCurrency(locale, num, n < 0, one, info, co)
+}
+
+// CentsNumber formats an integer amount of minor units of a currency as a
+// number without the currency, i.e. 1234 is 12.34 in USD, 12,34 in fr-CA:
+//
+//	{{ centsnumber .Locale .Data.TotalCents "EUR" }}
+//
+// The currency of the locale is used if the code is omitted.
+func CentsNumber(locale string, cents any, code ...string) string {
+	n, ok := toInt64(cents)
+	if !ok {
+		return fmt.Sprint(cents)
+	}
+
+	co := parseCurrencyOptions(locale, code)
+	num, _ := formatMinorUnits(locale, n, currencyFor(locale, co.code).decimals)
+	if n < 0 {
+		return "-" + num
+	}
+	return num
+}
+
+// formatMinorUnits formats the absolute value of an amount of minor units
+// with the separators of the locale using integer arithmetic only. It also
+// reports whether the amount is one major unit.
+func formatMinorUnits(locale string, n int64, decimals int) (string, bool) {
+	abs := uint64(n)
+	if n < 0 {
+		abs = uint64(-n)
+	}
+
+	unit := uint64(1)
+	for i := 0; i < decimals; i++ {
+		unit *= 10
+	}
+
+	major := getPrinter(locale).Sprint(number.Decimal(abs / unit))
+	if decimals == 0 {
+		return major, abs == unit
+	}
+
+	minor := strconv.FormatUint(abs%unit, 10)
+	minor = strings.Repeat("0", decimals-len(minor)) + minor
+	return major + decimalSeparator(locale) + minor, abs == unit
+}
+
+// decimalSeparator returns the decimal separator of a locale, i.e. "," for
+// fr-CA.
+func decimalSeparator(locale string) string {
+	s := formatDecimal(locale, 1.5, 1)
+	return strings.TrimSuffix(strings.TrimPrefix(s, "1"), "5")
+}
+
+// toInt64 converts any Go integer value to an int64.
+func toInt64(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint:
+		return int64(n), true
+	case uint8:
+		return int64(n), true
+	case uint16:
+		return int64(n), true
+	case uint32:
+		return int64(n), true
+	case uint64:
+		return int64(n), true
+	}
+	return 0, false
+}
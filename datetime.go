@@ -0,0 +1,234 @@
+package tpl
+
+import (
+	"strings"
+	"time"
+)
+
+// datePatterns holds the CLDR date, time, and date-time patterns of a locale
+// indexed by style: full, long, medium, and short.
+type datePatterns struct {
+	date     [4]string
+	time     [4]string
+	datetime [4]string
+}
+
+var styles = map[string]int{"full": 0, "long": 1, "medium": 2, "short": 3}
+
+var localeDatePatterns = map[string]datePatterns{
+	"en-US": {
+		date:     [4]string{"EEEE, MMMM d, y", "MMMM d, y", "MMM d, y", "M/d/yy"},
+		time:     [4]string{"h:mm:ss a z", "h:mm:ss a z", "h:mm:ss a", "h:mm a"},
+		datetime: [4]string{"{1} 'at' {0}", "{1} 'at' {0}", "{1}, {0}", "{1}, {0}"},
+	},
+	"en-GB": {
+		date:     [4]string{"EEEE d MMMM y", "d MMMM y", "d MMM y", "dd/MM/y"},
+		time:     [4]string{"HH:mm:ss z", "HH:mm:ss z", "HH:mm:ss", "HH:mm"},
+		datetime: [4]string{"{1} 'at' {0}", "{1} 'at' {0}", "{1}, {0}", "{1}, {0}"},
+	},
+	"en-CA": {
+		date:     [4]string{"EEEE, MMMM d, y", "MMMM d, y", "MMM d, y", "y-MM-dd"},
+		time:     [4]string{"h:mm:ss a z", "h:mm:ss a z", "h:mm:ss a", "h:mm a"},
+		datetime: [4]string{"{1} 'at' {0}", "{1} 'at' {0}", "{1}, {0}", "{1}, {0}"},
+	},
+	"fr-FR": {
+		date:     [4]string{"EEEE d MMMM y", "d MMMM y", "d MMM y", "dd/MM/y"},
+		time:     [4]string{"HH:mm:ss z", "HH:mm:ss z", "HH:mm:ss", "HH:mm"},
+		datetime: [4]string{"{1} 'à' {0}", "{1} 'à' {0}", "{1}, {0}", "{1} {0}"},
+	},
+	"fr-CA": {
+		date:     [4]string{"EEEE d MMMM y", "d MMMM y", "d MMM y", "y-MM-dd"},
+		time:     [4]string{"HH 'h' mm 'min' ss 's' z", "HH 'h' mm 'min' ss 's' z", "HH 'h' mm 'min' ss 's'", "HH 'h' mm"},
+		datetime: [4]string{"{1} 'à' {0}", "{1} 'à' {0}", "{1}, {0}", "{1} {0}"},
+	},
+	"de-DE": {
+		date:     [4]string{"EEEE, d. MMMM y", "d. MMMM y", "dd.MM.y", "dd.MM.yy"},
+		time:     [4]string{"HH:mm:ss z", "HH:mm:ss z", "HH:mm:ss", "HH:mm"},
+		datetime: [4]string{"{1} 'um' {0}", "{1} 'um' {0}", "{1}, {0}", "{1}, {0}"},
+	},
+	"es-ES": {
+		date:     [4]string{"EEEE, d 'de' MMMM 'de' y", "d 'de' MMMM 'de' y", "d MMM y", "d/M/yy"},
+		time:     [4]string{"H:mm:ss z", "H:mm:ss z", "H:mm:ss", "H:mm"},
+		datetime: [4]string{"{1}, {0}", "{1}, {0}", "{1}, {0}", "{1}, {0}"},
+	},
+	"it-IT": {
+		date:     [4]string{"EEEE d MMMM y", "d MMMM y", "d MMM y", "dd/MM/yy"},
+		time:     [4]string{"HH:mm:ss z", "HH:mm:ss z", "HH:mm:ss", "HH:mm"},
+		datetime: [4]string{"{1} 'alle ore' {0}", "{1} 'alle ore' {0}", "{1}, {0}", "{1}, {0}"},
+	},
+	"pt-BR": {
+		date:     [4]string{"EEEE, d 'de' MMMM 'de' y", "d 'de' MMMM 'de' y", "d 'de' MMM 'de' y", "dd/MM/y"},
+		time:     [4]string{"HH:mm:ss z", "HH:mm:ss z", "HH:mm:ss", "HH:mm"},
+		datetime: [4]string{"{1} {0}", "{1} {0}", "{1} {0}", "{1} {0}"},
+	},
+	"nl-NL": {
+		date:     [4]string{"EEEE d MMMM y", "d MMMM y", "d MMM y", "dd-MM-y"},
+		time:     [4]string{"HH:mm:ss z", "HH:mm:ss z", "HH:mm:ss", "HH:mm"},
+		datetime: [4]string{"{1} 'om' {0}", "{1} 'om' {0}", "{1}, {0}", "{1}, {0}"},
+	},
+	"ja-JP": {
+		date:     [4]string{"y年M月d日EEEE", "y年M月d日", "y/MM/dd", "y/MM/dd"},
+		time:     [4]string{"H時mm分ss秒 z", "H:mm:ss z", "H:mm:ss", "H:mm"},
+		datetime: [4]string{"{1} {0}", "{1} {0}", "{1} {0}", "{1} {0}"},
+	},
+}
+
+// skeletons maps the common CLDR skeletons to a pattern per language.
+var skeletons = map[string]map[string]string{
+	"en": {
+		"yMd": "M/d/y", "yMMMd": "MMM d, y", "yMMMMd": "MMMM d, y", "yMMM": "MMM y",
+		"yMMMM": "MMMM y", "MMMd": "MMM d", "MMMMd": "MMMM d", "MMMEd": "EEE, MMM d",
+		"Md": "M/d", "Ed": "d EEE", "Hm": "HH:mm", "Hms": "HH:mm:ss", "hm": "h:mm a", "hms": "h:mm:ss a",
+	},
+	"fr": {
+		"yMd": "dd/MM/y", "yMMMd": "d MMM y", "yMMMMd": "d MMMM y", "yMMM": "MMM y",
+		"yMMMM": "MMMM y", "MMMd": "d MMM", "MMMMd": "d MMMM", "MMMEd": "EEE d MMM",
+		"Md": "dd/MM", "Ed": "EEE d", "Hm": "HH:mm", "Hms": "HH:mm:ss", "hm": "h:mm a", "hms": "h:mm:ss a",
+	},
+	"de": {
+		"yMd": "d.M.y", "yMMMd": "d. MMM y", "yMMMMd": "d. MMMM y", "yMMM": "MMM y",
+		"yMMMM": "MMMM y", "MMMd": "d. MMM", "MMMMd": "d. MMMM", "MMMEd": "EEE, d. MMM",
+		"Md": "d.M.", "Ed": "EEE, d.", "Hm": "HH:mm", "Hms": "HH:mm:ss", "hm": "h:mm a", "hms": "h:mm:ss a",
+	},
+	"es": {
+		"yMd": "d/M/y", "yMMMd": "d MMM y", "yMMMMd": "d 'de' MMMM 'de' y", "yMMM": "MMM y",
+		"yMMMM": "MMMM 'de' y", "MMMd": "d MMM", "MMMMd": "d 'de' MMMM", "MMMEd": "EEE, d MMM",
+		"Md": "d/M", "Ed": "EEE d", "Hm": "H:mm", "Hms": "H:mm:ss", "hm": "h:mm a", "hms": "h:mm:ss a",
+	},
+}
+
+func getDatePatterns(locale string) datePatterns {
+	locale = strings.ReplaceAll(locale, "_", "-")
+	if p, ok := localeDatePatterns[locale]; ok {
+		return p
+	}
+
+	if l, ok := localeLanguages[localeLang(locale)]; ok {
+		if p, ok := localeDatePatterns[l]; ok {
+			return p
+		}
+	}
+
+	return localeDatePatterns["en-US"]
+}
+
+// resolvePattern returns the pattern for a style name, a skeleton, or uses the
+// value as a CLDR pattern.
+func resolvePattern(locale string, patterns [4]string, style []string) string {
+	s := "medium"
+	if len(style) > 0 && len(style[0]) > 0 {
+		s = style[0]
+	}
+
+	if i, ok := styles[s]; ok {
+		return patterns[i]
+	}
+
+	if sk, ok := skeletons[localeLang(locale)]; ok {
+		if p, ok := sk[s]; ok {
+			return p
+		}
+	} else if p, ok := skeletons["en"][s]; ok {
+		return p
+	}
+
+	return s
+}
+
+// FormatDate formats the date part of a time for a locale using a CLDR style:
+// "short", "medium" (default), "long", or "full", a skeleton like "yMMMd", or
+// a CLDR pattern like "EEEE d MMMM":
+//
+//	{{ date .Locale .Data.CreatedAt "long" }}
+func FormatDate(locale string, t time.Time, style ...string) string {
+	return formatPattern(locale, t, resolvePattern(locale, getDatePatterns(locale).date, style))
+}
+
+// FormatTime formats the time part of a time for a locale using a CLDR style,
+// a skeleton like "Hm", or a CLDR pattern.
+func FormatTime(locale string, t time.Time, style ...string) string {
+	return formatPattern(locale, t, resolvePattern(locale, getDatePatterns(locale).time, style))
+}
+
+// FormatDateTime formats a time with its date and time for a locale using a
+// CLDR style, a skeleton, or a CLDR pattern.
+func FormatDateTime(locale string, t time.Time, style ...string) string {
+	p := getDatePatterns(locale)
+
+	s := "medium"
+	if len(style) > 0 && len(style[0]) > 0 {
+		s = style[0]
+	}
+
+	i, ok := styles[s]
+	if !ok {
+		return formatPattern(locale, t, resolvePattern(locale, p.datetime, style))
+	}
+
+	d := formatPattern(locale, t, p.date[i])
+	tm := formatPattern(locale, t, p.time[i])
+
+	// the pattern joining them may contain quoted literals like 'at'
+	glue := formatPattern(locale, t, p.datetime[i])
+	return strings.NewReplacer("{0}", tm, "{1}", d).Replace(glue)
+}
+
+// MonthName returns the name of a month for a locale, e.g. janvier for fr-CA.
+// The month is a time.Month, a number from 1 to 12, or a time.Time. An
+// optional "short" style returns the abbreviated name:
+//
+//	{{ monthname .Locale .Data.Month "short" }}
+func MonthName(locale string, month any, style ...string) string {
+	var m int
+	switch v := month.(type) {
+	case time.Time:
+		m = int(v.Month())
+	case time.Month:
+		m = int(v)
+	default:
+		f, ok := toFloat64(month)
+		if !ok {
+			return ""
+		}
+		m = int(f)
+	}
+
+	if m < 1 || m > 12 {
+		return ""
+	}
+
+	cal := getCalendar(locale)
+	if len(style) > 0 && style[0] == "short" {
+		return cal.shortMonths[m-1]
+	}
+	return cal.months[m-1]
+}
+
+// WeekdayName returns the name of a weekday for a locale, e.g. Montag for
+// de-DE. The day is a time.Weekday, a number from 0 (Sunday) to 6, or a
+// time.Time. An optional "short" style returns the abbreviated name.
+func WeekdayName(locale string, day any, style ...string) string {
+	var d int
+	switch v := day.(type) {
+	case time.Time:
+		d = int(v.Weekday())
+	case time.Weekday:
+		d = int(v)
+	default:
+		f, ok := toFloat64(day)
+		if !ok {
+			return ""
+		}
+		d = int(f)
+	}
+
+	if d < 0 || d > 6 {
+		return ""
+	}
+
+	cal := getCalendar(locale)
+	if len(style) > 0 && style[0] == "short" {
+		return cal.shortDays[d]
+	}
+	return cal.days[d]
+}
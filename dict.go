@@ -0,0 +1,106 @@
+package tpl
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// mapValue returns the reflect.Value of a map with string keys.
+func mapValue(m any) (reflect.Value, bool) {
+	rv := reflect.ValueOf(m)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		rv = rv.Elem()
+	}
+	return rv, rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String
+}
+
+// Get returns the value of a key of a map, nil if it's missing. Unlike index,
+// it doesn't fail on a nil map:
+//
+//	{{ get .Data.Settings "theme" }}
+func Get(m any, key string) any {
+	rv, ok := mapValue(m)
+	if !ok || rv.IsNil() {
+		return nil
+	}
+
+	v := rv.MapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()))
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// Dig returns the value of nested keys of a map, or a default value if one of
+// them is missing. The keys come first, then the default and the map, for
+// pipelines:
+//
+//	{{ dig "user" "address" "city" "Unknown" .Data }}
+//	{{ .Data | dig "user" "name" "" }}
+func Dig(args ...any) (any, error) {
+	if len(args) < 3 {
+		return nil, errors.New("dig needs at least a key, a default value, and a map")
+	}
+
+	def, v := args[len(args)-2], args[len(args)-1]
+	for _, k := range args[:len(args)-2] {
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("dig: key %v is not a string", k)
+		}
+
+		if _, isMap := mapValue(v); !isMap {
+			return def, nil
+		}
+
+		if v = Get(v, key); v == nil {
+			return def, nil
+		}
+	}
+	return v, nil
+}
+
+// MergeMaps returns a new map with the keys of maps, the later ones winning,
+// i.e. to pass defaults and overrides to a partial:
+//
+//	{{ template "button" merge (map "size" "md" "kind" "primary") .Data.Button }}
+//
+// The maps aren't modified.
+func MergeMaps(maps ...any) (map[string]any, error) {
+	merged := make(map[string]any)
+	for _, m := range maps {
+		if m == nil {
+			continue
+		}
+
+		rv, ok := mapValue(m)
+		if !ok {
+			return nil, fmt.Errorf("merge: %T is not a map with string keys", m)
+		}
+
+		iter := rv.MapRange()
+		for iter.Next() {
+			merged[iter.Key().String()] = iter.Value().Interface()
+		}
+	}
+	return merged, nil
+}
+
+// Keys returns the sorted keys of a map:
+//
+//	{{ range keys .Data.Totals }}{{ . }}: {{ index $.Data.Totals . }}{{ end }}
+func Keys(m any) ([]string, error) {
+	rv, ok := mapValue(m)
+	if !ok {
+		return nil, fmt.Errorf("keys: %T is not a map with string keys", m)
+	}
+
+	keys := make([]string, 0, rv.Len())
+	for _, k := range rv.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
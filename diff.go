@@ -0,0 +1,126 @@
+package tpl
+
+import "strings"
+
+// DiffLine is a line of a diff, Op is "+" for an added line, "-" for a
+// removed line, and " " for an unchanged line.
+type DiffLine struct {
+	Op   string
+	Text string
+}
+
+// String returns the line as in a unified diff, i.e. "+<p>Hello</p>".
+func (d DiffLine) String() string {
+	return d.Op + d.Text
+}
+
+// diffText returns the line diff from a to b.
+func diffText(a, b string) []DiffLine {
+	return diffLines(strings.Split(a, "\n"), strings.Split(b, "\n"))
+}
+
+// diffLines returns the diff from a to b based on their longest common
+// subsequence of lines, found with the linear space variant of Myers'
+// algorithm so large outputs don't need a table of every pair of lines.
+func diffLines(a, b []string) []DiffLine {
+	var diff []DiffLine
+	compareLines(a, b, &diff)
+	return diff
+}
+
+// compareLines appends the diff from a to b to diff. It splits them at the
+// middle snake of their edit path and compares both halves.
+func compareLines(a, b []string, diff *[]DiffLine) {
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		*diff = append(*diff, DiffLine{Op: " ", Text: a[0]})
+		a, b = a[1:], b[1:]
+	}
+
+	n := 0
+	for n < len(a) && n < len(b) && a[len(a)-1-n] == b[len(b)-1-n] {
+		n++
+	}
+	suffix := a[len(a)-n:]
+	a, b = a[:len(a)-n], b[:len(b)-n]
+
+	switch {
+	case len(a) == 0:
+		for _, line := range b {
+			*diff = append(*diff, DiffLine{Op: "+", Text: line})
+		}
+	case len(b) == 0:
+		for _, line := range a {
+			*diff = append(*diff, DiffLine{Op: "-", Text: line})
+		}
+	default:
+		x, y := middleSnake(a, b)
+		compareLines(a[:x], b[:y], diff)
+		compareLines(a[x:], b[y:], diff)
+	}
+
+	for _, line := range suffix {
+		*diff = append(*diff, DiffLine{Op: " ", Text: line})
+	}
+}
+
+// middleSnake returns where a shortest edit path from a to b crosses its
+// middle. It searches from both ends at once, the backward search counts the
+// lines from the end of a and b. a and b must differ on their first and last
+// lines so the split is never at one of the ends.
+func middleSnake(a, b []string) (int, int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+
+	// forward[off+k] is the furthest line of a reached on diagonal k, i.e.
+	// where x-y == k, and backward[off+k] the same from the end
+	limit := (n + m + 1) / 2
+	off := limit
+	forward := make([]int, 2*limit+2)
+	backward := make([]int, 2*limit+2)
+
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[off+k-1] < forward[off+k+1]) {
+				x = forward[off+k+1]
+			} else {
+				x = forward[off+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[off+k] = x
+
+			if kb := delta - k; odd && kb >= -(d-1) && kb <= d-1 && x+backward[off+kb] >= n {
+				return x0, y0
+			}
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[off+k-1] < backward[off+k+1]) {
+				x = backward[off+k+1]
+			} else {
+				x = backward[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			backward[off+k] = x
+
+			if kf := delta - k; !odd && kf >= -d && kf <= d && x+forward[off+kf] >= n {
+				return n - x, m - y
+			}
+		}
+	}
+
+	// the searches meet within limit steps, removing a and adding b is a
+	// valid diff otherwise
+	return n, 0
+}
@@ -0,0 +1,53 @@
+package tpl
+
+import (
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+// CountryName returns the name of an ISO 3166 country code in the language
+// of the locale, e.g. Germany in en-US and Allemagne in fr-CA for DE. The
+// code is returned as is when it's unknown, and the name is in English when
+// the locale is empty or unsupported:
+//
+//	{{ countryname .Locale .Data.Country }}
+func CountryName(locale, code string) string {
+	region, err := language.ParseRegion(code)
+	if err != nil {
+		return code
+	}
+
+	namer := display.Regions(language.Make(locale))
+	if namer == nil {
+		namer = display.Regions(language.English)
+	}
+
+	if name := namer.Name(region); len(name) > 0 {
+		return name
+	}
+	return code
+}
+
+// LangName returns the name of a language code in the language of the
+// locale, e.g. German in en-US and allemand in fr-CA for de. Use the
+// language itself as locale to get its own name, like for a language picker.
+// The name is in English when the locale is empty or unsupported:
+//
+//	{{ langname .Lang "es" }}
+//	{{ langname "es" "es" }}
+func LangName(locale, code string) string {
+	tag, err := language.Parse(code)
+	if err != nil {
+		return code
+	}
+
+	namer := display.Languages(language.Make(locale))
+	if namer == nil {
+		namer = display.Languages(language.English)
+	}
+
+	if name := namer.Name(tag); len(name) > 0 {
+		return name
+	}
+	return code
+}
@@ -0,0 +1,149 @@
+package tpl
+
+import (
+	"fmt"
+	"html/template"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxDumpDepth limits how deep Dump goes in nested values.
+const maxDumpDepth = 10
+
+// Dump pretty-prints a value, the fields of structs and the keys of maps
+// included, while building a template:
+//
+//	{{ dump $ .Data }}
+//	{{ dump $ .CurrentUser "comment" }}
+//
+// It's output in a <pre>, or in an HTML comment with the "comment" style, only
+// in DevMode or when the Env of the PageData is dev, development, or local. It
+// renders nothing otherwise, a forgotten dump doesn't leak data in production.
+func Dump(data PageData, v any, style ...string) template.HTML {
+	if !config.DevMode && data.Env != "dev" && data.Env != "development" && data.Env != "local" {
+		return ""
+	}
+
+	var sb strings.Builder
+	d := dumper{sb: &sb, seen: make(map[uintptr]bool)}
+	d.dump(reflect.ValueOf(v), 0)
+
+	if len(style) > 0 && style[0] == "comment" {
+		s := strings.ReplaceAll(sb.String(), "--", "- -")
+		return template.HTML("<!--\n" + s + "\n-->")
+	}
+	return template.HTML(`<pre class="tpl-dump">` + template.HTMLEscapeString(sb.String()) + "</pre>")
+}
+
+type dumper struct {
+	sb   *strings.Builder
+	seen map[uintptr]bool
+}
+
+func (d dumper) indent(depth int) {
+	d.sb.WriteString(strings.Repeat("  ", depth))
+}
+
+func (d dumper) dump(v reflect.Value, depth int) {
+	if !v.IsValid() {
+		d.sb.WriteString("nil")
+		return
+	}
+
+	if depth > maxDumpDepth {
+		d.sb.WriteString("...")
+		return
+	}
+
+	if v.CanInterface() {
+		switch x := v.Interface().(type) {
+		case time.Time:
+			d.sb.WriteString(x.Format(time.RFC3339))
+			return
+		case fmt.Stringer:
+			if v.Kind() != reflect.Struct && v.Kind() != reflect.Pointer {
+				d.sb.WriteString(x.String())
+				return
+			}
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			d.sb.WriteString("nil")
+			return
+		}
+
+		if v.Kind() == reflect.Pointer {
+			if d.seen[v.Pointer()] {
+				d.sb.WriteString("<cycle>")
+				return
+			}
+			d.seen[v.Pointer()] = true
+			defer delete(d.seen, v.Pointer())
+			d.sb.WriteString("&")
+		}
+		d.dump(v.Elem(), depth)
+	case reflect.Struct:
+		d.sb.WriteString(v.Type().String() + " {\n")
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			d.indent(depth + 1)
+			d.sb.WriteString(f.Name + ": ")
+			d.dump(v.Field(i), depth+1)
+			d.sb.WriteString("\n")
+		}
+		d.indent(depth)
+		d.sb.WriteString("}")
+	case reflect.Map:
+		if v.IsNil() {
+			d.sb.WriteString("nil")
+			return
+		}
+
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+
+		d.sb.WriteString(v.Type().String() + " {\n")
+		for _, k := range keys {
+			d.indent(depth + 1)
+			d.sb.WriteString(fmt.Sprintf("%q: ", fmt.Sprint(k.Interface())))
+			d.dump(v.MapIndex(k), depth+1)
+			d.sb.WriteString("\n")
+		}
+		d.indent(depth)
+		d.sb.WriteString("}")
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			d.sb.WriteString("nil")
+			return
+		}
+
+		d.sb.WriteString(v.Type().String() + " [\n")
+		for i := 0; i < v.Len(); i++ {
+			d.indent(depth + 1)
+			d.dump(v.Index(i), depth+1)
+			d.sb.WriteString("\n")
+		}
+		d.indent(depth)
+		d.sb.WriteString("]")
+	case reflect.String:
+		d.sb.WriteString(fmt.Sprintf("%q", v.String()))
+	case reflect.Func, reflect.Chan:
+		d.sb.WriteString(v.Type().String())
+	default:
+		if v.CanInterface() {
+			d.sb.WriteString(fmt.Sprint(v.Interface()))
+		} else {
+			d.sb.WriteString(v.String())
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package tpl
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// durationUnits are the units of humanduration, largest first, with their
+// short symbol and the naturaltime key of their long form.
+var durationUnits = []struct {
+	d      time.Duration
+	symbol string
+	key    string
+}{
+	{24 * time.Hour, "d", "naturaltime-day"},
+	{time.Hour, "h", "naturaltime-hour"},
+	{time.Minute, "m", "naturaltime-minute"},
+	{time.Second, "s", "naturaltime-second"},
+}
+
+// HumanDuration formats a time.Duration, or a number of seconds, for humans
+// with its two largest units. The default style is short, i.e. 2h 15m, the
+// "long" style is in the page language, i.e. 2 hours 15 minutes or 3 jours,
+// and the "compact" style is a clock, i.e. 02:15:36:
+//
+//	{{ humanduration .Lang .Data.Elapsed }}
+//	{{ humanduration .Lang .Data.Seconds "compact" }}
+//
+// The long units come from the naturaltime-day, -hour, -minute, and -second
+// keys of the translation files, with built-in English and French defaults.
+func HumanDuration(lang string, v any, style ...string) string {
+	d, ok := v.(time.Duration)
+	if !ok {
+		secs, isNum := toFloat64(v)
+		if !isNum {
+			return fmt.Sprint(v)
+		}
+		d = time.Duration(secs * float64(time.Second))
+	}
+
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	d = d.Round(time.Second)
+
+	s := ""
+	if len(style) > 0 {
+		s = style[0]
+	}
+
+	if s == "compact" {
+		h, m, sec := int64(d/time.Hour), int64(d%time.Hour/time.Minute), int64(d%time.Minute/time.Second)
+		return fmt.Sprintf("%s%02d:%02d:%02d", sign, h, m, sec)
+	}
+
+	var parts []string
+	for _, u := range durationUnits {
+		if len(parts) == 2 || (len(parts) == 1 && d < u.d) {
+			break
+		}
+
+		n := int64(d / u.d)
+		if n == 0 {
+			continue
+		}
+		d -= time.Duration(n) * u.d
+
+		if s == "long" {
+			parts = append(parts, present(u.key, unitText(lang, u.key, n)))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d%s", n, u.symbol))
+		}
+	}
+
+	if len(parts) == 0 {
+		if s == "long" {
+			return present("naturaltime-second", unitText(lang, "naturaltime-second", 0))
+		}
+		return "0s"
+	}
+	return sign + strings.Join(parts, " ")
+}
@@ -0,0 +1,35 @@
+package tpl
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/yuin/goldmark-emoji/definition"
+)
+
+var (
+	emojis     definition.Emojis
+	emojisOnce sync.Once
+
+	shortcodePattern = regexp.MustCompile(`:[a-z0-9_+-]+:`)
+)
+
+// Emojify converts the :tada:-style shortcodes of a text to their unicode
+// emoji, i.e. for activity feeds or chat messages:
+//
+//	<p>{{ emojify .Data.Message }}</p>
+//
+// The shortcodes are GitHub's, the unknown ones are kept as typed.
+func Emojify(s string) string {
+	emojisOnce.Do(func() {
+		emojis = definition.Github()
+	})
+
+	return shortcodePattern.ReplaceAllStringFunc(s, func(code string) string {
+		e, ok := emojis.Get(code[1 : len(code)-1])
+		if !ok || !e.IsUnicode() {
+			return code
+		}
+		return string(e.Unicode)
+	})
+}
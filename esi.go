@@ -0,0 +1,127 @@
+package tpl
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"text/template/parse"
+)
+
+// esiFuncName is the name wrapESI calls ESI with.
+const esiFuncName = "tplesi"
+
+// esiPath returns the path of the ESI fragment handler, "/_esi" if the
+// ESIPath option is empty.
+func esiPath() string {
+	if len(config.ESIPath) > 0 {
+		return config.ESIPath
+	}
+	return "/_esi"
+}
+
+func isESIPartial(name string) bool {
+	for _, p := range config.ESIPartials {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ESI returns the <esi:include> tag of a partial listed in the ESIPartials
+// option, so the CDN fetches it from ESIHandler and stitches it into the
+// cached page. It returns an empty string when the partial itself is being
+// rendered, i.e. by ESIHandler.
+//
+// The partials of the ESIPartials option call it automatically, you don't
+// need to change your templates. A partial executed with another dot than a
+// PageData is rendered inline.
+func ESI(dot any, name string) template.HTML {
+	data, ok := dot.(PageData)
+	if !ok || data.state == nil || data.state.block == name || !isESIPartial(name) {
+		return ""
+	}
+
+	q := url.Values{}
+	q.Set("view", data.state.view)
+	q.Set("block", name)
+	if len(data.Lang) > 0 {
+		q.Set("lang", data.Lang)
+	}
+
+	// the query is escaped, its & are kept as is for the ESI processors
+	return template.HTML(fmt.Sprintf(`<esi:include src="%s?%s"/>`, template.HTMLEscapeString(esiPath()), q.Encode()))
+}
+
+// wrapESI makes the partials of the ESIPartials option render their
+// <esi:include> tag instead of their content when ESI returns one:
+//
+//	{{with tplesi . "name"}}{{.}}{{else}}original content{{end}}
+func wrapESI(t *template.Template, funcMap map[string]any) error {
+	for _, name := range config.ESIPartials {
+		pt := t.Lookup(name)
+		if pt == nil || pt.Tree == nil {
+			continue
+		}
+
+		src := fmt.Sprintf(`{{with %s . %q}}{{.}}{{else}}{{end}}`, esiFuncName, name)
+		trees, err := parse.Parse(name, src, "", "", funcMap, builtins)
+		if err != nil {
+			return err
+		}
+
+		root := trees[name].Root
+		with, ok := root.Nodes[0].(*parse.WithNode)
+		if !ok {
+			return fmt.Errorf("unable to wrap the ESI partial %s", name)
+		}
+
+		with.ElseList = pt.Tree.Root
+		pt.Tree.Root = root
+	}
+	return nil
+}
+
+// ESIHandler serves the partials of the ESIPartials option for the
+// <esi:include> tags of the views. The view, block, and lang are read from
+// the query string, data returns the PageData of the request and may be nil:
+//
+//	http.Handle("/_esi", templ.ESIHandler(func(r *http.Request) tpl.PageData {
+//		return tpl.PageData{CurrentUser: currentUser(r)}
+//	}))
+func (templ *Template) ESIHandler(data func(r *http.Request) PageData) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		view, block := r.URL.Query().Get("view"), r.URL.Query().Get("block")
+
+		templ.mu.RLock()
+		_, ok := templ.Views[view]
+		templ.mu.RUnlock()
+
+		if !ok || !isESIPartial(block) {
+			http.NotFound(w, r)
+			return
+		}
+
+		var pd PageData
+		if data != nil {
+			pd = data(r)
+		}
+		if len(pd.Lang) == 0 {
+			pd.Lang = r.URL.Query().Get("lang")
+		}
+
+		var buf bytes.Buffer
+		if err := templ.RenderBlock(&buf, view, block, pd); err != nil {
+			configLogger().Error("ESI partial render failed", slog.String("view", view), slog.String("block", block), slog.Any("err", err))
+
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		buf.WriteTo(w)
+	})
+}
@@ -0,0 +1,68 @@
+package tpl
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+var (
+	iecUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	siUnits  = []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
+)
+
+// FileSize formats a number of bytes with the locale's decimal separator, in
+// 1024-based IEC units by default, i.e. 1.5 MiB, or in 1000-based SI units
+// with the "si" mode, i.e. 1.6 MB:
+//
+//	{{ filesize .Locale .Data.Size }}
+//	{{ filesize .Locale .Data.Size "si" }}
+//
+// Sizes have one decimal, none from 100 or in bytes.
+func FileSize(locale string, v any, mode ...string) string {
+	f, ok := toFloat64(v)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+
+	base, units := 1024.0, iecUnits
+	if len(mode) > 0 && mode[0] == "si" {
+		base, units = 1000, siUnits
+	}
+
+	sign := ""
+	if f < 0 {
+		sign, f = "-", -f
+	}
+
+	i := 0
+	for f >= base && i < len(units)-1 {
+		f /= base
+		i++
+	}
+
+	// 1023.97 KiB is rounded to 1 MiB, not 1,024 KiB
+	if i > 0 && i < len(units)-1 && roundSize(f) >= base {
+		f /= base
+		i++
+	}
+
+	var num string
+	switch {
+	case i == 0:
+		num = Number(locale, f, 0)
+	case roundSize(f) >= 100:
+		num = Number(locale, f, 0)
+	default:
+		num = strings.TrimSuffix(Number(locale, f, 1), decimalSeparator(locale)+"0")
+	}
+	return sign + num + " " + units[i]
+}
+
+// roundSize rounds a size to the decimal it's displayed with, none from 100.
+func roundSize(f float64) float64 {
+	if f >= 100 {
+		return math.Round(f)
+	}
+	return math.Round(f*10) / 10
+}
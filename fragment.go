@@ -0,0 +1,47 @@
+package tpl
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// Fragment returns the attributes identifying a fragment of the page so
+// htmx and Turbo can morph it, and the browser can animate it with view
+// transitions:
+//
+//	<div {{ fragment . "cart" }}>
+//
+// It outputs id="cart" style="view-transition-name: cart" and, when the
+// fragment is in a secondary block of RenderBlocks, adds hx-swap-oob="true"
+// so the same markup is swapped out-of-band in a partial response.
+func Fragment(data PageData, name string) template.HTMLAttr {
+	id := fragmentID(name)
+
+	attrs := fmt.Sprintf(`id="%s" style="view-transition-name: %s"`, id, id)
+	if data.oob {
+		attrs += ` hx-swap-oob="true"`
+	}
+
+	return template.HTMLAttr(attrs)
+}
+
+// fragmentID keeps only the characters valid in both an HTML id and a CSS
+// identifier.
+func fragmentID(name string) string {
+	var sb strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune('-')
+		}
+	}
+
+	id := sb.String()
+	if len(id) == 0 || (id[0] >= '0' && id[0] <= '9') {
+		id = "f-" + id
+	}
+	return id
+}
@@ -2,10 +2,26 @@ package tpl
 
 import "fmt"
 
+// FuncMap returns the functions tpl adds to the func map passed to Parse.
+func FuncMap() map[string]any {
+	funcs := make(map[string]any)
+	addTranslationFunctions(funcs)
+	addInternationalizationFunctions(funcs)
+	addHelperFunctions(funcs)
+	return funcs
+}
+
+// enhanceFuncMap adds the functions of tpl your func map doesn't define,
+// yours are never replaced.
 func enhanceFuncMap(fmap map[string]any) {
-	addTranslationFunctions(fmap)
-	addInternationalizationFunctions(fmap)
-	addHelperFunctions(fmap)
+	for name, fn := range FuncMap() {
+		if _, ok := fmap[name]; !ok {
+			fmap[name] = fn
+		}
+	}
+
+	// the ESI partials call it under a name your func map can't shadow
+	fmap[esiFuncName] = ESI
 }
 
 func addTranslationFunctions(fmap map[string]any) {
@@ -13,14 +29,113 @@ func addTranslationFunctions(fmap map[string]any) {
 	fmap["tp"] = TranslatePlural
 	fmap["tf"] = TranslateFormat
 	fmap["tfp"] = TranslateFormatPlural
+	fmap["tm"] = TranslateMessage
+	fmap["tselect"] = TranslateSelect
 }
 
 func addInternationalizationFunctions(fmap map[string]any) {
 	fmap["shortdate"] = ToDate
 	fmap["currency"] = ToCurrency
+	fmap["accounting"] = Accounting
+	fmap["centscurrency"] = CentsCurrency
+	fmap["centsnumber"] = CentsNumber
+	fmap["number"] = Number
+	fmap["ordinal"] = Ordinal
+	fmap["percent"] = Percent
+	fmap["percentvalue"] = PercentValue
+	fmap["unit"] = Unit
+	fmap["abbrevnum"] = AbbrevNum
+	fmap["intword"] = IntWord
+	fmap["intcomma"] = IntComma
+	fmap["filesize"] = FileSize
+	fmap["date"] = FormatDate
+	fmap["time"] = FormatTime
+	fmap["datetime"] = FormatDateTime
+	fmap["monthname"] = MonthName
+	fmap["weekdayname"] = WeekdayName
+	fmap["localtime"] = LocalTime
+	fmap["naturaltime"] = NaturalTime
+	fmap["naturalday"] = NaturalDay
+	fmap["timesince"] = TimeSince
+	fmap["timeuntil"] = TimeUntil
+	fmap["humanduration"] = HumanDuration
+	fmap["readingtime"] = ReadingTime
+	fmap["phone"] = Phone
+	fmap["address"] = FormatAddress
+	fmap["countryname"] = CountryName
+	fmap["langname"] = LangName
+	fmap["dir"] = Dir
+	fmap["bidi"] = Bidi
+	fmap["hreflang"] = Hreflang
+	fmap["alternates"] = Alternates
+	fmap["langurl"] = LangURL
 }
 
 func addHelperFunctions(fmap map[string]any) {
+	fmap["preload"] = Preload
+	fmap["asset"] = Asset
+	fmap["assettags"] = AssetTags
+	fmap["header"] = Header
+	fmap["fragment"] = Fragment
+	fmap["esi"] = ESI
+	fmap["push"] = Push
+	fmap["autolink"] = Autolink
+	fmap["nl2br"] = Nl2br
+	fmap["highlight"] = Highlight
+	fmap["emojify"] = Emojify
+	fmap["striptags"] = StripTags
+	fmap["wordwrap"] = WordWrap
+	fmap["pluralize"] = Pluralize
+	fmap["mask"] = Mask
+	fmap["maskemail"] = MaskEmail
+	fmap["maskcard"] = MaskCard
+	fmap["initials"] = Initials
+	fmap["truncate"] = Truncate
+	fmap["excerpt"] = Excerpt
+	fmap["markdown"] = Markdown
+	fmap["sanitize"] = Sanitize
+	fmap["toc"] = TOC
+	fmap["highlightcode"] = HighlightCode
+	fmap["qrcode"] = QRCode
+	fmap["stack"] = Stack
+	fmap["collect"] = Collect
+	fmap["take"] = Take
+	fmap["chunk"] = Chunk
+	fmap["first"] = First
+	fmap["last"] = Last
+	fmap["reverse"] = Reverse
+	fmap["uniq"] = Uniq
+	fmap["sortby"] = SortBy
+	fmap["groupby"] = GroupBy
+	fmap["get"] = Get
+	fmap["dig"] = Dig
+	fmap["merge"] = MergeMaps
+	fmap["keys"] = Keys
+	fmap["urljoin"] = URLJoin
+	fmap["queryset"] = QuerySet
+	fmap["querydel"] = QueryDel
+	fmap["urlencode"] = URLEncode
+	fmap["json"] = JSON
+	fmap["jsonscript"] = JSONScript
+	fmap["class"] = Class
+	fmap["attrs"] = Attrs
+	fmap["dump"] = Dump
+	fmap["safehtml"] = SafeHTML
+	fmap["safejs"] = SafeJS
+	fmap["safecss"] = SafeCSS
+	fmap["safeurl"] = SafeURL
+	fmap["rangechunked"] = RangeChunked
+	fmap["add"] = Add
+	fmap["sub"] = Sub
+	fmap["mul"] = Mul
+	fmap["div"] = Div
+	fmap["mod"] = Mod
+	fmap["min"] = Min
+	fmap["max"] = Max
+	fmap["round"] = Round
+	fmap["ceil"] = Ceil
+	fmap["floor"] = Floor
+
 	fmap["map"] = func(v ...any) map[string]any {
 		if len(v)%2 != 0 {
 			panic("call to map should have a key and value of even pairs")
@@ -1,9 +1,19 @@
 package tpl_test
 
 import (
+	"bytes"
+	"encoding/base64"
+	"html"
+	"html/template"
+	stdpng "image/png"
+	"log/slog"
+	"reflect"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
+
+	"github.com/dstpierre/tpl"
 )
 
 func TestTranslationFunctions(t *testing.T) {
@@ -13,6 +23,8 @@ func TestTranslationFunctions(t *testing.T) {
 		t.Errorf("can't find hello-world transaltion: %s", body)
 	} else if !strings.Contains(body, "<p>Bonjour personnes</p>") {
 		t.Errorf("plural value not found in %s", body)
+	} else if !strings.Contains(body, "<p>4 nouveaux messages pour vous, Dom</p>") {
+		t.Errorf("named placeholders not replaced in %s", body)
 	}
 }
 
@@ -27,3 +39,1414 @@ func TestInternationalization(t *testing.T) {
 		t.Errorf("can't find Canadian currency formatted: %s", body)
 	}
 }
+
+func TestTranslateMessage(t *testing.T) {
+	templ := load(t)
+	body := render(t, templ, "app/i18n.html")
+	if !strings.Contains(body, "<p>Vous avez 3 articles</p>") {
+		t.Errorf("can't find ICU plural message: %s", body)
+	}
+
+	tests := []struct {
+		msg  string
+		args map[string]any
+		want string
+	}{
+		{"{n, plural, =0 {none} one {# item} other {# items}}", map[string]any{"n": 0}, "none"},
+		{"{n, plural, =0 {none} one {# item} other {# items}}", map[string]any{"n": 1}, "1 item"},
+		{"{g, select, female {She} other {They}} said '{hi}'", map[string]any{"g": "female"}, "She said {hi}"},
+		{"{n, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}", map[string]any{"n": 22}, "22nd"},
+		{"Hello {name}", map[string]any{"name": "Dom"}, "Hello Dom"},
+	}
+	for _, tt := range tests {
+		got, err := tpl.FormatMessage("en", tt.msg, tt.args)
+		if err != nil {
+			t.Fatal(err)
+		} else if got != tt.want {
+			t.Errorf("expected %q got %q", tt.want, got)
+		}
+	}
+}
+
+func TestTranslatePluralRanges(t *testing.T) {
+	load(t)
+
+	tests := map[int64]string{
+		0: "aucun article",
+		1: "un article",
+		3: "quelques articles",
+	}
+	for n, want := range tests {
+		if got := tpl.TranslatePlural("fr", "items", n); got != want {
+			t.Errorf("for %d expected %q got %q", n, want, got)
+		}
+	}
+
+	if got := tpl.TranslateFormatPlural("fr", "items", 12, []any{12}); got != "12 articles" {
+		t.Errorf("expected other value got %q", got)
+	}
+
+	// overlapping ranges are tried by their lower bound, not as strings
+	if got := tpl.TranslatePlural("fr", "seats", 10); got != "plusieurs places" {
+		t.Errorf("expected the range starting first got %q", got)
+	} else if got := tpl.TranslatePlural("fr", "seats", 11); got != "beaucoup de places" {
+		t.Errorf("expected the open range got %q", got)
+	}
+}
+
+func TestPluralRules(t *testing.T) {
+	load(t)
+
+	if got := tpl.TranslatePlural("en", "hello-people", 0); got != "Hello people" {
+		t.Errorf("expected plural for 0 in English got %q", got)
+	} else if got := tpl.TranslatePlural("fr", "hello-people", 0); got != "Bonjour personne" {
+		t.Errorf("expected singular for 0 in French got %q", got)
+	}
+
+	tpl.Set(tpl.Option{
+		TemplateRootName: "testdata",
+		PluralRules: map[string]tpl.PluralRule{
+			"en": func(n int64) bool { return n > 1 },
+		},
+	})
+
+	if got := tpl.TranslatePlural("en", "hello-people", 0); got != "Hello person" {
+		t.Errorf("expected custom rule to return singular got %q", got)
+	}
+}
+
+func TestTranslateSelect(t *testing.T) {
+	load(t)
+
+	if got := tpl.TranslateSelect("fr", "invited-you", "female"); got != "Elle vous a invité" {
+		t.Errorf("expected female variant got %q", got)
+	} else if got := tpl.TranslateSelect("fr", "invited-you", "", map[string]any{"name": "Dom"}); got != "Dom vous a invité" {
+		t.Errorf("expected other variant got %q", got)
+	}
+}
+
+func TestTranslatorContext(t *testing.T) {
+	load(t)
+
+	msg := tpl.GetMessageFromKey("en", "hello-world")
+	if msg.Description != "Heading of the i18n test page" || msg.Comment != "Keep it short" {
+		t.Errorf("translator context not loaded: %+v", msg)
+	}
+}
+
+func TestPseudoLocalize(t *testing.T) {
+	load(t)
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", PseudoLocalize: true})
+
+	if got := tpl.Translate("en", "hello-world"); got != "⟦Ĥéļļö ŵöŕļð····⟧" {
+		t.Errorf("unexpected pseudo-localized text %q", got)
+	} else if got := tpl.TranslateFormatPlural("en", "formatted", 2, []any{2}); got != "⟦Ţĥéŕé'š 2 þéöþļé······⟧" {
+		t.Errorf("unexpected pseudo-localized formatted text %q", got)
+	} else if got := tpl.Translate("en", "welcome", map[string]any{"name": "Bob", "count": 3}); got != "⟦Ŵéļçöɱé ƀáçķ Bob, ýöû ĥáṽé 3 ñéŵ ɱéššáĝéš··············⟧" {
+		t.Errorf("expected the arguments left as they are %q", got)
+	} else if got := tpl.TranslateMessage("en", "cart-items", map[string]any{"count": 2}); got != "⟦Ýöû ĥáṽé 2 îţéɱš······⟧" {
+		t.Errorf("unexpected pseudo-localized ICU message %q", got)
+	} else if got := tpl.NaturalDay("en", time.Now()); got != "⟦ţöðáý··⟧" {
+		t.Errorf("unexpected pseudo-localized built-in text %q", got)
+	} else if got := tpl.Bind("en").T("hello-world"); got != "⟦Ĥéļļö ŵöŕļð····⟧" {
+		t.Errorf("unexpected pseudo-localized Translator text %q", got)
+	}
+}
+
+func TestShowTranslationKeys(t *testing.T) {
+	load(t)
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", DevMode: true, ShowTranslationKeys: true})
+	defer tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	if got := tpl.Translate("en", "hello-world"); got != "Hello world [hello-world]" {
+		t.Errorf("expected the key appended, got %q", got)
+	}
+
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", ShowTranslationKeys: true})
+	if got := tpl.Translate("en", "hello-world"); got != "Hello world" {
+		t.Errorf("expected the key only in DevMode, got %q", got)
+	}
+}
+
+func TestCompleteness(t *testing.T) {
+	load(t)
+
+	if missing := tpl.MissingKeys("fr", "en"); len(missing) != 1 || missing[0] != "only-in-english" {
+		t.Errorf("unexpected missing keys: %v", missing)
+	}
+
+	c := tpl.Completeness()
+	if c["en"] != 1 {
+		t.Errorf("expected en to be complete got %v", c["en"])
+	} else if c["fr"] >= 1 || c["fr"] < 0.8 {
+		t.Errorf("unexpected fr completeness %v", c["fr"])
+	}
+}
+
+func TestHreflang(t *testing.T) {
+	load(t)
+
+	got := string(tpl.Hreflang("https://example.com/en/about?x=1"))
+	want := `<link rel="alternate" hreflang="en" href="https://example.com/en/about?x=1">
+<link rel="alternate" hreflang="fr" href="https://example.com/fr/about?x=1">
+`
+	if got != want {
+		t.Errorf("expected %s got %s", want, got)
+	}
+}
+
+func TestAlternates(t *testing.T) {
+	load(t)
+
+	got := string(tpl.Alternates(tpl.PageData{CurrentURL: "https://example.com/fr/about"}))
+	want := `<link rel="alternate" hreflang="en" href="https://example.com/en/about">
+<link rel="alternate" hreflang="fr" href="https://example.com/fr/about">
+<link rel="alternate" hreflang="x-default" href="https://example.com/en/about">
+`
+	if got != want {
+		t.Errorf("expected %s got %s", want, got)
+	}
+}
+
+func TestLocaleFromLanguageRegistry(t *testing.T) {
+	templ := load(t)
+	tpl.Set(tpl.Option{
+		TemplateRootName: "testdata",
+		Languages:        []tpl.LanguageConfig{{Code: "fr", Locale: "fr-CA"}},
+	})
+
+	data := tpl.PageData{Lang: "fr", Data: pagedata{Date: time.Now(), Amount: 1234.56}}
+
+	var buf bytes.Buffer
+	if err := templ.Render(&buf, "app/i18n.html", data); err != nil {
+		t.Fatal(err)
+	} else if body := buf.String(); !strings.Contains(body, "<em>1234.56 $</em>") {
+		t.Errorf("expected fr-CA currency format: %s", body)
+	}
+}
+
+func TestCurrencyDisplay(t *testing.T) {
+	tests := []struct {
+		locale string
+		opts   []string
+		want   string
+	}{
+		{"en-US", []string{"EUR", "symbol"}, "€1,234.56"},
+		{"en-US", []string{"EUR", "code"}, "EUR\u00a01,234.56"},
+		{"en-US", []string{"EUR", "name"}, "1,234.56 euros"},
+		{"fr-CA", []string{"name"}, "1\u00a0234,56 dollars canadiens"},
+		{"fr-FR", []string{"EUR"}, "1\u00a0234,56\u00a0€"},
+		{"ja-JP", []string{"JPY"}, "¥1,235"},
+		{"en-US", []string{"KRW"}, "₩1,235"},
+		{"en-US", []string{"BHD"}, "BHD\u00a01,234.560"},
+		{"de-DE", []string{"SEK", "code"}, "1.234,56\u00a0SEK"},
+		{"pt-BR", []string{"BRL"}, "R$\u00a01.234,56"},
+	}
+	for _, tt := range tests {
+		if got := tpl.ToCurrency(tt.locale, 1234.56, tt.opts...); got != tt.want {
+			t.Errorf("%s %v: expected %q got %q", tt.locale, tt.opts, tt.want, got)
+		}
+	}
+}
+
+func TestLangURL(t *testing.T) {
+	load(t)
+
+	if got := tpl.LangURL("/en/about?page=2", "fr"); got != "/fr/about?page=2" {
+		t.Errorf("expected prefix URL got %s", got)
+	} else if got := tpl.LangURL("/", "fr"); got != "/fr/" {
+		t.Errorf("expected root prefix URL got %s", got)
+	}
+
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", LangURLMode: "query"})
+	if got := tpl.LangURL("/about?lang=en", "fr"); got != "/about?lang=fr" {
+		t.Errorf("expected query URL got %s", got)
+	}
+}
+
+func TestAutolink(t *testing.T) {
+	got := string(tpl.Autolink("Call +1 (555) 123-4567 or see https://example.com/a?b=1&c=2. Mail <bob@example.com>"))
+	want := `Call <a href="tel:+15551234567">+1 (555) 123-4567</a> or see ` +
+		`<a href="https://example.com/a?b=1&amp;c=2" rel="nofollow noopener">https://example.com/a?b=1&amp;c=2</a>. ` +
+		`Mail &lt;<a href="mailto:bob@example.com">bob@example.com</a>&gt;`
+	if got != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+
+	if got := string(tpl.Autolink("Call 555.123.4567 or (555) 123-4567")); strings.Count(got, `href="tel:5551234567"`) != 2 {
+		t.Errorf("expected the local numbers linked, got %s", got)
+	}
+
+	for _, s := range []string{"Due 2024-01-02", "On 2024/01/02 10:30", "Upgraded to 10.0.19041.1234", "Served by 192.168.100.200"} {
+		if got := string(tpl.Autolink(s)); strings.Contains(got, "<a") {
+			t.Errorf("expected no link in %q, got %s", s, got)
+		}
+	}
+}
+
+func TestTextHelpers(t *testing.T) {
+	if got := string(tpl.Nl2br("Line <1>\r\nLine 2\n")); got != "Line &lt;1&gt;<br>\nLine 2<br>\n" {
+		t.Errorf("unexpected nl2br %q", got)
+	}
+
+	if got := string(tpl.Highlight("Go <templates> for GOPHERS", "go  gophers")); got != "<mark>Go</mark> &lt;templates&gt; for <mark>GOPHERS</mark>" {
+		t.Errorf("unexpected highlight %q", got)
+	}
+
+	if got := string(tpl.Highlight("a.b & c", "b & c")); got != "a.<mark>b</mark> <mark>&amp;</mark> <mark>c</mark>" {
+		t.Errorf("unexpected highlight %q", got)
+	}
+
+	if got := string(tpl.Highlight("<b>x</b>", " ")); got != "&lt;b&gt;x&lt;/b&gt;" {
+		t.Errorf("unexpected highlight without a query %q", got)
+	}
+
+	if got := tpl.Emojify("Shipped :tada::rocket: at 10:30:00, :not_an_emoji:"); got != "Shipped 🎉🚀 at 10:30:00, :not_an_emoji:" {
+		t.Errorf("unexpected emojify %q", got)
+	}
+
+	if got := tpl.StripTags(`<p>Fish &amp; <b>chips</b></p><script>alert(1)</script><style>p{}</style>!`); got != "Fish & chips!" {
+		t.Errorf("unexpected striptags %q", got)
+	}
+
+	got := tpl.WordWrap(12, "The quick brown fox jumps\n\nover a supercalifragilistic dog")
+	want := "The quick\nbrown fox\njumps\n\nover a\nsupercalifragilistic\ndog"
+	if got != want {
+		t.Errorf("expected %q got %q", want, got)
+	}
+}
+
+func TestPluralize(t *testing.T) {
+	tests := []struct {
+		count  any
+		word   string
+		plural []string
+		want   string
+	}{
+		{1, "category", nil, "1 category"},
+		{3, "category", nil, "3 categories"},
+		{0, "day", nil, "0 days"},
+		{2, "Box", nil, "2 Boxes"},
+		{2, "church", nil, "2 churches"},
+		{2, "person", nil, "2 people"},
+		{2, "Leaf", nil, "2 Leaves"},
+		{2, "sheep", nil, "2 sheep"},
+		{2, "URL", nil, "2 URLs"},
+		{2, "City", nil, "2 Cities"},
+		{1.5, "hour", nil, "1.5 hours"},
+		{2, "octopus", []string{"octopi"}, "2 octopi"},
+	}
+	for _, tt := range tests {
+		if got := tpl.Pluralize(tt.count, tt.word, tt.plural...); got != tt.want {
+			t.Errorf("expected %q got %q", tt.want, got)
+		}
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		got, want string
+	}{
+		{tpl.MaskEmail("jane.doe@example.com"), "j•••@example.com"},
+		{tpl.MaskEmail("élodie@example.fr"), "é•••@example.fr"},
+		{tpl.MaskEmail("not-an-email"), "no••••••••il"},
+		{tpl.MaskCard("4242 4242 4242 4242"), "•••• 4242"},
+		{tpl.MaskCard("12"), "••••"},
+		{tpl.Mask("sk_live_abcdef", 4), "sk_l••••••cdef"},
+		{tpl.Mask("abc"), "•••"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("expected %q got %q", tt.want, tt.got)
+		}
+	}
+}
+
+func TestInitials(t *testing.T) {
+	tests := []struct {
+		name string
+		n    []int
+		want string
+	}{
+		{"Jane Doe", nil, "JD"},
+		{"jane mary doe", nil, "JD"},
+		{"élodie  Lefebvre", nil, "ÉL"},
+		{"Madonna", nil, "M"},
+		{"Jane Mary Doe", []int{3}, "JMD"},
+		{"Jane Doe", []int{1}, "J"},
+		{"(Bob) Smith", nil, "BS"},
+		{"", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := tpl.Initials(tt.name, tt.n...); got != tt.want {
+			t.Errorf("%q: expected %q got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		n    int
+		s    string
+		want string
+	}{
+		{20, "short", "short"},
+		{12, "The quick brown fox", "The quick…"},
+		{9, "The quick, brown fox", "The quick…"},
+		{10, "Fish &amp; chips forever", "Fish &amp;…"},
+		{5, "Fish &amp; chips", "Fish…"},
+		{4, "Supercalifragilistic", "Supe…"},
+		{5, "Ça été très long", "Ça…"},
+	}
+	for _, tt := range tests {
+		if got := tpl.Truncate(tt.n, tt.s); got != tt.want {
+			t.Errorf("truncate %d %q: expected %q got %q", tt.n, tt.s, tt.want, got)
+		}
+	}
+
+	body := "<h1>Title</h1>\n<p>Some <b>bold</b>   text &amp; more words here.</p>"
+	if got := tpl.Excerpt(body); got != "Title Some bold text & more words here." {
+		t.Errorf("unexpected excerpt %q", got)
+	} else if got := tpl.Excerpt(body, 16); got != "Title Some bold…" {
+		t.Errorf("unexpected excerpt %q", got)
+	}
+}
+
+func TestNumber(t *testing.T) {
+	tests := []struct {
+		locale string
+		v      any
+		dec    []int
+		want   string
+	}{
+		{"en-US", 1234567.891, nil, "1,234,567.891"},
+		{"fr-CA", 1234.5, []int{2}, "1\u00a0234,50"},
+		{"de-DE", 1234, nil, "1.234"},
+		{"hi-IN", 1234567, nil, "12,34,567"},
+		{"en-US", 1.5, []int{-2}, "2"},
+		{"en-US", int64(9007199254740993), nil, "9,007,199,254,740,993"},
+		{"en-US", uint64(18446744073709551615), nil, "18,446,744,073,709,551,615"},
+	}
+	for _, tt := range tests {
+		if got := tpl.Number(tt.locale, tt.v, tt.dec...); got != tt.want {
+			t.Errorf("%s: expected %q got %q", tt.locale, tt.want, got)
+		}
+	}
+}
+
+func TestDateTimeFormatting(t *testing.T) {
+	d := time.Date(2024, time.March, 5, 14, 7, 9, 0, time.UTC)
+
+	tests := []struct {
+		fn     func(string, time.Time, ...string) string
+		locale string
+		style  string
+		want   string
+	}{
+		{tpl.FormatDate, "en-US", "short", "3/5/24"},
+		{tpl.FormatDate, "en-US", "", "Mar 5, 2024"},
+		{tpl.FormatDate, "fr-CA", "full", "mardi 5 mars 2024"},
+		{tpl.FormatDate, "de-DE", "long", "5. März 2024"},
+		{tpl.FormatDate, "es-ES", "yMMMMd", "5 de marzo de 2024"},
+		{tpl.FormatDate, "en-US", "EEE d", "Tue 5"},
+		{tpl.FormatTime, "en-US", "short", "2:07 PM"},
+		{tpl.FormatTime, "fr-CA", "short", "14 h 07"},
+		{tpl.FormatDateTime, "en-US", "long", "March 5, 2024 at 2:07:09 PM UTC"},
+		{tpl.FormatDateTime, "fr-FR", "short", "05/03/2024 14:07"},
+	}
+	for _, tt := range tests {
+		if got := tt.fn(tt.locale, d, tt.style); got != tt.want {
+			t.Errorf("%s %s: expected %q got %q", tt.locale, tt.style, tt.want, got)
+		}
+	}
+}
+
+func TestLocalTime(t *testing.T) {
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", Timezone: "America/Toronto"})
+	defer tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	d := time.Date(2024, time.March, 5, 2, 30, 0, 0, time.UTC)
+
+	if got := tpl.LocalTime(tpl.PageData{}, d).Format("15:04"); got != "21:30" {
+		t.Errorf("fallback zone: expected 21:30 got %s", got)
+	}
+
+	pd := tpl.PageData{Timezone: "Asia/Tokyo"}
+	if got := tpl.LocalTime(pd, d).Format("15:04"); got != "11:30" {
+		t.Errorf("viewer zone: expected 11:30 got %s", got)
+	}
+
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	for i := 0; i < 3; i++ {
+		if got := tpl.LocalTime(tpl.PageData{Timezone: "Mars/Olympus_Mons"}, d).Format("15:04"); got != "21:30" {
+			t.Errorf("unknown zone: expected the fallback 21:30 got %s", got)
+		}
+	}
+	if n := strings.Count(logs.String(), "loading time zone"); n != 1 {
+		t.Errorf("expected the unknown zone logged once, got %d: %s", n, logs.String())
+	}
+
+	if got := tpl.ToDate("en-US", d, ""); got != "03-04-2024" {
+		t.Errorf("shortdate with fallback zone: expected 03-04-2024 got %s", got)
+	}
+
+	if got := tpl.ToDate("en-US", d); got != "03-05-2024" {
+		t.Errorf("shortdate without zone: expected 03-05-2024 got %s", got)
+	}
+}
+
+func TestNaturalTime(t *testing.T) {
+	load(t)
+
+	tests := []struct {
+		lang string
+		t    time.Time
+		want string
+	}{
+		{"en", time.Now().Add(-5*time.Minute - time.Second), "5 minutes ago"},
+		{"en", time.Now().Add(-time.Hour - time.Second), "1 hour ago"},
+		{"en", time.Now().Add(3*24*time.Hour + time.Minute), "in 3 days"},
+		{"en", time.Now(), "just now"},
+		{"fr", time.Now().Add(-2*time.Hour - time.Second), "il y a 2 heures"},
+		{"fr", time.Now().Add(-400 * 24 * time.Hour), "il y a 1 an"},
+		{"en", time.Time{}, ""},
+	}
+	for _, tt := range tests {
+		if got := tpl.NaturalTime(tt.lang, tt.t); got != tt.want {
+			t.Errorf("%s: expected %q got %q", tt.lang, tt.want, got)
+		}
+	}
+}
+
+func TestNowOption(t *testing.T) {
+	clock := time.Date(2024, time.March, 4, 12, 0, 0, 0, time.UTC)
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", Now: func() time.Time { return clock }})
+	defer tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	if got := tpl.NaturalTime("en", clock.Add(-2*time.Hour)); got != "2 hours ago" {
+		t.Errorf("expected the time relative to the Now option, got %q", got)
+	}
+}
+
+func TestNaturalDay(t *testing.T) {
+	load(t)
+	clock := time.Date(2024, time.March, 4, 2, 0, 0, 0, time.UTC)
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", Now: func() time.Time { return clock }})
+	defer tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{tpl.NaturalDay("en-US", clock.Add(20*time.Hour)), "today"},
+		{tpl.NaturalDay("en-US", clock.Add(-3*time.Hour)), "yesterday"},
+		{tpl.NaturalDay("fr-CA", clock.Add(24*time.Hour)), "demain"},
+		{tpl.NaturalDay("en-US", clock.AddDate(0, 0, -5)), "02-28-2024"},
+		// it's still March 3 in Toronto
+		{tpl.NaturalDay("fr-CA", clock.Add(-3*time.Hour), "America/Toronto"), "aujourd'hui"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("expected %q got %q", tt.want, tt.got)
+		}
+	}
+}
+
+func TestMonthWeekdayName(t *testing.T) {
+	d := time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{tpl.MonthName("fr-CA", 1), "janvier"},
+		{tpl.MonthName("de-DE", time.March), "März"},
+		{tpl.MonthName("en-US", d, "short"), "Mar"},
+		{tpl.MonthName("en-US", 13), ""},
+		{tpl.WeekdayName("de-DE", 1), "Montag"},
+		{tpl.WeekdayName("fr-CA", d, "short"), "lun."},
+		{tpl.WeekdayName("es-ES", time.Saturday), "sábado"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("expected %q got %q", tt.want, tt.got)
+		}
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	tests := []struct {
+		locale string
+		n      any
+		want   string
+	}{
+		{"en-US", 1, "1st"},
+		{"en-US", 2, "2nd"},
+		{"en-US", 3, "3rd"},
+		{"en-US", 11, "11th"},
+		{"en-US", 22, "22nd"},
+		{"en-US", 1001, "1,001st"},
+		{"fr-CA", 1, "1er"},
+		{"fr-CA", 2, "2e"},
+		{"de-DE", 3, "3."},
+		{"xx", int64(4), "4th"},
+	}
+	for _, tt := range tests {
+		if got := tpl.Ordinal(tt.locale, tt.n); got != tt.want {
+			t.Errorf("%s %v: expected %q got %q", tt.locale, tt.n, tt.want, got)
+		}
+	}
+}
+
+func TestAccounting(t *testing.T) {
+	if got := tpl.ToCurrency("en-US", -1234.56, "accounting"); got != "($1,234.56)" {
+		t.Errorf("expected ($1,234.56) got %s", got)
+	}
+
+	if got := tpl.ToCurrency("fr-FR", -5, "EUR", "accounting"); got != "(5,00\u00a0€)" {
+		t.Errorf("expected (5,00 €) got %s", got)
+	}
+
+	if got := tpl.Accounting("en-US", -1234.56); got != `<span class="negative">($1,234.56)</span>` {
+		t.Errorf("unexpected negative accounting output %s", got)
+	}
+
+	if got := tpl.Accounting("en-US", 10, "EUR"); got != "€10.00" {
+		t.Errorf("expected €10.00 got %s", got)
+	}
+}
+
+func TestCentsCurrency(t *testing.T) {
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{tpl.CentsCurrency("en-US", int64(123456), "USD"), "$1,234.56"},
+		{tpl.CentsCurrency("en-US", 5, "USD"), "$0.05"},
+		{tpl.CentsCurrency("fr-FR", -995, "EUR"), "-9,95\u00a0€"},
+		{tpl.CentsCurrency("ja-JP", 1234, "JPY"), "¥1,234"},
+		{tpl.CentsCurrency("en-US", 100, "EUR", "name"), "1.00 euro"},
+		{tpl.CentsCurrency("en-US", -250, "USD", "accounting"), "($2.50)"},
+		{tpl.CentsCurrency("en-US", int64(9007199254740993), "USD"), "$90,071,992,547,409.93"},
+		{tpl.CentsNumber("fr-CA", 123456, "CAD"), "1\u00a0234,56"},
+		{tpl.CentsNumber("en-US", -1), "-0.01"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("expected %q got %q", tt.want, tt.got)
+		}
+	}
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{tpl.Percent("en-US", 0.125), "12.5%"},
+		{tpl.Percent("fr-CA", 0.125), "12,5\u00a0%"},
+		{tpl.Percent("en-US", 0.5, 2), "50.00%"},
+		{tpl.Percent("de-DE", 0.12345), "12,35\u00a0%"},
+		{tpl.PercentValue("en-US", 12.5), "12.5%"},
+		{tpl.PercentValue("fr-FR", 80, 1), "80,0\u00a0%"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("expected %q got %q", tt.want, tt.got)
+		}
+	}
+}
+
+func TestUnit(t *testing.T) {
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{tpl.Unit("fr-CA", 5.26, "km"), "5,3\u00a0km"},
+		{tpl.Unit("en-US", 5, "km"), "5\u00a0km"},
+		{tpl.Unit("en-US", 5, "km", "convert"), "3.1\u00a0mi"},
+		{tpl.Unit("en-CA", 5, "km", "convert"), "5\u00a0km"},
+		{tpl.Unit("fr-FR", 10, "lb", "convert", 2), "4,54\u00a0kg"},
+		{tpl.Unit("en-US", 21, "celsius", "convert"), "69.8°F"},
+		{tpl.Unit("fr-FR", 21, "c"), "21\u00a0°C"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("expected %q got %q", tt.want, tt.got)
+		}
+	}
+}
+
+func TestAbbrevNum(t *testing.T) {
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{tpl.AbbrevNum("en-US", 999), "999"},
+		{tpl.AbbrevNum("en-US", 1234), "1.2K"},
+		{tpl.AbbrevNum("en-US", 1000), "1K"},
+		{tpl.AbbrevNum("fr-CA", 3400000), "3,4 M"},
+		{tpl.AbbrevNum("de-DE", 2500000000, 2), "2,5 Mrd."},
+		{tpl.AbbrevNum("en-US", -15300), "-15.3K"},
+		{tpl.AbbrevNum("en", 999999), "1M"},
+		{tpl.AbbrevNum("en", 999950), "1M"},
+		{tpl.AbbrevNum("en", 999949), "999.9K"},
+		{tpl.AbbrevNum("en", -999999), "-1M"},
+		{tpl.AbbrevNum("en", 999999, 0), "1M"},
+		{tpl.IntWord("en", 999999), "1 million"},
+		{tpl.IntWord("en", 999999999), "1 billion"},
+		{tpl.IntWord("en-US", 1200000), "1.2 million"},
+		{tpl.IntWord("fr-CA", 2500000), "2,5 millions"},
+		{tpl.IntWord("fr-CA", 1200000), "1,2 million"},
+		{tpl.IntWord("en-US", 3000000000000), "3 trillion"},
+		{tpl.IntComma(1234567), "1,234,567"},
+		{tpl.IntComma(1234567, "fr-CA"), "1\u00a0234\u00a0567"},
+		{tpl.IntComma(1234567, "de-DE"), "1.234.567"},
+		{tpl.IntComma(int64(9007199254740993)), "9,007,199,254,740,993"},
+		{tpl.IntComma(uint64(18446744073709551615), "de-DE"), "18.446.744.073.709.551.615"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("expected %q got %q", tt.want, tt.got)
+		}
+	}
+}
+
+func TestFileSize(t *testing.T) {
+	tests := []struct {
+		locale string
+		v      any
+		mode   []string
+		want   string
+	}{
+		{"en-US", 512, nil, "512 B"},
+		{"en-US", 1536, nil, "1.5 KiB"},
+		{"en-US", 2 * 1024 * 1024, nil, "2 MiB"},
+		{"en-US", 1500000, []string{"si"}, "1.5 MB"},
+		{"en-US", int64(250e9), []string{"si"}, "250 GB"},
+		{"fr-FR", 1536, nil, "1,5 KiB"},
+		{"de-DE", 1234567, []string{"si"}, "1,2 MB"},
+		{"en-US", -2048, nil, "-2 KiB"},
+		{"en-US", 1023, nil, "1,023 B"},
+		{"en-US", 1048575, nil, "1 MiB"},
+		{"en-US", 1024*1024*1024 - 1, nil, "1 GiB"},
+		{"en-US", int64(1)<<40 - 1, nil, "1 TiB"},
+		{"en-US", 999999, []string{"si"}, "1 MB"},
+		{"en-US", -1048575, nil, "-1 MiB"},
+	}
+	for _, tt := range tests {
+		if got := tpl.FileSize(tt.locale, tt.v, tt.mode...); got != tt.want {
+			t.Errorf("%s %v: expected %q got %q", tt.locale, tt.v, tt.want, got)
+		}
+	}
+}
+
+func TestDisplayNames(t *testing.T) {
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{tpl.CountryName("en-US", "DE"), "Germany"},
+		{tpl.CountryName("fr-CA", "de"), "Allemagne"},
+		{tpl.CountryName("en-US", "invalid"), "invalid"},
+		{tpl.LangName("en-US", "de"), "German"},
+		{tpl.LangName("fr-CA", "de"), "allemand"},
+		{tpl.LangName("es", "es"), "español"},
+		{tpl.CountryName("", "DE"), "Germany"},
+		{tpl.CountryName("xx", "DE"), "Germany"},
+		{tpl.LangName("", "de"), "German"},
+		{tpl.LangName("xx", "de"), "German"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("expected %q got %q", tt.want, tt.got)
+		}
+	}
+}
+
+func TestPhone(t *testing.T) {
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{tpl.Phone("CA", "5145550123"), "(514) 555-0123"},
+		{tpl.Phone("fr-CA", "1-514-555-0123", "international"), "+1 514-555-0123"},
+		{tpl.Phone("FR", "0123456789"), "01 23 45 67 89"},
+		{tpl.Phone("FR", "01.23.45.67.89", "international"), "+33 1 23 45 67 89"},
+		{tpl.Phone("US", "+33 1 23 45 67 89"), "01 23 45 67 89"},
+		{tpl.Phone("GB", "02079460958"), "020 7946 0958"},
+		{tpl.Phone("GB", "07700900123", "international"), "+44 7700 900123"},
+		{tpl.Phone("CA", "12345"), "12345"},
+		{tpl.Phone("ZZ", "0123456789"), "0123456789"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("expected %q got %q", tt.want, tt.got)
+		}
+	}
+}
+
+func TestDirBidi(t *testing.T) {
+	tpl.Set(tpl.Option{Languages: []tpl.LanguageConfig{{Code: "en"}, {Code: "xx", Direction: "rtl"}}})
+	defer tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{tpl.Dir("en"), "ltr"},
+		{tpl.Dir("ar"), "rtl"},
+		{tpl.Dir("he-IL"), "rtl"},
+		{tpl.Dir("xx"), "rtl"},
+		{tpl.Bidi("שלום"), "\u2068שלום\u2069"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("expected %q got %q", tt.want, tt.got)
+		}
+	}
+}
+
+func TestAddress(t *testing.T) {
+	us := tpl.Address{
+		Name:       "Jane Doe",
+		Street:     []string{"350 Fifth Avenue", "Suite 100"},
+		City:       "New York",
+		Region:     "NY",
+		PostalCode: "10118",
+		Country:    "US",
+	}
+
+	if got := tpl.FormatAddress("en-US", us); got != "Jane Doe<br>350 Fifth Avenue<br>Suite 100<br>New York, NY 10118" {
+		t.Errorf("unexpected US address %q", got)
+	}
+
+	fr := tpl.Address{
+		Name:         "Marie Curie",
+		Organization: "R&D",
+		Street:       []string{"12 rue de Rivoli"},
+		City:         "Paris",
+		PostalCode:   "75004",
+		Country:      "FR",
+	}
+
+	if got := tpl.FormatAddress("en-CA", fr); got != "Marie Curie<br>R&amp;D<br>12 rue de Rivoli<br>75004 Paris<br>FRANCE" {
+		t.Errorf("unexpected FR address %q", got)
+	}
+
+	if got := tpl.FormatAddress("", fr); got != "Marie Curie<br>R&amp;D<br>12 rue de Rivoli<br>75004 Paris<br>FRANCE" {
+		t.Errorf("unexpected FR address without a locale %q", got)
+	}
+
+	us.City = ""
+	if lines := us.Lines("en-US"); lines[len(lines)-1] != "NY 10118" {
+		t.Errorf("expected the separator of the missing city removed, got %q", lines)
+	}
+}
+
+func BenchmarkTranslate(b *testing.B) {
+	tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+	if _, err := tpl.Parse(fsTest, fmap); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tpl.Translate("en", "hello-world")
+	}
+}
+
+func BenchmarkTranslatePlural(b *testing.B) {
+	tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+	if _, err := tpl.Parse(fsTest, fmap); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tpl.TranslatePlural("fr", "formatted", int64(i%3))
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	load(t)
+
+	body := "<p>" + strings.Repeat("word ", 1000) + "</p><script>" + strings.Repeat("skip ", 1000) + "</script>"
+	if got := tpl.ReadingTime("en", body); got != "5 min read" {
+		t.Errorf("expected 5 min read got %s", got)
+	} else if got := tpl.ReadingTime("fr", "Court"); got != "1 min de lecture" {
+		t.Errorf("expected 1 min de lecture got %s", got)
+	}
+
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", WordsPerMinute: 100})
+	defer tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	if got := tpl.ReadingTime("en", body); got != "10 min read" {
+		t.Errorf("expected the WordsPerMinute option used, got %s", got)
+	}
+}
+
+func TestHumanDuration(t *testing.T) {
+	load(t)
+
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{tpl.HumanDuration("en", 2*time.Hour+15*time.Minute+36*time.Second), "2h 15m"},
+		{tpl.HumanDuration("en", 8136, "compact"), "02:15:36"},
+		{tpl.HumanDuration("en", 3*24*time.Hour+5*time.Minute, "long"), "3 days"},
+		{tpl.HumanDuration("fr", 2*time.Hour+time.Minute, "long"), "2 heures 1 minute"},
+		{tpl.HumanDuration("en", 45.4), "45s"},
+		{tpl.HumanDuration("en", -90*time.Second), "-1m 30s"},
+		{tpl.HumanDuration("en", 0), "0s"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("expected %q got %q", tt.want, tt.got)
+		}
+	}
+
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", DevMode: true, ShowTranslationKeys: true})
+	defer tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	if got := tpl.HumanDuration("en", 2*time.Hour+time.Minute, "long"); got != "2 hours [naturaltime-hour] 1 minute [naturaltime-minute]" {
+		t.Errorf("expected the long units presented like translations, got %q", got)
+	}
+}
+
+func TestTranslator(t *testing.T) {
+	load(t)
+
+	tr := tpl.Bind("fr")
+	if got, want := tr.T("hello-world"), tpl.Translate("fr", "hello-world"); got != want {
+		t.Errorf("expected %q got %q", want, got)
+	}
+
+	if got, want := tr.TP("formatted", 2), tpl.TranslatePlural("fr", "formatted", 2); got != want {
+		t.Errorf("expected %q got %q", want, got)
+	}
+
+	if got := tr.T("missing-key"); got != "not found" {
+		t.Errorf("expected a missing key to be not found, got %q", got)
+	}
+}
+
+func BenchmarkTranslator(b *testing.B) {
+	tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+	if _, err := tpl.Parse(fsTest, fmap); err != nil {
+		b.Fatal(err)
+	}
+
+	tr := tpl.Bind("en")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tr.T("hello-world")
+	}
+}
+
+func TestTimeSinceUntil(t *testing.T) {
+	load(t)
+	clock := time.Date(2024, time.March, 4, 12, 0, 0, 0, time.UTC)
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", Now: func() time.Time { return clock }})
+	defer tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{tpl.TimeSince("en", clock.Add(-3*24*time.Hour)), "3 days"},
+		{tpl.TimeSince("fr", clock.Add(-2*time.Hour)), "2 heures"},
+		{tpl.TimeSince("en", clock.Add(-30*time.Second)), "30 seconds"},
+		{tpl.TimeSince("en", clock.Add(time.Hour)), "0 seconds"},
+		{tpl.TimeUntil("en", clock.Add(2*time.Hour+time.Minute)), "2 hours"},
+		{tpl.TimeUntil("fr", clock.Add(time.Minute)), "1 minute"},
+		{tpl.TimeSince("en", time.Time{}), ""},
+		{tpl.TimeUntil("en", time.Time{}), ""},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("expected %q got %q", tt.want, tt.got)
+		}
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	got := string(tpl.Markdown("# Title\n\nSome **bold** and a [link](https://example.com).\n\n<script>alert(1)</script>"))
+
+	if !strings.Contains(got, "<h1>Title</h1>") || !strings.Contains(got, "<strong>bold</strong>") {
+		t.Errorf("expected the markdown rendered: %s", got)
+	} else if !strings.Contains(got, `href="https://example.com"`) {
+		t.Errorf("expected the link kept: %s", got)
+	} else if strings.Contains(got, "<script>") {
+		t.Errorf("expected the script removed: %s", got)
+	}
+
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", MarkdownSanitizer: strings.ToUpper})
+	defer tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	if got := string(tpl.Markdown("*hi*")); got != "<P><EM>HI</EM></P>\n" {
+		t.Errorf("expected the MarkdownSanitizer option used, got %q", got)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	got := string(tpl.Sanitize(`<p onclick="x()">Hi <b>there</b><script>alert(1)</script> <a href="javascript:x()">a</a></p>`))
+	if got != "<p>Hi <b>there</b> a</p>" {
+		t.Errorf("unexpected sanitized HTML %q", got)
+	}
+
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", Sanitizer: strings.ToUpper})
+	defer tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	if got := string(tpl.Sanitize("<b>hi</b>")); got != "<B>HI</B>" {
+		t.Errorf("expected the Sanitizer option used, got %q", got)
+	} else if got := string(tpl.Markdown("*hi*")); got != "<P><EM>HI</EM></P>\n" {
+		t.Errorf("expected the markdown func to use the Sanitizer, got %q", got)
+	}
+}
+
+func TestTOC(t *testing.T) {
+	md := "# Guide\n\n## Getting started\n\n### Install\n\n### Configure\n\n## FAQ & help\n\n## Getting started\n"
+	toc := tpl.TOC(md)
+
+	expected := []tpl.Heading{
+		{ID: "getting-started", Text: "Getting started", Level: 2, Children: []tpl.Heading{
+			{ID: "install", Text: "Install", Level: 3},
+			{ID: "configure", Text: "Configure", Level: 3},
+		}},
+		{ID: "faq--help", Text: "FAQ & help", Level: 2},
+		{ID: "getting-started-1", Text: "Getting started", Level: 2},
+	}
+	if !reflect.DeepEqual(toc, expected) {
+		t.Errorf("expected %+v got %+v", expected, toc)
+	}
+
+	toc = tpl.TOC(template.HTML(`<h3>Intro</h3><h2 id="custom">Custom <em>id</em></h2><h2>Über uns</h2>`))
+	expected = []tpl.Heading{
+		{ID: "intro", Text: "Intro", Level: 3},
+		{ID: "custom", Text: "Custom id", Level: 2},
+		{ID: "ber-uns", Text: "Über uns", Level: 2},
+	}
+	if !reflect.DeepEqual(toc, expected) {
+		t.Errorf("expected %+v got %+v", expected, toc)
+	}
+}
+
+func TestHighlightCode(t *testing.T) {
+	got := string(tpl.HighlightCode("go", `func main() { x := "<b>" }`))
+	if !strings.Contains(got, `<span style="color:#000;font-weight:bold">func</span>`) {
+		t.Errorf("expected the keyword highlighted: %s", got)
+	} else if !strings.Contains(got, "&lt;b&gt;") {
+		t.Errorf("expected the code escaped: %s", got)
+	}
+
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", CodeStyle: "monokai"})
+	defer tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	if styled := string(tpl.HighlightCode("go", "func main() {}")); styled == got || !strings.Contains(styled, "background-color:#272822") {
+		t.Errorf("expected the CodeStyle option used: %s", styled)
+	}
+}
+
+type invoiceLine struct {
+	Name     string
+	Category string
+	Amount   float64
+	Date     time.Time
+}
+
+func (l invoiceLine) Label() string { return l.Category + "/" + l.Name }
+
+func TestCollectionHelpers(t *testing.T) {
+	templ := load(t)
+
+	d := time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)
+	lines := []invoiceLine{
+		{"Pen", "Office", 3, d},
+		{"Laptop", "Hardware", 1200, d.AddDate(0, 0, -2)},
+		{"Paper", "Office", 8.5, d.AddDate(0, 0, 1)},
+		{"Mouse", "Hardware", 25, d.AddDate(0, 0, -1)},
+	}
+
+	src := `{{ (first .Data).Name }} {{ (last .Data).Name }} ` +
+		`{{ range reverse .Data }}{{ .Name }},{{ end }} ` +
+		`{{ range sortby "Amount" .Data }}{{ .Name }},{{ end }} ` +
+		`{{ range sortby "-Date" .Data }}{{ .Name }},{{ end }} ` +
+		`{{ range sortby "Label" .Data }}{{ .Name }},{{ end }} ` +
+		`{{ range groupby "Category" .Data }}{{ .Key }}:{{ range .Items }}{{ .Name }},{{ end }}{{ end }}`
+
+	var buf bytes.Buffer
+	if err := templ.RenderInline(&buf, src, tpl.PageData{Data: lines}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Pen Mouse Mouse,Paper,Laptop,Pen, Pen,Paper,Mouse,Laptop, Paper,Pen,Mouse,Laptop, " +
+		"Laptop,Mouse,Paper,Pen, Office:Pen,Paper,Hardware:Laptop,Mouse,"
+	if got := buf.String(); got != expected {
+		t.Errorf("expected\n%q got\n%q", expected, got)
+	}
+
+	if got, err := tpl.Uniq([]string{"a", "b", "a", "c", "b"}); err != nil || !reflect.DeepEqual(got, []any{"a", "b", "c"}) {
+		t.Errorf("unexpected uniq %v %v", got, err)
+	}
+
+	if _, err := tpl.SortBy("Missing", lines); err == nil {
+		t.Error("expected an error for a missing field")
+	}
+
+	m := []map[string]any{{"n": 2}, {"n": 1}, {}}
+	if got, err := tpl.SortBy("n", m); err != nil || len(got) != 3 || got[0].(map[string]any)["n"] != nil || got[1].(map[string]any)["n"] != 1 {
+		t.Errorf("expected the maps sorted by key with the missing first, got %v %v", got, err)
+	}
+}
+
+func TestDictHelpers(t *testing.T) {
+	templ := load(t)
+
+	data := map[string]any{
+		"user":     map[string]any{"name": "Dominic", "address": map[string]string{"city": "Montréal"}},
+		"settings": map[string]any(nil),
+	}
+
+	src := `{{ get .Data.user "name" }} {{ get .Data.settings "theme" }} ` +
+		`{{ dig "user" "address" "city" "?" .Data }} {{ dig "user" "phone" "none" .Data }} {{ .Data | dig "user" "name" "" }} ` +
+		`{{ with merge (map "size" "md" "kind" "primary") (map "kind" "danger") }}{{ range keys . }}{{ . }}={{ get $.Data.user "name" }}{{ end }} {{ .kind }}{{ end }}`
+
+	var buf bytes.Buffer
+	if err := templ.RenderInline(&buf, src, tpl.PageData{Data: data}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Dominic  Montréal none Dominic kind=Dominicsize=Dominic danger"
+	if got := buf.String(); got != expected {
+		t.Errorf("expected %q got %q", expected, got)
+	}
+
+	defaults := map[string]any{"a": 1}
+	if _, err := tpl.MergeMaps(defaults, map[string]any{"a": 2}); err != nil || defaults["a"] != 1 {
+		t.Errorf("expected the maps left unchanged, got %v %v", defaults, err)
+	} else if _, err := tpl.Keys([]int{1}); err == nil {
+		t.Error("expected an error for a slice")
+	}
+}
+
+func TestSafeHelpers(t *testing.T) {
+	templ := load(t)
+
+	data := map[string]string{
+		"html": "<b>bold</b>",
+		"js":   `{"debug": true}`,
+		"css":  "color: red; background: url(x.png)",
+		"url":  "myapp://open?id=1",
+	}
+
+	src := `{{ safehtml .Data.html }}<script>var c = {{ safejs .Data.js }};</script>` +
+		`<div style="{{ safecss .Data.css }}"><a href="{{ safeurl .Data.url }}"></a><a href="{{ .Data.url }}"></a>`
+
+	var buf bytes.Buffer
+	if err := templ.RenderInline(&buf, src, tpl.PageData{Data: data}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<b>bold</b><script>var c = {"debug": true};</script>` +
+		`<div style="color: red; background: url(x.png)"><a href="myapp://open?id=1"></a><a href="#ZgotmplZ"></a>`
+	if got := buf.String(); got != expected {
+		t.Errorf("expected\n%s got\n%s", expected, got)
+	}
+}
+
+func TestURLHelpers(t *testing.T) {
+	tests := []struct {
+		got, want string
+	}{
+		{tpl.URLJoin("/products/", "shoes & boots", 42), "/products/shoes%20&%20boots/42"},
+		{tpl.URLJoin("https://example.com/api?v=2", "/users/", "a/b"), "https://example.com/api/users/a%2Fb?v=2"},
+		{tpl.URLJoin("", "docs"), "/docs"},
+		{tpl.QuerySet("page", 2, "/list?sort=name&page=1"), "/list?page=2&sort=name"},
+		{tpl.QuerySet("q", "a&b", "/search"), "/search?q=a%26b"},
+		{tpl.QueryDel("color", "size", "/list?color=red&size=m&sort=name#top"), "/list?sort=name#top"},
+		{tpl.URLEncode("tom & jerry"), "tom+%26+jerry"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("expected %s got %s", tt.want, tt.got)
+		}
+	}
+
+	templ := load(t)
+
+	var buf bytes.Buffer
+	src := `<a href="{{ .CurrentURL | queryset "page" 3 }}">3</a><a href="{{ .CurrentURL | querydel "page" }}">x</a>`
+	if err := templ.RenderInline(&buf, src, tpl.PageData{CurrentURL: "/list?page=2&sort=name"}); err != nil {
+		t.Fatal(err)
+	} else if got := buf.String(); got != `<a href="/list?page=3&amp;sort=name">3</a><a href="/list?sort=name">x</a>` {
+		t.Errorf("unexpected links %s", got)
+	}
+}
+
+func TestJSONHelpers(t *testing.T) {
+	templ := load(t)
+
+	data := map[string]any{"name": "</script><script>alert(1)</script>", "n": 1}
+	src := `<div data-x="{{ json .Data }}"></div><script>var x = {{ json .Data }};</script>{{ jsonscript "state" .Data }}`
+
+	var buf bytes.Buffer
+	if err := templ.RenderInline(&buf, src, tpl.PageData{Data: data}); err != nil {
+		t.Fatal(err)
+	}
+
+	encoded := `{"n":1,"name":"\u003c/script\u003e\u003cscript\u003ealert(1)\u003c/script\u003e"}`
+	expected := `<div data-x="{&#34;n&#34;:1,&#34;name&#34;:&#34;\u003c/script\u003e\u003cscript\u003ealert(1)\u003c/script\u003e&#34;}"></div>` +
+		`<script>var x = ` + encoded + `;</script>` +
+		`<script type="application/json" id="state">` + encoded + `</script>`
+	if got := buf.String(); got != expected {
+		t.Errorf("expected\n%s got\n%s", expected, got)
+	}
+
+	if _, err := tpl.JSON(func() {}); err == nil {
+		t.Error("expected an error for a func")
+	}
+}
+
+func TestDump(t *testing.T) {
+	templ := load(t)
+
+	type user struct {
+		Name  string
+		Tags  []string
+		Prefs map[string]int
+		Next  *user
+		notes string
+	}
+	u := &user{Name: "<Zoé>", Tags: []string{"a"}, Prefs: map[string]int{"b": 2, "a": 1}, notes: "hidden"}
+	u.Next = u
+
+	render := func(env string, src string) string {
+		var buf bytes.Buffer
+		if err := templ.RenderInline(&buf, src, tpl.PageData{Env: env, Data: u}); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	if got := render("prod", `{{ dump $ .Data }}`); got != "" {
+		t.Errorf("expected nothing in production, got %s", got)
+	}
+
+	expected := `<pre class="tpl-dump">&amp;tpl_test.user {
+  Name: &#34;&lt;Zoé&gt;&#34;
+  Tags: []string [
+    &#34;a&#34;
+  ]
+  Prefs: map[string]int {
+    &#34;a&#34;: 1
+    &#34;b&#34;: 2
+  }
+  Next: &lt;cycle&gt;
+}</pre>`
+	if got := render("dev", `{{ dump $ .Data }}`); got != expected {
+		t.Errorf("expected\n%s got\n%s", expected, got)
+	}
+
+	if got := render("local", `{{ dump $ .Data.Tags "comment" }}`); got != "<!--\n[]string [\n  \"a\"\n]\n-->" {
+		t.Errorf("unexpected comment dump %q", got)
+	}
+}
+
+func TestClass(t *testing.T) {
+	templ := load(t)
+
+	src := `<li class="{{ class "item" "active" (eq .Data 2) "disabled" false "item  wide" }}"></li>`
+
+	var buf bytes.Buffer
+	if err := templ.RenderInline(&buf, src, tpl.PageData{Data: 2}); err != nil {
+		t.Fatal(err)
+	} else if got := buf.String(); got != `<li class="item active wide"></li>` {
+		t.Errorf("unexpected class %s", got)
+	}
+
+	if got, err := tpl.Class(map[string]bool{"b": true, "a": true, "c": false}, "x"); err != nil || got != "a b x" {
+		t.Errorf("unexpected class from a map %q %v", got, err)
+	} else if _, err := tpl.Class(1); err == nil {
+		t.Error("expected an error for a number")
+	}
+}
+
+func TestAttrs(t *testing.T) {
+	templ := load(t)
+
+	src := `<button {{ attrs "data-id" 42 "aria-label" .Data "disabled" true "hidden" false "title" "" }}></button>` +
+		`<a {{ attrs (map "href" "javascript:alert(1)" "data-x" "y") }}></a>`
+
+	var buf bytes.Buffer
+	if err := templ.RenderInline(&buf, src, tpl.PageData{Data: `Say "hi" <now>`}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<button data-id="42" aria-label="Say &#34;hi&#34; &lt;now&gt;" disabled></button><a data-x="y" href="#ZgotmplZ"></a>`
+	if got := buf.String(); got != expected {
+		t.Errorf("expected\n%s got\n%s", expected, got)
+	}
+
+	if _, err := tpl.Attrs("onclick", "alert(1)"); err == nil {
+		t.Error("expected event handlers rejected")
+	} else if _, err := tpl.Attrs(`x" y`, "1"); err == nil {
+		t.Error("expected an invalid name rejected")
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	templ := load(t)
+
+	src := `{{ add 1 2 3 }} {{ add .Data 0.5 }} {{ sub 10 4 }} {{ mul 2 3 4 }} {{ div 7 2 }} {{ div 7.0 2 }} ` +
+		`{{ mod 7 3 }} {{ min 3 1 2 }} {{ max 1 2.5 }} {{ round 2.345 2 }} {{ ceil 1.2 }} {{ floor 1.8 }}`
+
+	var buf bytes.Buffer
+	if err := templ.RenderInline(&buf, src, tpl.PageData{Data: int64(10)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := buf.String(); got != "6 10.5 6 24 3 3.5 1 1 2.5 2.35 2 1" {
+		t.Errorf("unexpected results %q", got)
+	}
+
+	if _, err := tpl.Div(1, 0); err == nil {
+		t.Error("expected an error dividing by zero")
+	} else if _, err := tpl.Add(1, "2"); err == nil {
+		t.Error("expected an error for a string")
+	}
+}
+
+func TestQRCode(t *testing.T) {
+	png, err := tpl.QRCode("https://example.com/tickets/42", 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(string(png), "data:image/png;base64,"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := stdpng.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	} else if img.Bounds().Dx() != 128 {
+		t.Errorf("expected a 128px image got %d", img.Bounds().Dx())
+	}
+
+	templ := load(t)
+
+	var buf bytes.Buffer
+	if err := templ.RenderInline(&buf, `<img src="{{ qrcode .Data 200 "svg" }}">`, tpl.PageData{Data: "otpauth://totp/app"}); err != nil {
+		t.Fatal(err)
+	}
+
+	src := html.UnescapeString(buf.String())
+	if !strings.HasPrefix(src, `<img src="data:image/svg+xml;base64,`) {
+		t.Fatalf("expected the data URI kept: %s", src)
+	}
+
+	svg, _ := base64.StdEncoding.DecodeString(strings.TrimSuffix(strings.TrimPrefix(src, `<img src="data:image/svg+xml;base64,`), `">`))
+	if !strings.Contains(string(svg), `width="200"`) || !strings.Contains(string(svg), "v1h-") {
+		t.Errorf("unexpected svg %s", svg)
+	}
+
+	for _, size := range []int{0, -1, 100000} {
+		if _, err := tpl.QRCode("https://example.com", size); err == nil {
+			t.Errorf("expected an error for the size %d", size)
+		}
+	}
+}
+
+func TestSeqHelpers(t *testing.T) {
+	calls := 0
+	seq := func(yield func(int) bool) {
+		for i := 1; i <= 5; i++ {
+			calls++
+			if !yield(i) {
+				return
+			}
+		}
+	}
+
+	if got, err := tpl.Collect(seq); err != nil || len(got) != 5 || got[4] != 5 {
+		t.Errorf("unexpected collect %v %v", got, err)
+	}
+
+	calls = 0
+	if got, err := tpl.Take(2, seq); err != nil || len(got) != 2 || got[1] != 2 {
+		t.Errorf("unexpected take %v %v", got, err)
+	} else if calls != 2 {
+		t.Errorf("expected take to stop the iteration, got %d calls", calls)
+	}
+
+	if got, err := tpl.Chunk(2, seq); err != nil || len(got) != 3 || len(got[2]) != 1 {
+		t.Errorf("unexpected chunk %v %v", got, err)
+	}
+
+	if got, err := tpl.Take(2, []string{"a", "b", "c"}); err != nil || len(got) != 2 {
+		t.Errorf("unexpected take of a slice %v %v", got, err)
+	}
+
+	if _, err := tpl.Collect(42); err == nil {
+		t.Error("expected an error for a value that isn't an iterator")
+	}
+}
+
+func TestAssets(t *testing.T) {
+	defer tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	manifest := `{
+		"src/main.ts": {"file": "assets/main-4f2c.js", "src": "src/main.ts", "isEntry": true, "css": ["assets/main-a1b2.css"], "imports": ["_shared-9d8e.js"]},
+		"_shared-9d8e.js": {"file": "assets/shared-9d8e.js", "css": ["assets/shared-77aa.css"]},
+		"src/theme.css": {"file": "assets/theme-c3d4.css", "src": "src/theme.css", "isEntry": true}
+	}`
+	tpl.Set(tpl.Option{
+		TemplateRootName: "testdata",
+		AssetManifest:    "dist/.vite/manifest.json",
+		AssetFS:          fstest.MapFS{"dist/.vite/manifest.json": {Data: []byte(manifest)}},
+		AssetBaseURL:     "/static/",
+	})
+
+	if got, err := tpl.Asset("src/main.ts"); err != nil {
+		t.Fatal(err)
+	} else if got != "/static/assets/main-4f2c.js" {
+		t.Errorf("unexpected asset %q", got)
+	}
+
+	if _, err := tpl.Asset("src/mian.ts"); err == nil {
+		t.Error("expected an error for an unknown asset")
+	}
+
+	got, err := tpl.AssetTags("src/main.ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<link rel="stylesheet" href="/static/assets/main-a1b2.css">
+<link rel="stylesheet" href="/static/assets/shared-77aa.css">
+<script type="module" src="/static/assets/main-4f2c.js"></script>
+<link rel="modulepreload" href="/static/assets/shared-9d8e.js">`
+	if string(got) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+
+	if got, _ := tpl.AssetTags("src/theme.css"); got != `<link rel="stylesheet" href="/static/assets/theme-c3d4.css">` {
+		t.Errorf("unexpected stylesheet tags %q", got)
+	}
+
+	tpl.Set(tpl.Option{
+		TemplateRootName: "testdata",
+		AssetManifest:    "manifest.json",
+		AssetFS:          fstest.MapFS{"manifest.json": {Data: []byte(`{"app.js": "app-5f2c1a.js"}`)}},
+	})
+
+	if got, _ := tpl.AssetTags("app.js"); got != `<script type="module" src="/app-5f2c1a.js"></script>` {
+		t.Errorf("unexpected esbuild tags %q", got)
+	}
+}
@@ -0,0 +1,21 @@
+package tpl
+
+import "net/http"
+
+// Header declares a response header from a view, i.e. so SEO and security
+// headers live with the page that needs them:
+//
+//	{{ header . "X-Robots-Tag" "noindex" }}
+//
+// The headers are collected during the render and set by RenderHTTP before
+// the page is written. A header declared by the view replaces the value set
+// by your handler. It outputs nothing.
+func Header(data PageData, name, value string) string {
+	if data.state != nil {
+		if data.state.headers == nil {
+			data.state.headers = make(http.Header)
+		}
+		data.state.headers.Add(name, value)
+	}
+	return ""
+}
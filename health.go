@@ -0,0 +1,80 @@
+package tpl
+
+import (
+	"html/template"
+	"path"
+	"sort"
+	"strings"
+)
+
+// HealthReport lists the problems found by HealthCheck.
+type HealthReport struct {
+	MissingTemplates      []HealthIssue `json:"missingTemplates,omitempty"`
+	MissingTranslations   []HealthIssue `json:"missingTranslations,omitempty"`
+	EmailsWithoutLanguage []string      `json:"emailsWithoutLanguage,omitempty"`
+}
+
+// HealthIssue is a template or translation key missing for a view or email.
+type HealthIssue struct {
+	View string `json:"view"`
+	Name string `json:"name"`
+}
+
+// OK returns true when no problem was found.
+func (r HealthReport) OK() bool {
+	return len(r.MissingTemplates) == 0 &&
+		len(r.MissingTranslations) == 0 &&
+		len(r.EmailsWithoutLanguage) == 0
+}
+
+// HealthCheck verifies that every view and email only invokes templates that
+// exist, that every translation key they use exists in the default language,
+// and that every email has a language variant, i.e. verify_en.html.
+//
+// The report can be logged at startup or returned from a /healthz endpoint.
+func (templ *Template) HealthCheck() HealthReport {
+	var report HealthReport
+
+	lang := defaultLang()
+
+	check := func(name string, t *template.Template) {
+		seen := make(map[string]bool)
+		for _, n := range missingTemplates(t) {
+			if !seen["t:"+n] {
+				seen["t:"+n] = true
+				report.MissingTemplates = append(report.MissingTemplates, HealthIssue{View: name, Name: n})
+			}
+		}
+
+		for _, key := range usedTranslationKeys(t) {
+			if _, ok := lookupMessage(lang, key); !ok && !seen["k:"+key] {
+				seen["k:"+key] = true
+				report.MissingTranslations = append(report.MissingTranslations, HealthIssue{View: name, Name: key})
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(templ.Views) {
+		check(name, templ.Views[name])
+	}
+
+	for _, name := range sortedKeys(templ.Emails) {
+		check(name, templ.Emails[name])
+
+		base := strings.TrimSuffix(name, path.Ext(name))
+		if i := strings.LastIndex(base, "_"); i <= 0 || i == len(base)-1 {
+			report.EmailsWithoutLanguage = append(report.EmailsWithoutLanguage, name)
+		}
+	}
+
+	return report
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,56 @@
+package tpl
+
+import (
+	"html/template"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	chromastyles "github.com/alecthomas/chroma/v2/styles"
+)
+
+// defaultCodeStyle is the chroma style of highlightcode if the CodeStyle
+// option is empty.
+const defaultCodeStyle = "github"
+
+var codeFormatter = chromahtml.New(chromahtml.WithClasses(false), chromahtml.TabWidth(4))
+
+// HighlightCode returns the syntax highlighted HTML of a source code in a
+// language, i.e. go, js, html, or sql, for docs and changelogs without a
+// client-side highlighter:
+//
+//	{{ highlightcode "go" .Data.Example }}
+//
+// The language is guessed from the code if it's unknown. The colors come from
+// the chroma style of the CodeStyle option, github by default, as inline
+// styles.
+func HighlightCode(lang, src string) template.HTML {
+	lexer := lexers.Get(lang)
+	if lexer == nil {
+		lexer = lexers.Analyse(src)
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+
+	name := config.CodeStyle
+	if len(name) == 0 {
+		name = defaultCodeStyle
+	}
+
+	it, err := chroma.Coalesce(lexer).Tokenise(nil, src)
+	if err != nil {
+		return plainCode(src)
+	}
+
+	var sb strings.Builder
+	if err := codeFormatter.Format(&sb, chromastyles.Get(name), it); err != nil {
+		return plainCode(src)
+	}
+	return template.HTML(sb.String())
+}
+
+func plainCode(src string) template.HTML {
+	return template.HTML("<pre><code>" + template.HTMLEscapeString(src) + "</code></pre>")
+}
@@ -6,7 +6,16 @@ import (
 )
 
 // ToDate formats a date to a short date without time based on locale.
-func ToDate(locale string, date time.Time) string {
+//
+// An optional IANA time zone converts the date first, so the day matches the
+// viewer's calendar, an empty zone uses the Timezone option:
+//
+//	{{ shortdate .Locale .Data.CreatedAt .Timezone }}
+func ToDate(locale string, date time.Time, timezone ...string) string {
+	if len(timezone) > 0 {
+		date = inZone(date, timezone[0])
+	}
+
 	layout := "01-02-2006"
 
 	switch locale {
@@ -18,7 +27,18 @@ func ToDate(locale string, date time.Time) string {
 }
 
 // ToCurrency formats an amounts based on locale with the proper currency sign.
-func ToCurrency(locale string, amount float64) string {
+//
+// Optional arguments set the ISO 4217 currency code, the locale's currency
+// if omitted, and how the currency is displayed: "symbol" (€1,234.56), "code"
+// (EUR 1,234.56), or "name" (1,234.56 euros), localized for the locale. The
+// "accounting" option puts negative amounts in parentheses, ($1,234.56):
+//
+//	{{ currency .Locale .Data.Amount "EUR" "name" }}
+func ToCurrency(locale string, amount float64, opts ...string) string {
+	if len(opts) > 0 {
+		return formatCurrency(locale, amount, parseCurrencyOptions(locale, opts))
+	}
+
 	format := "$%.2f"
 
 	switch locale {
@@ -0,0 +1,449 @@
+package tpl
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// icuCache holds the parsed ICU messages keyed by their source.
+var icuCache sync.Map
+
+type icuNode interface {
+	format(b *strings.Builder, lang string, args map[string]any, num *float64)
+}
+
+type icuText string
+
+func (n icuText) format(b *strings.Builder, _ string, _ map[string]any, _ *float64) {
+	b.WriteString(string(n))
+}
+
+// icuPound is the # inside a plural option, replaced by the number.
+type icuPound struct{}
+
+func (icuPound) format(b *strings.Builder, _ string, _ map[string]any, num *float64) {
+	if num == nil {
+		b.WriteByte('#')
+		return
+	}
+	b.WriteString(formatNumber(*num))
+}
+
+type icuArg struct {
+	name string
+}
+
+func (n icuArg) format(b *strings.Builder, _ string, args map[string]any, _ *float64) {
+	v, ok := args[n.name]
+	if !ok {
+		b.WriteString("{" + n.name + "}")
+		return
+	}
+
+	if f, ok := toFloat64(v); ok {
+		b.WriteString(formatNumber(f))
+		return
+	}
+
+	fmt.Fprint(b, v)
+}
+
+type icuSelect struct {
+	name    string
+	kind    string // plural, selectordinal, or select
+	offset  float64
+	options map[string][]icuNode
+}
+
+func (n icuSelect) format(b *strings.Builder, lang string, args map[string]any, num *float64) {
+	v := args[n.name]
+
+	var opt []icuNode
+	var pound *float64
+
+	if n.kind == "select" {
+		key := fmt.Sprint(v)
+		if o, ok := n.options[key]; ok {
+			opt = o
+		} else {
+			opt = n.options["other"]
+		}
+		pound = num
+	} else {
+		f, _ := toFloat64(v)
+		if o, ok := n.options["="+formatNumber(f)]; ok {
+			opt = o
+		} else {
+			f -= n.offset
+			cat := pluralCategory(lang, f)
+			if n.kind == "selectordinal" {
+				cat = ordinalCategory(lang, f)
+			}
+
+			if o, ok := n.options[cat]; ok {
+				opt = o
+			} else {
+				opt = n.options["other"]
+			}
+		}
+		pound = &f
+	}
+
+	for _, node := range opt {
+		node.format(b, lang, args, pound)
+	}
+}
+
+// FormatMessage formats an ICU MessageFormat string with the named arguments.
+//
+// The supported syntax is the simple argument {name}, {name, number},
+// {name, plural, ...}, {name, selectordinal, ...}, and {name, select, ...},
+// including the offset: and =N selectors and the # placeholder inside plural
+// options.
+func FormatMessage(lang, msg string, args map[string]any) (string, error) {
+	nodes, err := parseMessage(msg)
+	if err != nil {
+		return "", err
+	}
+	return formatNodes(nodes, lang, args), nil
+}
+
+// parseMessage returns the parsed nodes of an ICU message, cached by source.
+func parseMessage(msg string) ([]icuNode, error) {
+	if v, ok := icuCache.Load(msg); ok {
+		return v.([]icuNode), nil
+	}
+
+	p := &icuParser{src: msg}
+	nodes, err := p.parse(false)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.src) {
+		return nil, fmt.Errorf("unexpected '}' at %d in message: %s", p.pos, msg)
+	}
+	icuCache.Store(msg, nodes)
+	return nodes, nil
+}
+
+func formatNodes(nodes []icuNode, lang string, args map[string]any) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		n.format(&b, lang, args, nil)
+	}
+	return b.String()
+}
+
+// TranslateMessage returns the translation value formatted as an ICU
+// MessageFormat message with the named arguments.
+func TranslateMessage(lang, key string, args map[string]any) string {
+	msg := GetMessageFromKey(lang, key).Value
+
+	nodes, err := parseMessage(msg)
+	if err != nil {
+		return present(key, msg)
+	}
+
+	if config.PseudoLocalize {
+		nodes = accentNodes(nodes)
+	}
+	return present(key, formatNodes(nodes, lang, args))
+}
+
+// accentNodes returns a copy of the nodes with their text pseudo-localized,
+// the arguments are formatted as they are.
+func accentNodes(nodes []icuNode) []icuNode {
+	accented := make([]icuNode, len(nodes))
+	for i, n := range nodes {
+		switch n := n.(type) {
+		case icuText:
+			accented[i] = icuText(accentLetters(string(n)))
+		case icuSelect:
+			options := make(map[string][]icuNode, len(n.options))
+			for k, opt := range n.options {
+				options[k] = accentNodes(opt)
+			}
+			n.options = options
+			accented[i] = n
+		default:
+			accented[i] = n
+		}
+	}
+	return accented
+}
+
+type icuParser struct {
+	src string
+	pos int
+}
+
+// parse reads a message until the end of the source or an unmatched '}'.
+func (p *icuParser) parse(inPlural bool) ([]icuNode, error) {
+	var nodes []icuNode
+	var text strings.Builder
+
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, icuText(text.String()))
+			text.Reset()
+		}
+	}
+
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '\'':
+			p.quoted(&text, inPlural)
+		case c == '}':
+			flush()
+			return nodes, nil
+		case c == '#' && inPlural:
+			flush()
+			nodes = append(nodes, icuPound{})
+			p.pos++
+		case c == '{':
+			flush()
+			n, err := p.argument(inPlural)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, n)
+		default:
+			text.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	flush()
+	return nodes, nil
+}
+
+// quoted handles the apostrophe escaping rules: two apostrophes are a literal
+// apostrophe and one followed by a syntax character starts a quoted literal.
+func (p *icuParser) quoted(text *strings.Builder, inPlural bool) {
+	p.pos++
+	if p.pos >= len(p.src) {
+		text.WriteByte('\'')
+		return
+	}
+
+	next := p.src[p.pos]
+	if next == '\'' {
+		text.WriteByte('\'')
+		p.pos++
+		return
+	}
+
+	if next != '{' && next != '}' && !(next == '#' && inPlural) {
+		text.WriteByte('\'')
+		return
+	}
+
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		p.pos++
+		if c == '\'' {
+			if p.pos < len(p.src) && p.src[p.pos] == '\'' {
+				text.WriteByte('\'')
+				p.pos++
+				continue
+			}
+			return
+		}
+		text.WriteByte(c)
+	}
+}
+
+func (p *icuParser) argument(inPlural bool) (icuNode, error) {
+	p.pos++ // {
+
+	name := p.token()
+	if len(name) == 0 {
+		return nil, fmt.Errorf("missing argument name at %d", p.pos)
+	}
+
+	p.skipSpaces()
+	if p.consume('}') {
+		return icuArg{name: name}, nil
+	}
+
+	if !p.consume(',') {
+		return nil, fmt.Errorf("expected ',' or '}' after argument %s", name)
+	}
+
+	kind := p.token()
+	p.skipSpaces()
+
+	switch kind {
+	case "number", "date", "time", "spellout", "duration", "ordinal":
+		// the style, if any, is ignored
+		depth := 1
+		for p.pos < len(p.src) && depth > 0 {
+			switch p.src[p.pos] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+			p.pos++
+		}
+		if depth > 0 {
+			return nil, errors.New("unterminated argument " + name)
+		}
+		return icuArg{name: name}, nil
+	case "plural", "selectordinal", "select":
+	default:
+		return nil, fmt.Errorf("unknown argument type %q for %s", kind, name)
+	}
+
+	if !p.consume(',') {
+		return nil, fmt.Errorf("expected ',' after %s type", name)
+	}
+
+	sel := icuSelect{name: name, kind: kind, options: make(map[string][]icuNode)}
+	for {
+		p.skipSpaces()
+		if p.consume('}') {
+			break
+		}
+		if p.pos >= len(p.src) {
+			return nil, errors.New("unterminated argument " + name)
+		}
+
+		key := p.token()
+		if len(key) == 0 {
+			return nil, fmt.Errorf("expected option selector at %d", p.pos)
+		}
+
+		if strings.HasPrefix(key, "offset:") {
+			off, err := strconv.ParseFloat(strings.TrimPrefix(key, "offset:"), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid offset for %s: %w", name, err)
+			}
+			sel.offset = off
+			continue
+		}
+
+		p.skipSpaces()
+		if !p.consume('{') {
+			return nil, fmt.Errorf("expected '{' after selector %s", key)
+		}
+
+		nodes, err := p.parse(kind != "select" || inPlural)
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume('}') {
+			return nil, fmt.Errorf("unterminated option %s", key)
+		}
+
+		sel.options[key] = nodes
+	}
+
+	if _, ok := sel.options["other"]; !ok {
+		return nil, fmt.Errorf("missing other option for %s", name)
+	}
+
+	return sel, nil
+}
+
+func (p *icuParser) token() string {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == ',' || c == '{' || c == '}' || c == ' ' || c == '\t' || c == '\n' {
+			break
+		}
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *icuParser) skipSpaces() {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *icuParser) consume(c byte) bool {
+	if p.pos < len(p.src) && p.src[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// pluralCategory returns the CLDR cardinal plural category for a number
+// based on the language's PluralRule.
+func pluralCategory(lang string, n float64) string {
+	if n == math.Trunc(n) && !isPlural(lang, int64(n)) {
+		return "one"
+	}
+	return "other"
+}
+
+// ordinalCategory returns the CLDR ordinal plural category for a number.
+func ordinalCategory(lang string, n float64) string {
+	i := int64(n)
+	switch lang {
+	case "fr":
+		if i == 1 {
+			return "one"
+		}
+	case "en":
+		switch {
+		case i%10 == 1 && i%100 != 11:
+			return "one"
+		case i%10 == 2 && i%100 != 12:
+			return "two"
+		case i%10 == 3 && i%100 != 13:
+			return "few"
+		}
+	}
+	return "other"
+}
+
+// toFloat64 converts any Go numeric value to a float64.
+func toFloat64(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
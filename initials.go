@@ -0,0 +1,38 @@
+package tpl
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Initials returns the upper-cased initials of a name for avatar
+// placeholders, 2 by default, i.e. JD for Jane Doe or ÉL for élodie Lefebvre:
+//
+//	<span class="avatar">{{ initials .CurrentUser.Name }}</span>
+//
+// With more words than initials, the last word is kept, JD for Jane Mary Doe,
+// unless a single initial is asked.
+func Initials(name string, n ...int) string {
+	count := 2
+	if len(n) > 0 && n[0] > 0 {
+		count = n[0]
+	}
+
+	var letters []rune
+	for _, word := range strings.Fields(name) {
+		for _, r := range word {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				letters = append(letters, unicode.ToUpper(r))
+				break
+			}
+		}
+	}
+
+	if len(letters) > count {
+		if count == 1 {
+			return string(letters[0])
+		}
+		letters = append(letters[:count-1], letters[len(letters)-1])
+	}
+	return string(letters)
+}
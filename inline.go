@@ -0,0 +1,73 @@
+package tpl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"html/template"
+	"io"
+)
+
+// maxInlineCache is the number of parsed inline templates kept in memory.
+const maxInlineCache = 512
+
+// RenderInline parses and renders a template from a string, with the same
+// funcmap and translation functions as the views. It's meant for one-off
+// templates, like notification templates editable by admins and stored in a
+// database.
+//
+// Parsed templates are cached by the hash of their source. The data can be a
+// PageData or any value.
+func (templ *Template) RenderInline(w io.Writer, src string, data any) error {
+	t, err := templ.parseInline(src)
+	if err != nil {
+		return err
+	}
+
+	if pd, ok := data.(PageData); ok {
+		pd.state = newRenderState()
+		defer pd.state.end()
+		data = pd
+
+		if err := t.Execute(chunkedOutput(w, pd.state), data); err != nil {
+			return err
+		}
+		return pd.state.failure()
+	}
+
+	return t.Execute(w, data)
+}
+
+func (templ *Template) parseInline(src string) (*template.Template, error) {
+	sum := sha256.Sum256([]byte(src))
+	key := hex.EncodeToString(sum[:])
+
+	templ.mu.RLock()
+	t, ok := templ.inline[key]
+	templ.mu.RUnlock()
+
+	templ.stats.cache(ok)
+	if ok {
+		return t, nil
+	}
+
+	fmap := templ.funcMap
+	if fmap == nil {
+		fmap = make(map[string]any)
+		enhanceFuncMap(fmap)
+	}
+
+	t, err := template.New("inline").Funcs(fmap).Parse(src)
+	if err != nil {
+		return nil, err
+	}
+
+	templ.mu.Lock()
+	defer templ.mu.Unlock()
+
+	if templ.inline == nil || len(templ.inline) >= maxInlineCache {
+		templ.inline = make(map[string]*template.Template)
+	}
+	templ.inline[key] = t
+
+	return t, nil
+}
@@ -0,0 +1,37 @@
+package tpl
+
+import (
+	"encoding/json"
+	"html/template"
+)
+
+// JSON marshals a value, i.e. for a data attribute or a script:
+//
+//	<div data-chart="{{ json .Data.Series }}"></div>
+//	<script>const user = {{ json .CurrentUser }};</script>
+//
+// The <, >, and & characters are escaped as \u003c, \u003e, and \u0026, the
+// output is safe in a script.
+func JSON(v any) (template.JS, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return template.JS(b), nil
+}
+
+// JSONScript outputs a <script type="application/json"> tag holding the JSON
+// of a value, to hand the initial state of a page to frontend code:
+//
+//	{{ jsonscript "initial-state" .Data }}
+//
+// A </script> in the value can't end the tag, the frontend code reads it with
+// JSON.parse(document.getElementById("initial-state").textContent).
+func JSONScript(id string, v any) (template.HTML, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return template.HTML(`<script type="application/json" id="` + template.HTMLEscapeString(id) + `">` + string(b) + `</script>`), nil
+}
@@ -0,0 +1,102 @@
+package tpl
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"strings"
+)
+
+// LangURL returns the current URL for another language, to build language
+// switchers:
+//
+//	<a href="{{ langurl .CurrentURL "fr" }}">Français</a>
+//
+// By default, the language is the first segment of the path, /en/about becomes
+// /fr/about. With the LangURLMode option set to "query", the lang query string
+// parameter is set instead, /about?lang=en becomes /about?lang=fr.
+func LangURL(current, lang string) string {
+	return localizeURL(current, lang)
+}
+
+// localizeURL returns the URL for a language based on the LangURLMode option.
+func localizeURL(raw, lang string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	if config.LangURLMode == "query" {
+		q := u.Query()
+		q.Set("lang", lang)
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+
+	p := stripLangPrefix(u.Path)
+	u.Path = "/" + lang + p
+	if p == "/" {
+		u.Path = "/" + lang + "/"
+	}
+	return u.String()
+}
+
+// stripLangPrefix removes a known language from the start of a path.
+func stripLangPrefix(p string) string {
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+
+	seg, rest, _ := strings.Cut(p[1:], "/")
+	for _, lang := range availableLanguages() {
+		if seg == lang {
+			return "/" + rest
+		}
+	}
+	return p
+}
+
+// Hreflang outputs a <link rel="alternate" hreflang> tag per language for the
+// current path or URL, which search engines use to find the translated
+// versions of a page:
+//
+//	<head>{{ hreflang "/about" }}</head>
+//
+// The URL of each language is built like LangURL does, i.e. /fr/about. Use
+// absolute URLs as search engines expect.
+func Hreflang(current string) template.HTML {
+	return alternateLinks(current, false)
+}
+
+// Alternates outputs a <link rel="alternate" hreflang> tag per language for
+// the CurrentURL of the data, plus an x-default tag pointing to the URL of
+// the default language:
+//
+//	<head>{{ alternates . }}</head>
+//
+// Set CurrentURL to the absolute URL of the request, as search engines
+// expect.
+func Alternates(data PageData) template.HTML {
+	return alternateLinks(data.CurrentURL, true)
+}
+
+func alternateLinks(current string, xDefault bool) template.HTML {
+	var sb strings.Builder
+
+	link := func(hreflang, lang string) {
+		fmt.Fprintf(&sb, `<link rel="alternate" hreflang="%s" href="%s">`,
+			template.HTMLEscapeString(hreflang),
+			template.HTMLEscapeString(localizeURL(current, lang)),
+		)
+		sb.WriteByte('\n')
+	}
+
+	for _, lang := range availableLanguages() {
+		link(lang, lang)
+	}
+
+	if xDefault {
+		link("x-default", defaultLang())
+	}
+	return template.HTML(sb.String())
+}
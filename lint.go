@@ -0,0 +1,138 @@
+package tpl
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template/parse"
+)
+
+// LintFile describes the template being linted.
+type LintFile struct {
+	// Name is the file name the template was parsed from, e.g. nav.html.
+	Name string
+	// Template is the name of the template, either the file name or a name
+	// given by {{define}}.
+	Template string
+}
+
+// LintIssue is a problem reported by a LintRule.
+type LintIssue struct {
+	Rule    string
+	File    string
+	Line    int
+	Message string
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s:%d: %s (%s)", i.File, i.Line, i.Message, i.Rule)
+}
+
+// LintRule checks a parsed template and reports the issues it finds. Use the
+// Issue helper to report an issue with the position of a node.
+type LintRule interface {
+	Name() string
+	Check(tree *parse.Tree, file LintFile) []LintIssue
+}
+
+var (
+	lintMu    sync.RWMutex
+	lintRules []LintRule
+)
+
+// RegisterLintRule adds a rule that runs on every template via Template.Lint
+// and at Parse when the Strict option is set. It can't be removed, set the
+// LintRules option for rules that come and go, i.e. in tests.
+func RegisterLintRule(rule LintRule) {
+	lintMu.Lock()
+	defer lintMu.Unlock()
+
+	lintRules = append(lintRules, rule)
+}
+
+// Issue builds a LintIssue positioned at the node inside the tree.
+func Issue(rule LintRule, tree *parse.Tree, node parse.Node, msg string) LintIssue {
+	issue := LintIssue{Rule: rule.Name(), File: tree.ParseName, Message: msg}
+
+	loc, _ := tree.ErrorContext(node)
+	if parts := strings.Split(loc, ":"); len(parts) >= 2 {
+		issue.Line, _ = strconv.Atoi(parts[1])
+	}
+
+	return issue
+}
+
+// Lint runs the lint rules on the templates of the views and emails. The
+// issues of a shared layout or partial are reported once.
+func (templ *Template) Lint() []LintIssue {
+	templ.mu.RLock()
+	parsed := make([]*template.Template, 0, len(templ.Views)+len(templ.Emails))
+	for _, v := range templ.Views {
+		parsed = append(parsed, v)
+	}
+	for _, e := range templ.Emails {
+		parsed = append(parsed, e)
+	}
+	templ.mu.RUnlock()
+
+	var issues []LintIssue
+	seen := make(map[string]bool)
+
+	check := func(t *template.Template) {
+		for _, issue := range lintTemplate(t) {
+			key := issue.String()
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			issues = append(issues, issue)
+		}
+	}
+
+	for _, t := range parsed {
+		check(t)
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].File != issues[j].File {
+			return issues[i].File < issues[j].File
+		}
+		return issues[i].Line < issues[j].Line
+	})
+
+	return issues
+}
+
+func lintTemplate(t *template.Template) []LintIssue {
+	lintMu.RLock()
+	defer lintMu.RUnlock()
+
+	var issues []LintIssue
+	for _, at := range t.Templates() {
+		if at.Tree == nil || at.Tree.Root == nil {
+			continue
+		}
+
+		file := LintFile{Name: at.Tree.ParseName, Template: at.Name()}
+		for _, rule := range lintRules {
+			issues = append(issues, rule.Check(at.Tree, file)...)
+		}
+		for _, rule := range config.LintRules {
+			issues = append(issues, rule.Check(at.Tree, file)...)
+		}
+	}
+	return issues
+}
+
+// lintError joins the issues into one error.
+func lintError(issues []LintIssue) error {
+	var errs []error
+	for _, issue := range issues {
+		errs = append(errs, errors.New(issue.String()))
+	}
+	return errors.Join(errs...)
+}
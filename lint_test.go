@@ -0,0 +1,79 @@
+package tpl_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"text/template/parse"
+
+	"github.com/dstpierre/tpl"
+)
+
+type noTitleRule struct{}
+
+func (noTitleRule) Name() string { return "no-title-tag" }
+
+func (r noTitleRule) Check(tree *parse.Tree, file tpl.LintFile) []tpl.LintIssue {
+	var issues []tpl.LintIssue
+	for _, n := range tree.Root.Nodes {
+		if text, ok := n.(*parse.TextNode); ok && strings.Contains(string(text.Text), "<title>") {
+			issues = append(issues, tpl.Issue(r, tree, n, "use the Title field"))
+		}
+	}
+	return issues
+}
+
+func TestLint(t *testing.T) {
+	rules := []tpl.LintRule{noTitleRule{}}
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", LintRules: rules})
+	defer tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	templ, err := tpl.Parse(fsTest, fmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issues := templ.Lint()
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, one per layout with a title got %v", issues)
+	}
+
+	issue := issues[0]
+	if issue.File != "app.html" || issue.Line != 1 || issue.Rule != "no-title-tag" {
+		t.Errorf("unexpected issue: %v", issue)
+	}
+
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", LintRules: rules, Strict: true})
+	if _, err := tpl.Parse(fsTest, fmap); err == nil {
+		t.Error("expected strict parse to fail")
+	}
+
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", Strict: true})
+	if _, err := tpl.Parse(fsTest, fmap); err != nil {
+		t.Errorf("expected the rule gone with the option, got %v", err)
+	}
+}
+
+func TestLintWhileUpdating(t *testing.T) {
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", LintRules: []tpl.LintRule{noTitleRule{}}})
+	defer tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	templ, err := tpl.Parse(fsTest, fmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			templ.UpdateView("app/dashboard.html", []byte(`{{define "content"}}edited{{end}}`))
+		}
+	}()
+
+	for i := 0; i < 20; i++ {
+		templ.Lint()
+	}
+	wg.Wait()
+}
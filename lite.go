@@ -0,0 +1,44 @@
+package tpl
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// liteName returns the name of the lite variant of a view, i.e.
+// app/dashboard.lite.html for app/dashboard.html.
+func liteName(view string) string {
+	ext := path.Ext(view)
+	return strings.TrimSuffix(view, ext) + ".lite" + ext
+}
+
+// mainOfLite returns the file name of the main view of a lite variant, i.e.
+// dashboard.html for dashboard.lite.html.
+func mainOfLite(name string) (string, bool) {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if !strings.HasSuffix(base, ".lite") {
+		return "", false
+	}
+	return strings.TrimSuffix(base, ".lite") + ext, true
+}
+
+// WantsLite reports whether a request asks for a lighter page, via the
+// Save-Data header or a slow-2g or 2g effective connection type, to set the
+// Lite field of the PageData:
+//
+//	data := tpl.PageData{Lite: tpl.WantsLite(r)}
+//
+// If you cache the pages, vary the cache on the Save-Data and ECT headers.
+func WantsLite(r *http.Request) bool {
+	if strings.EqualFold(r.Header.Get("Save-Data"), "on") {
+		return true
+	}
+
+	switch strings.ToLower(r.Header.Get("ECT")) {
+	case "slow-2g", "2g":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,205 @@
+package tpl
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// localeFormat holds the currency conventions of a locale.
+type localeFormat struct {
+	currency string
+	// symbolAfter is true when the currency symbol follows the amount.
+	symbolAfter bool
+	// symbolSpace is true when a space separates a leading symbol from the
+	// amount, e.g. R$ 1.234,56.
+	symbolSpace bool
+}
+
+var localeFormats = map[string]localeFormat{
+	"en-US": {currency: "USD"},
+	"en-CA": {currency: "CAD"},
+	"en-GB": {currency: "GBP"},
+	"en-AU": {currency: "AUD"},
+	"fr-CA": {currency: "CAD", symbolAfter: true},
+	"fr-FR": {currency: "EUR", symbolAfter: true},
+	"fr-BE": {currency: "EUR", symbolAfter: true},
+	"fr-CH": {currency: "CHF", symbolAfter: true},
+	"de-DE": {currency: "EUR", symbolAfter: true},
+	"de-CH": {currency: "CHF", symbolSpace: true},
+	"es-ES": {currency: "EUR", symbolAfter: true},
+	"es-MX": {currency: "MXN"},
+	"it-IT": {currency: "EUR", symbolAfter: true},
+	"nl-NL": {currency: "EUR", symbolSpace: true},
+	"pt-BR": {currency: "BRL", symbolSpace: true},
+	"pt-PT": {currency: "EUR", symbolAfter: true},
+	"ja-JP": {currency: "JPY"},
+}
+
+// localeLanguages are used when only the language part of a locale is known.
+var localeLanguages = map[string]string{
+	"en": "en-US",
+	"fr": "fr-FR",
+	"de": "de-DE",
+	"es": "es-ES",
+	"it": "it-IT",
+	"nl": "nl-NL",
+	"pt": "pt-BR",
+	"ja": "ja-JP",
+}
+
+// getLocaleFormat returns the conventions of a locale, falling back to the
+// locale's language and finally to en-US.
+func getLocaleFormat(locale string) localeFormat {
+	locale = strings.ReplaceAll(locale, "_", "-")
+	if lf, ok := localeFormats[locale]; ok {
+		return lf
+	}
+
+	lang, _, _ := strings.Cut(locale, "-")
+	if l, ok := localeLanguages[strings.ToLower(lang)]; ok {
+		return localeFormats[l]
+	}
+
+	return localeFormats["en-US"]
+}
+
+// localeLang returns the language part of a locale, i.e. fr for fr-CA.
+func localeLang(locale string) string {
+	lang, _, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	return strings.ToLower(lang)
+}
+
+// printers caches the message.Printer of each locale.
+var printers sync.Map
+
+func getPrinter(locale string) *message.Printer {
+	if p, ok := printers.Load(locale); ok {
+		return p.(*message.Printer)
+	}
+
+	p := message.NewPrinter(language.Make(locale))
+	printers.Store(locale, p)
+	return p
+}
+
+// formatDecimal formats a number with the locale's grouping and decimal
+// separators and a fixed number of decimals, 0 if negative.
+func formatDecimal(locale string, v any, decimals int) string {
+	decimals = max(decimals, 0)
+	return getPrinter(locale).Sprint(number.Decimal(
+		v,
+		number.MinFractionDigits(decimals),
+		number.MaxFractionDigits(decimals),
+	))
+}
+
+// Number formats a number with the grouping and decimal separators of the
+// locale, e.g. 1,234.5 in en-US, 1 234,5 in fr-CA, and 1.234,5 in de-DE.
+//
+// By default up to 3 decimals are displayed, an optional argument sets the
+// exact number of decimals:
+//
+//	{{ number .Locale .Data.Total 2 }}
+func Number(locale string, v any, decimals ...int) string {
+	f, ok := toFloat64(v)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+
+	// integers are formatted as is, a float64 loses the digits above 2^53
+	var n any = f
+	if isInteger(v) {
+		n = v
+	}
+
+	if len(decimals) > 0 {
+		return formatDecimal(locale, n, decimals[0])
+	}
+
+	return getPrinter(locale).Sprint(number.Decimal(n, number.MaxFractionDigits(3)))
+}
+
+// isInteger reports whether v is of an integer kind.
+func isInteger(v any) bool {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return true
+	}
+	return false
+}
+
+// Percent formats a fraction as a percentage with the conventions of the
+// locale, e.g. 0.125 is 12.5% in en-US and 12,5 % in fr-CA.
+//
+// By default up to 2 decimals are displayed, an optional argument sets the
+// exact number of decimals:
+//
+//	{{ percent .Locale .Data.ConversionRate 1 }}
+func Percent(locale string, v any, decimals ...int) string {
+	f, ok := toFloat64(v)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+
+	opts := []number.Option{number.MaxFractionDigits(2)}
+	if len(decimals) > 0 {
+		opts = []number.Option{
+			number.MinFractionDigits(decimals[0]),
+			number.MaxFractionDigits(decimals[0]),
+		}
+	}
+
+	return getPrinter(locale).Sprint(number.Percent(f, opts...))
+}
+
+// PercentValue formats a value already in percent, e.g. 12.5 is 12.5% in
+// en-US, like Percent.
+func PercentValue(locale string, v any, decimals ...int) string {
+	f, ok := toFloat64(v)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+	return Percent(locale, f/100, decimals...)
+}
+
+// ordinalSuffixes are the ordinal suffixes of a language by CLDR ordinal
+// category.
+var ordinalSuffixes = map[string]map[string]string{
+	"en": {"one": "st", "two": "nd", "few": "rd", "other": "th"},
+	"fr": {"one": "er", "other": "e"},
+	"de": {"other": "."},
+	"es": {"other": "º"},
+	"it": {"other": "º"},
+	"pt": {"other": "º"},
+	"nl": {"other": "e"},
+	"ja": {"other": "番目"},
+}
+
+// Ordinal formats a whole number as an ordinal for the locale, e.g. 1st, 2nd,
+// 3rd in English, 1er and 2e in French, or 2. in German:
+//
+//	{{ ordinal .Locale .Data.Rank }}
+func Ordinal(locale string, v any) string {
+	f, ok := toFloat64(v)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+
+	lang := localeLang(locale)
+	suffixes, ok := ordinalSuffixes[lang]
+	if !ok {
+		lang, suffixes = "en", ordinalSuffixes["en"]
+	}
+
+	suffix, ok := suffixes[ordinalCategory(lang, f)]
+	if !ok {
+		suffix = suffixes["other"]
+	}
+
+	return formatDecimal(locale, f, 0) + suffix
+}
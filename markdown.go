@@ -0,0 +1,40 @@
+package tpl
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// markdownRenderer converts GitHub Flavored Markdown, the tables, task
+// lists, strikethrough, and autolinks included.
+var markdownRenderer = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// sanitizeMarkdown applies the MarkdownSanitizer option, the one of the
+// sanitize func if nil.
+func sanitizeMarkdown(s string) string {
+	if config.MarkdownSanitizer != nil {
+		return config.MarkdownSanitizer(s)
+	}
+
+	return sanitizeHTML(s)
+}
+
+// Markdown renders Markdown, like user bios, changelogs, or CMS content, to
+// sanitized HTML:
+//
+//	<div class="bio">{{ markdown .Data.Bio }}</div>
+//
+// The output is sanitized with the MarkdownSanitizer option, by default the
+// links, formatting, lists, tables, and images are kept while scripts, styles,
+// and event handlers are removed.
+func Markdown(src string) template.HTML {
+	var buf bytes.Buffer
+	if err := markdownRenderer.Convert([]byte(src), &buf); err != nil {
+		return template.HTML(template.HTMLEscapeString(src))
+	}
+
+	return template.HTML(sanitizeMarkdown(buf.String()))
+}
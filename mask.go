@@ -0,0 +1,59 @@
+package tpl
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maskRune replaces the hidden characters of the mask funcs.
+const maskRune = "•"
+
+// Mask hides the middle of a sensitive value, keeping n characters, 2 by
+// default, at each end, i.e. an API key or an IBAN:
+//
+//	{{ mask .Data.APIKey 4 }}
+//
+// Values too short to keep both ends are fully masked.
+func Mask(s string, n ...int) string {
+	keep := 2
+	if len(n) > 0 && n[0] >= 0 {
+		keep = n[0]
+	}
+
+	r := []rune(s)
+	if len(r) <= keep*2 {
+		return strings.Repeat(maskRune, len(r))
+	}
+	return string(r[:keep]) + strings.Repeat(maskRune, len(r)-keep*2) + string(r[len(r)-keep:])
+}
+
+// MaskEmail hides the local part of an email address but its first
+// character, i.e. j•••@example.com:
+//
+//	<p>We sent a code to {{ maskemail .CurrentUser.Email }}</p>
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return Mask(email)
+	}
+
+	first := []rune(email[:at])[0]
+	return string(first) + strings.Repeat(maskRune, 3) + email[at:]
+}
+
+// MaskCard hides a card number but its last 4 digits, i.e. •••• 4242:
+//
+//	<p>Paid with {{ maskcard .Data.CardNumber }}</p>
+func MaskCard(number string) string {
+	var digits []rune
+	for _, r := range number {
+		if unicode.IsDigit(r) {
+			digits = append(digits, r)
+		}
+	}
+
+	if len(digits) < 4 {
+		return strings.Repeat(maskRune, 4)
+	}
+	return strings.Repeat(maskRune, 4) + " " + string(digits[len(digits)-4:])
+}
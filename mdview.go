@@ -0,0 +1,161 @@
+package tpl
+
+import (
+	"bufio"
+	"bytes"
+	"html/template"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template/parse"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	goldparser "github.com/yuin/goldmark/parser"
+	goldhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// viewRenderer converts the markdown views. Unlike the markdown func, their
+// HTML is kept as they're written by the developers, the one of untrusted
+// sources is sanitized.
+var viewRenderer = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(goldparser.WithAutoHeadingID()),
+	goldmark.WithRendererOptions(goldhtml.WithUnsafe()),
+)
+
+// frontMatter holds the "key: value" lines between the --- lines at the top
+// of a markdown view.
+type frontMatter map[string]string
+
+// splitFrontMatter returns the front matter and the content of a markdown
+// file.
+func splitFrontMatter(src []byte) (frontMatter, []byte) {
+	rest, ok := bytes.CutPrefix(src, []byte("---\n"))
+	if !ok {
+		rest, ok = bytes.CutPrefix(src, []byte("---\r\n"))
+	}
+	if !ok {
+		return nil, src
+	}
+
+	i := bytes.Index(rest, []byte("\n---"))
+	if i < 0 {
+		return nil, src
+	}
+
+	fm := make(frontMatter)
+	sc := bufio.NewScanner(bytes.NewReader(rest[:i]))
+	for sc.Scan() {
+		k, v, ok := strings.Cut(sc.Text(), ":")
+		if !ok {
+			continue
+		}
+		fm[strings.TrimSpace(k)] = strings.Trim(strings.TrimSpace(v), `"'`)
+	}
+
+	content := rest[i+len("\n---"):]
+	if j := bytes.IndexByte(content, '\n'); j >= 0 {
+		content = content[j+1:]
+	}
+	return fm, content
+}
+
+func isMarkdown(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".md")
+}
+
+// addMarkdown converts a markdown view to HTML and defines it as the
+// "content" block of t. The HTML of an untrusted view is sanitized like the
+// one of the markdown func.
+func addMarkdown(t *template.Template, src []byte, untrusted bool) (frontMatter, error) {
+	fm, content := splitFrontMatter(src)
+
+	var buf bytes.Buffer
+	if err := viewRenderer.Convert(content, &buf); err != nil {
+		return nil, err
+	}
+
+	html := buf.Bytes()
+	if untrusted {
+		html = []byte(sanitizeMarkdown(buf.String()))
+	}
+
+	// a text node, so the {{ }} of the content aren't actions
+	tree := &parse.Tree{
+		Name: "content",
+		Root: &parse.ListNode{
+			NodeType: parse.NodeList,
+			Nodes:    []parse.Node{&parse.TextNode{NodeType: parse.NodeText, Text: html}},
+		},
+	}
+
+	if _, err := t.AddParseTree("content", tree); err != nil {
+		return nil, err
+	}
+	return fm, nil
+}
+
+// parsePatterns parses the files of a view into t, the markdown views
+// become its "content" block. It returns the front matter of the markdown
+// view, if any.
+func parsePatterns(t *template.Template, fsys fs.FS, patterns []string) (*template.Template, frontMatter, error) {
+	var fm frontMatter
+	var files []string
+
+	for i, p := range patterns {
+		if !isMarkdown(p) {
+			files = append(files, p)
+			continue
+		}
+
+		// the content is defined after the layout declared its block
+		if _, err := t.ParseFS(fsys, files...); err != nil {
+			return nil, nil, err
+		}
+
+		src, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		_, untrusted := fileSandbox(fsys, p)
+		if fm, err = addMarkdown(t, src, untrusted); err != nil {
+			return nil, nil, err
+		}
+
+		if len(patterns[i+1:]) > 0 {
+			if _, err := t.ParseFS(fsys, patterns[i+1:]...); err != nil {
+				return nil, nil, err
+			}
+		}
+		return t, fm, nil
+	}
+
+	t, err := t.ParseFS(fsys, files...)
+	return t, fm, err
+}
+
+// applyFrontMatter sets the Title and Data of the page from the front matter
+// of a markdown view, when they're empty.
+func (templ *Template) applyFrontMatter(view string, data *PageData) {
+	templ.mu.RLock()
+	fm, ok := templ.frontMatters[view]
+	templ.mu.RUnlock()
+
+	if !ok {
+		return
+	}
+
+	if len(data.Title) == 0 {
+		data.Title = fm["title"]
+	}
+
+	if data.Data == nil {
+		m := make(map[string]string, len(fm))
+		for k, v := range fm {
+			m[k] = v
+		}
+		data.Data = m
+	}
+}
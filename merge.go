@@ -0,0 +1,268 @@
+package tpl
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"maps"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// MergePolicy tells Merge what to do when a view, an email, or a translation
+// key exists in both Templates.
+type MergePolicy int
+
+const (
+	// MergeError fails the merge on the first conflict, nothing is merged.
+	MergeError MergePolicy = iota
+	// MergeKeep keeps the views, emails, and translations of the host.
+	MergeKeep
+	// MergeReplace replaces them with the ones of the merged Template.
+	MergeReplace
+)
+
+// ParseModule parses the templates of a reusable module under its root
+// directory, to Merge them. Unlike Parse, the TemplateRootName option and the
+// catalog are left untouched, the translations of the module are added to the
+// catalog once merged.
+func ParseModule(fsys fs.FS, root string, funcMap map[string]any) (*Template, error) {
+	return parseFS(asEmbedFS(fsys), fsys, root, funcMap, true)
+}
+
+// viewOrigin is where the files and functions of a view or an email merged
+// from another Template come from.
+type viewOrigin struct {
+	fsys    fs.FS
+	root    string
+	funcMap map[string]any
+}
+
+// origin returns where the files and functions of a view or an email come
+// from, the Template it was merged from or templ.
+func (templ *Template) origin(name string) viewOrigin {
+	templ.mu.RLock()
+	defer templ.mu.RUnlock()
+
+	return templ.originLocked(name)
+}
+
+// originLocked is like origin, callers must hold templ.mu.
+func (templ *Template) originLocked(name string) viewOrigin {
+	if o, ok := templ.merged[name]; ok {
+		return o
+	}
+	return viewOrigin{fsys: templ.fsys, root: templ.root, funcMap: templ.funcMap}
+}
+
+// Merge mounts the views, emails, and translations of another Template, i.e.
+// the login pages and verification emails of a reusable auth module that
+// embeds its own templates:
+//
+//	auth, err := tpl.ParseModule(auth.FS, "templates", auth.FuncMap)
+//	templ, err := tpl.Parse(fs, nil)
+//	err = templ.Merge(auth)
+//
+// The merged views keep the layouts, partials, and functions of their module.
+// They're rendered, validated, and updated by name like the host's.
+//
+// The policy defaults to MergeError, translations conflict only when their
+// texts differ.
+func (templ *Template) Merge(other *Template, policy ...MergePolicy) error {
+	if other == templ {
+		return nil
+	}
+
+	p := MergeError
+	if len(policy) > 0 {
+		p = policy[0]
+	}
+
+	// other is copied and released before templ is locked, a.Merge(b) and
+	// b.Merge(a) can't deadlock
+	other = other.mergeSnapshot()
+
+	templ.mu.Lock()
+	defer templ.mu.Unlock()
+
+	catalogMu.Lock()
+	if p == MergeError {
+		if conflicts := templ.mergeConflicts(other); len(conflicts) > 0 {
+			catalogMu.Unlock()
+			return fmt.Errorf("merge conflicts: %s", strings.Join(conflicts, ", "))
+		}
+	}
+	added := templ.mergeTranslations(other, p)
+	catalogMu.Unlock()
+
+	for lang, msgs := range added {
+		audit(context.Background(), AuditTranslationsAdd, lang, 0, textKeys(msgs))
+	}
+
+	for name, t := range other.Views {
+		if _, ok := templ.Views[name]; ok {
+			if p == MergeKeep {
+				continue
+			}
+			delete(templ.sources, name)
+			delete(templ.history, name)
+			templ.resetCaches(name)
+		}
+
+		templ.Views[name] = t
+		templ.mergeViewState(other, name)
+	}
+
+	for name, t := range other.Emails {
+		if _, ok := templ.Emails[name]; ok {
+			if p == MergeKeep {
+				continue
+			}
+			delete(templ.history, name)
+		}
+
+		templ.Emails[name] = t
+		templ.mergeEmailState(other, name)
+	}
+
+	return nil
+}
+
+// mergeSnapshot returns a copy of what Merge reads of templ.
+func (templ *Template) mergeSnapshot() *Template {
+	templ.mu.RLock()
+	defer templ.mu.RUnlock()
+
+	return &Template{
+		Views:        maps.Clone(templ.Views),
+		Emails:       maps.Clone(templ.Emails),
+		funcMap:      templ.funcMap,
+		sources:      maps.Clone(templ.sources),
+		fsys:         templ.fsys,
+		root:         templ.root,
+		merged:       maps.Clone(templ.merged),
+		sandboxes:    maps.Clone(templ.sandboxes),
+		frontMatters: maps.Clone(templ.frontMatters),
+		baked:        maps.Clone(templ.baked),
+		translations: maps.Clone(templ.translations),
+	}
+}
+
+// mergeViewState copies what's known about a view of other besides its
+// parsed template. Callers must hold templ.mu.
+func (templ *Template) mergeViewState(other *Template, name string) {
+	templ.mergeEmailState(other, name)
+
+	if templ.sources == nil {
+		templ.sources = make(map[string][]string)
+	}
+	templ.sources[name] = other.sources[name]
+
+	if fm, ok := other.frontMatters[name]; ok {
+		if templ.frontMatters == nil {
+			templ.frontMatters = make(map[string]frontMatter)
+		}
+		templ.frontMatters[name] = fm
+	}
+
+	for key, b := range other.baked {
+		if strings.HasSuffix(key, "/"+name) {
+			if templ.baked == nil {
+				templ.baked = make(map[string][]byte)
+			}
+			templ.baked[key] = b
+		}
+	}
+}
+
+// mergeEmailState copies the origin and the Sandbox of an email or a view of
+// other. Callers must hold templ.mu.
+func (templ *Template) mergeEmailState(other *Template, name string) {
+	o, ok := other.merged[name]
+	if !ok {
+		o = viewOrigin{fsys: other.fsys, root: other.root, funcMap: other.funcMap}
+	}
+
+	if templ.merged == nil {
+		templ.merged = make(map[string]viewOrigin)
+	}
+	templ.merged[name] = o
+
+	if sb, ok := other.sandboxes[name]; ok {
+		templ.setSandbox(name, sb)
+	} else {
+		delete(templ.sandboxes, name)
+	}
+}
+
+func (templ *Template) setSandbox(name string, sb Sandbox) {
+	if templ.sandboxes == nil {
+		templ.sandboxes = make(map[string]Sandbox)
+	}
+	templ.sandboxes[name] = sb
+}
+
+// mergeTranslations adds the translations of other to the catalog and
+// returns the added texts by language. With MergeKeep, the keys templ has are
+// skipped. Callers must hold catalogMu.
+func (templ *Template) mergeTranslations(other *Template, p MergePolicy) map[string][]Text {
+	if messages == nil {
+		messages = make(map[string]map[string]Text)
+	}
+
+	added := make(map[string][]Text)
+	for lang, msgs := range other.translations {
+		own := make(map[string]bool)
+		for _, msg := range templ.translations[lang] {
+			own[msg.Key] = true
+		}
+
+		var add []Text
+		for _, msg := range msgs {
+			if own[msg.Key] && p == MergeKeep {
+				continue
+			}
+			add = append(add, msg)
+		}
+
+		if len(add) > 0 {
+			fillTranslations(lang, add)
+			added[lang] = add
+		}
+	}
+	return added
+}
+
+// mergeConflicts returns the sorted views, emails, and lang:key translations
+// of other that exist in templ. Callers must hold catalogMu.
+func (templ *Template) mergeConflicts(other *Template) []string {
+	var conflicts []string
+	for name := range other.Views {
+		if _, ok := templ.Views[name]; ok {
+			conflicts = append(conflicts, name)
+		}
+	}
+
+	for name := range other.Emails {
+		if _, ok := templ.Emails[name]; ok {
+			conflicts = append(conflicts, "emails/"+name)
+		}
+	}
+
+	for lang, msgs := range other.translations {
+		own := make(map[string]Text)
+		for _, msg := range templ.translations[lang] {
+			own[msg.Key] = msg
+		}
+
+		for _, msg := range msgs {
+			if mine, ok := own[msg.Key]; ok && !reflect.DeepEqual(mine, msg) {
+				conflicts = append(conflicts, lang+":"+msg.Key)
+			}
+		}
+	}
+
+	sort.Strings(conflicts)
+	return conflicts
+}
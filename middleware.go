@@ -0,0 +1,143 @@
+package tpl
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type contextKey int
+
+const langContextKey contextKey = iota
+
+// LanguageMiddleware resolves the language of the request in this order: the
+// lang query string parameter, the lang cookie, the Accept-Language header,
+// and finally the DefaultLang. Only the available languages are accepted.
+//
+// A language chosen via the query string is persisted in the cookie. Use
+// RequestLang in your handlers to fill the PageData:
+//
+//	data := tpl.PageData{Lang: tpl.RequestLang(r)}
+func LanguageMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang, fromQuery := detectLang(r)
+
+		if fromQuery {
+			http.SetCookie(w, &http.Cookie{
+				Name:     langCookieName(),
+				Value:    lang,
+				Path:     "/",
+				Expires:  time.Now().AddDate(1, 0, 0),
+				SameSite: http.SameSiteLaxMode,
+				HttpOnly: true,
+			})
+		}
+
+		ctx := context.WithValue(r.Context(), langContextKey, lang)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestLang returns the language resolved by LanguageMiddleware, or the
+// DefaultLang if the middleware did not run.
+func RequestLang(r *http.Request) string {
+	if lang, ok := r.Context().Value(langContextKey).(string); ok {
+		return lang
+	}
+	return defaultLang()
+}
+
+// detectLang returns the language of the request and whether it comes from the
+// query string.
+func detectLang(r *http.Request) (string, bool) {
+	if lang, ok := matchLang(r.URL.Query().Get("lang")); ok {
+		return lang, true
+	}
+
+	if c, err := r.Cookie(langCookieName()); err == nil {
+		if lang, ok := matchLang(c.Value); ok {
+			return lang, false
+		}
+	}
+
+	for _, al := range parseAcceptLanguage(r.Header.Get("Accept-Language")) {
+		if lang, ok := matchLang(al); ok {
+			return lang, false
+		}
+	}
+
+	return defaultLang(), false
+}
+
+// matchLang returns the available language matching exactly, or by its base
+// language, i.e. fr-CA matches fr.
+func matchLang(s string) (string, bool) {
+	if len(s) == 0 {
+		return "", false
+	}
+
+	langs := availableLanguages()
+	for _, lang := range langs {
+		if strings.EqualFold(lang, s) {
+			return lang, true
+		}
+	}
+
+	base, _, _ := strings.Cut(s, "-")
+	for _, lang := range langs {
+		if strings.EqualFold(lang, base) {
+			return lang, true
+		}
+	}
+	return "", false
+}
+
+func langCookieName() string {
+	if len(config.LangCookieName) == 0 {
+		return "lang"
+	}
+	return config.LangCookieName
+}
+
+// parseAcceptLanguage returns the acceptable languages of an Accept-Language
+// header sorted by their quality value.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+
+	var list []weighted
+	for _, part := range strings.Split(header, ",") {
+		lang, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if len(lang) == 0 || lang == "*" {
+			continue
+		}
+
+		q := 1.0
+		for _, p := range strings.Split(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(p), "q="); ok {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+
+		// q=0 means not acceptable
+		if q <= 0 {
+			continue
+		}
+		list = append(list, weighted{lang: lang, q: q})
+	}
+
+	sort.SliceStable(list, func(i, j int) bool { return list[i].q > list[j].q })
+
+	langs := make([]string, len(list))
+	for i, w := range list {
+		langs[i] = w.lang
+	}
+	return langs
+}
@@ -0,0 +1,46 @@
+package tpl_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dstpierre/tpl"
+)
+
+func TestLanguageMiddleware(t *testing.T) {
+	load(t)
+
+	var lang string
+	h := tpl.LanguageMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang = tpl.RequestLang(r)
+	}))
+
+	tests := []struct {
+		url    string
+		cookie string
+		accept string
+		want   string
+	}{
+		{"/?lang=fr", "en", "en", "fr"},
+		{"/", "fr", "en", "fr"},
+		{"/", "", "de-DE,fr-CA;q=0.8,en;q=0.5", "fr"},
+		{"/", "", "de", "en"},
+		{"/", "", "fr;q=0", "en"},
+		{"/", "", "fr;q=0,de;q=0.5", "en"},
+		{"/", "", "en;q=0, fr;q=0.1", "fr"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.url, nil)
+		if len(tt.cookie) > 0 {
+			req.AddCookie(&http.Cookie{Name: "lang", Value: tt.cookie})
+		}
+		req.Header.Set("Accept-Language", tt.accept)
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if lang != tt.want {
+			t.Errorf("%s: expected %s got %s", tt.url, tt.want, lang)
+		}
+	}
+}
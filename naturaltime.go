@@ -0,0 +1,178 @@
+package tpl
+
+import (
+	"time"
+)
+
+// naturalTimeTexts are the built-in texts of naturaltime, naturalday, and
+// readingtime, used when the translation files don't define the
+// naturaltime-*, naturalday-*, and readingtime keys.
+var naturalTimeTexts = map[string]map[string]Text{
+	"en": {
+		"naturaltime-now":      {Value: "just now"},
+		"naturaltime-ago":      {Value: "{time} ago"},
+		"naturaltime-in":       {Value: "in {time}"},
+		"naturaltime-second":   {Value: "{count} second", PluralValue: "{count} seconds"},
+		"naturaltime-minute":   {Value: "{count} minute", PluralValue: "{count} minutes"},
+		"naturaltime-hour":     {Value: "{count} hour", PluralValue: "{count} hours"},
+		"naturaltime-day":      {Value: "{count} day", PluralValue: "{count} days"},
+		"naturaltime-month":    {Value: "{count} month", PluralValue: "{count} months"},
+		"naturaltime-year":     {Value: "{count} year", PluralValue: "{count} years"},
+		"naturalday-today":     {Value: "today"},
+		"naturalday-yesterday": {Value: "yesterday"},
+		"naturalday-tomorrow":  {Value: "tomorrow"},
+		"readingtime":          {Value: "{count} min read"},
+	},
+	"fr": {
+		"naturaltime-now":      {Value: "à l'instant"},
+		"naturaltime-ago":      {Value: "il y a {time}"},
+		"naturaltime-in":       {Value: "dans {time}"},
+		"naturaltime-second":   {Value: "{count} seconde", PluralValue: "{count} secondes"},
+		"naturaltime-minute":   {Value: "{count} minute", PluralValue: "{count} minutes"},
+		"naturaltime-hour":     {Value: "{count} heure", PluralValue: "{count} heures"},
+		"naturaltime-day":      {Value: "{count} jour", PluralValue: "{count} jours"},
+		"naturaltime-month":    {Value: "{count} mois", PluralValue: "{count} mois"},
+		"naturaltime-year":     {Value: "{count} an", PluralValue: "{count} ans"},
+		"naturalday-today":     {Value: "aujourd'hui"},
+		"naturalday-yesterday": {Value: "hier"},
+		"naturalday-tomorrow":  {Value: "demain"},
+		"readingtime":          {Value: "{count} min de lecture"},
+	},
+}
+
+// naturalText returns the text of a naturaltime key from the translation
+// files, falling back to the built-in texts of the language, then English.
+func naturalText(lang, key string) Text {
+	if v, ok := findMessage(lang, key); ok {
+		return v
+	}
+
+	if texts, ok := naturalTimeTexts[localeLang(lang)]; ok {
+		return texts[key]
+	}
+
+	return naturalTimeTexts["en"][key]
+}
+
+// NaturalTime returns how long ago or in how long a time is relative to now
+// in the page language, e.g. "5 minutes ago" or "dans 3 jours". Now is the
+// time returned by the Now option, if set:
+//
+//	{{ naturaltime .Lang .Data.CreatedAt }}
+//
+// The words come from the naturaltime-now, naturaltime-ago, naturaltime-in,
+// and naturaltime-minute, -hour, -day, -month, and -year keys of the
+// translation files, with built-in English and French defaults. A zero time,
+// an unset field, returns "".
+func NaturalTime(lang string, t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	d := now().Sub(t)
+
+	phrase := "naturaltime-ago"
+	if d < 0 {
+		phrase = "naturaltime-in"
+		d = -d
+	}
+
+	if d < time.Minute {
+		return present("naturaltime-now", localize(naturalText(lang, "naturaltime-now").Value))
+	}
+
+	_, amount := naturalAmount(lang, d)
+	args := []map[string]any{{"time": amount}}
+	return present(phrase, replacePlaceholders(localize(naturalText(lang, phrase).Value), args))
+}
+
+// naturalAmount returns a positive duration in its largest unit, e.g. "3
+// days", in the page language, and the key of the unit.
+func naturalAmount(lang string, d time.Duration) (string, string) {
+	var unit string
+	var count int64
+
+	switch {
+	case d < time.Minute:
+		unit, count = "naturaltime-second", int64(d/time.Second)
+	case d < time.Hour:
+		unit, count = "naturaltime-minute", int64(d/time.Minute)
+	case d < 24*time.Hour:
+		unit, count = "naturaltime-hour", int64(d/time.Hour)
+	case d < 30*24*time.Hour:
+		unit, count = "naturaltime-day", int64(d/(24*time.Hour))
+	case d < 365*24*time.Hour:
+		unit, count = "naturaltime-month", int64(d/(30*24*time.Hour))
+	default:
+		unit, count = "naturaltime-year", int64(d/(365*24*time.Hour))
+	}
+
+	return unit, unitText(lang, unit, count)
+}
+
+// unitText returns a count of a naturaltime unit in the page language, e.g.
+// "3 days".
+func unitText(lang, unit string, count int64) string {
+	args := []map[string]any{{"count": count}}
+	return replacePlaceholders(localize(naturalText(lang, unit).pluralValue(lang, count)), args)
+}
+
+// TimeSince returns how long ago a time is, without "ago", e.g. "3 days" or
+// "2 heures", for copy composing it differently. Times in the future return
+// the amount of 0 seconds, and a zero time, an unset field, returns "":
+//
+//	{{ t .Lang "member-for" (map "time" (timesince .Lang .Data.JoinedAt)) }}
+func TimeSince(lang string, t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return present(naturalAmount(lang, max(now().Sub(t), 0)))
+}
+
+// TimeUntil returns how long until a time, without "in", e.g. "2 hours",
+// like TimeSince:
+//
+//	{{ t .Lang "expires-in" (map "time" (timeuntil .Lang .Data.ExpiresAt)) }}
+func TimeUntil(lang string, t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return present(naturalAmount(lang, max(t.Sub(now()), 0)))
+}
+
+// NaturalDay returns "today", "yesterday", or "tomorrow" in the language of
+// the locale for a date around now, and the shortdate of the others, for
+// activity feeds:
+//
+//	{{ naturalday .Locale .Data.CreatedAt .Timezone }}
+//
+// The optional IANA time zone decides the viewer's day, an empty zone uses
+// the Timezone option. The words come from the naturalday-today,
+// naturalday-yesterday, and naturalday-tomorrow keys of the translation
+// files, with built-in English and French defaults.
+func NaturalDay(locale string, d time.Time, timezone ...string) string {
+	if len(timezone) > 0 {
+		d = inZone(d, timezone[0])
+	}
+	today := now().In(d.Location())
+
+	y, m, day := today.Date()
+	midnight := time.Date(y, m, day, 0, 0, 0, 0, today.Location())
+
+	y, m, day = d.Date()
+	days := int(time.Date(y, m, day, 0, 0, 0, 0, today.Location()).Sub(midnight).Round(time.Hour) / (24 * time.Hour))
+
+	var key string
+	switch days {
+	case 0:
+		key = "naturalday-today"
+	case -1:
+		key = "naturalday-yesterday"
+	case 1:
+		key = "naturalday-tomorrow"
+	default:
+		return ToDate(locale, d)
+	}
+
+	return present(key, localize(naturalText(localeLang(locale), key).Value))
+}
@@ -0,0 +1,158 @@
+package tpl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+// OfflineOptions describes the offline shell of a progressive web app.
+type OfflineOptions struct {
+	// View is the view rendered as the offline shell, i.e. app/offline.html.
+	View string
+	// Data is the PageData of the shell render.
+	Data PageData
+	// ShellURL is the URL the shell is served on, "/offline" if empty.
+	ShellURL string
+	// Assets are the static files to precache, i.e. your embedded static
+	// directory.
+	Assets fs.FS
+	// AssetsURL is the URL prefix of the assets, "/static" if empty.
+	AssetsURL string
+}
+
+// PrecacheEntry is an URL to precache and the revision of its content, in
+// the format of the Workbox precache manifest.
+type PrecacheEntry struct {
+	URL      string `json:"url"`
+	Revision string `json:"revision"`
+}
+
+// OfflineShell is the rendered offline shell and the manifest of the files a
+// service worker precaches.
+type OfflineShell struct {
+	HTML []byte
+	// Precache lists the shell followed by the assets.
+	Precache []PrecacheEntry
+}
+
+// OfflineShell renders the offline shell view and fingerprints the assets so
+// a service worker can precache them, giving a tpl app offline support
+// without a JavaScript build step:
+//
+//	shell, err := templ.OfflineShell(tpl.OfflineOptions{
+//		View:   "app/offline.html",
+//		Assets: staticFS,
+//	})
+//	http.HandleFunc("/offline", func(w http.ResponseWriter, r *http.Request) {
+//		w.Write(shell.HTML)
+//	})
+//	http.HandleFunc("/sw.js", func(w http.ResponseWriter, r *http.Request) {
+//		w.Header().Set("Content-Type", "text/javascript")
+//		w.Write(shell.ServiceWorker())
+//	})
+func (templ *Template) OfflineShell(opts OfflineOptions) (OfflineShell, error) {
+	shellURL := opts.ShellURL
+	if len(shellURL) == 0 {
+		shellURL = "/offline"
+	}
+
+	assetsURL := opts.AssetsURL
+	if len(assetsURL) == 0 {
+		assetsURL = "/static"
+	}
+
+	var buf bytes.Buffer
+	if err := templ.Render(&buf, opts.View, opts.Data); err != nil {
+		return OfflineShell{}, err
+	}
+
+	shell := OfflineShell{
+		HTML:     buf.Bytes(),
+		Precache: []PrecacheEntry{{URL: shellURL, Revision: revision(buf.Bytes())}},
+	}
+
+	if opts.Assets == nil {
+		return shell, nil
+	}
+
+	err := fs.WalkDir(opts.Assets, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		b, err := fs.ReadFile(opts.Assets, p)
+		if err != nil {
+			return err
+		}
+
+		shell.Precache = append(shell.Precache, PrecacheEntry{
+			URL:      path.Join(assetsURL, p),
+			Revision: revision(b),
+		})
+		return nil
+	})
+	return shell, err
+}
+
+// revision returns the fingerprint of a file content.
+func revision(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ManifestJSON returns the precache manifest as JSON.
+func (s OfflineShell) ManifestJSON() ([]byte, error) {
+	return json.Marshal(s.Precache)
+}
+
+// ServiceWorker returns a service worker script that precaches the manifest
+// on install, removes the caches of previous versions on activate, serves
+// the assets from the cache, and falls back to the shell when a navigation
+// fails.
+func (s OfflineShell) ServiceWorker() []byte {
+	manifest, _ := s.ManifestJSON()
+
+	var all []byte
+	for _, e := range s.Precache {
+		all = append(all, e.Revision...)
+	}
+
+	return []byte(fmt.Sprintf(serviceWorkerJS, "tpl-"+revision(all), manifest, s.Precache[0].URL))
+}
+
+const serviceWorkerJS = `const CACHE = %q;
+const PRECACHE = %s;
+const SHELL = %q;
+
+self.addEventListener("install", (event) => {
+  event.waitUntil(
+    caches.open(CACHE).then((cache) => cache.addAll(PRECACHE.map((e) => e.url)))
+  );
+  self.skipWaiting();
+});
+
+self.addEventListener("activate", (event) => {
+  event.waitUntil(
+    caches.keys().then((keys) =>
+      Promise.all(keys.filter((k) => k !== CACHE).map((k) => caches.delete(k)))
+    )
+  );
+  self.clients.claim();
+});
+
+self.addEventListener("fetch", (event) => {
+  if (event.request.mode === "navigate") {
+    event.respondWith(fetch(event.request).catch(() => caches.match(SHELL)));
+    return;
+  }
+
+  event.respondWith(
+    caches.match(event.request).then((res) => res || fetch(event.request))
+  );
+});
+`
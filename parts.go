@@ -0,0 +1,53 @@
+package tpl
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"text/template/parse"
+)
+
+// RenderParts renders each top-level block of a view separately, the blocks
+// and templates the layout calls directly, like "title", "nav", and
+// "content". The outputs are keyed by block name.
+//
+// This is useful when the page is assembled elsewhere, i.e. by an edge worker
+// or a non-Go host page embedding a fragment rendered by tpl:
+//
+//	parts, err := templ.RenderParts("app/dashboard.html", data)
+//	head := parts["title"]
+func (templ *Template) RenderParts(view string, data PageData) (map[string][]byte, error) {
+	view, v, ok := templ.lookupView(view)
+	if !ok {
+		return nil, errors.New("can't find view: " + view)
+	}
+
+	parts := make(map[string][]byte)
+	for _, name := range topLevelBlocks(v) {
+		var buf bytes.Buffer
+		if _, err := templ.render(&buf, view, name, data); err != nil {
+			return nil, err
+		}
+
+		parts[name] = buf.Bytes()
+	}
+	return parts, nil
+}
+
+// topLevelBlocks returns the names of the templates called by the layout,
+// outside of any {{define}}, in the order they appear.
+func topLevelBlocks(t *template.Template) []string {
+	if t.Tree == nil {
+		return nil
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	walkNodes(t.Tree.Root, func(n parse.Node) {
+		if tn, ok := n.(*parse.TemplateNode); ok && !seen[tn.Name] {
+			seen[tn.Name] = true
+			names = append(names, tn.Name)
+		}
+	})
+	return names
+}
@@ -0,0 +1,147 @@
+package tpl
+
+import (
+	"strings"
+)
+
+// phoneRegion holds the numbering plan of a region: its calling code, its
+// trunk prefix, and the digit groups of its national numbers by length.
+type phoneRegion struct {
+	code  string
+	trunk string
+	// groups returns the sizes of the digit groups of a national number
+	// without the trunk prefix.
+	groups func(n string) []int
+	// national formats the groups for a national number, if set.
+	national func(g []string) string
+}
+
+var phoneRegions = map[string]phoneRegion{
+	"US": nanp,
+	"CA": nanp,
+	"FR": {code: "33", trunk: "0", groups: fixedGroups(9, 1, 2, 2, 2, 2)},
+	"BE": {code: "32", trunk: "0", groups: func(n string) []int {
+		if len(n) == 9 {
+			return []int{3, 2, 2, 2}
+		}
+		return fixedGroups(8, 1, 3, 2, 2)(n)
+	}},
+	"CH": {code: "41", trunk: "0", groups: fixedGroups(9, 2, 3, 2, 2)},
+	"ES": {code: "34", groups: fixedGroups(9, 3, 3, 3)},
+	"IT": {code: "39", groups: fixedGroups(10, 3, 3, 4)},
+	"GB": {code: "44", trunk: "0", groups: func(n string) []int {
+		if len(n) != 10 {
+			return nil
+		} else if strings.HasPrefix(n, "2") {
+			return []int{2, 4, 4}
+		}
+		return []int{4, 6}
+	}},
+	"AU": {code: "61", trunk: "0", groups: func(n string) []int {
+		if len(n) != 9 {
+			return nil
+		} else if strings.HasPrefix(n, "4") {
+			return []int{3, 3, 3}
+		}
+		return []int{1, 4, 4}
+	}},
+}
+
+// nanp is the North American Numbering Plan.
+var nanp = phoneRegion{
+	code:   "1",
+	groups: fixedGroups(10, 3, 3, 4),
+	national: func(g []string) string {
+		return "(" + g[0] + ") " + g[1] + "-" + g[2]
+	},
+}
+
+// fixedGroups returns the groups of numbers of a single length.
+func fixedGroups(length int, groups ...int) func(string) []int {
+	return func(n string) []int {
+		if len(n) != length {
+			return nil
+		}
+		return groups
+	}
+}
+
+// Phone formats a phone number of a region in its national form, e.g.
+// (514) 555-0123 for CA or 01 23 45 67 89 for FR. With the "international"
+// format, the calling code is added and the trunk prefix removed, e.g.
+// +1 514-555-0123 and +33 1 23 45 67 89:
+//
+//	{{ phone "CA" .Data.Phone }}
+//	{{ phone .Locale .Data.Phone "international" }}
+//
+// The region is an ISO 3166 code or a locale. Numbers starting with + are
+// formatted with the plan of their calling code. Numbers that don't match the
+// plan of the region are returned as is.
+func Phone(region, number string, format ...string) string {
+	if _, r, ok := strings.Cut(strings.ReplaceAll(region, "_", "-"), "-"); ok {
+		region = r
+	}
+	region = strings.ToUpper(region)
+
+	intl := strings.HasPrefix(strings.TrimSpace(number), "+")
+
+	var digits strings.Builder
+	for _, r := range number {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	n := digits.String()
+
+	pr, ok := phoneRegions[region]
+	if intl {
+		// the plan of the calling code, preferring the region's
+		if !ok || !strings.HasPrefix(n, pr.code) {
+			ok = false
+			for _, r := range phoneRegions {
+				if strings.HasPrefix(n, r.code) {
+					pr, ok = r, true
+					break
+				}
+			}
+		}
+		if ok {
+			n = strings.TrimPrefix(n, pr.code)
+		}
+	}
+	if !ok {
+		return number
+	}
+
+	if !intl {
+		if len(pr.trunk) > 0 {
+			n = strings.TrimPrefix(n, pr.trunk)
+		} else if pr.code == "1" && len(n) == 11 {
+			n = strings.TrimPrefix(n, "1")
+		}
+	}
+
+	sizes := pr.groups(n)
+	if sizes == nil {
+		return number
+	}
+
+	groups := make([]string, len(sizes))
+	for i, size := range sizes {
+		groups[i], n = n[:size], n[size:]
+	}
+
+	if len(format) > 0 && format[0] == "international" {
+		sep := " "
+		if pr.national != nil {
+			sep = "-"
+		}
+		return "+" + pr.code + " " + strings.Join(groups, sep)
+	}
+
+	if pr.national != nil {
+		return pr.national(groups)
+	}
+	groups[0] = pr.trunk + groups[0]
+	return strings.Join(groups, " ")
+}
@@ -0,0 +1,77 @@
+package tpl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// irregularPlurals are the English words not following the suffix rules.
+var irregularPlurals = map[string]string{
+	"person": "people", "child": "children", "man": "men", "woman": "women",
+	"mouse": "mice", "foot": "feet", "tooth": "teeth", "goose": "geese",
+	"ox": "oxen", "leaf": "leaves", "knife": "knives", "wife": "wives",
+	"life": "lives", "half": "halves", "wolf": "wolves", "shelf": "shelves",
+	"thief": "thieves", "loaf": "loaves", "hero": "heroes", "potato": "potatoes",
+	"tomato": "tomatoes", "echo": "echoes", "veto": "vetoes", "cactus": "cacti",
+	"analysis": "analyses", "crisis": "crises", "criterion": "criteria",
+	"datum": "data", "index": "indices", "matrix": "matrices", "quiz": "quizzes",
+}
+
+// uncountables are the English words whose plural is the singular.
+var uncountables = map[string]bool{
+	"sheep": true, "fish": true, "deer": true, "series": true, "species": true,
+	"information": true, "equipment": true, "news": true, "rice": true,
+	"money": true, "feedback": true, "software": true, "metadata": true,
+}
+
+// Pluralize returns a count followed by the English singular or plural form
+// of a word, derived if it's not given:
+//
+//	{{ pluralize .Data.Count "category" }}        1 category, 3 categories
+//	{{ pluralize .Data.Count "octopus" "octopi" }}
+//
+// Only 1 takes the singular. For other languages, use the plurals of the
+// translation files with tp.
+func Pluralize(count any, singular string, plural ...string) string {
+	n, ok := toFloat64(count)
+	if ok && (n == 1 || n == -1) {
+		return fmt.Sprint(count) + " " + singular
+	}
+
+	if len(plural) > 0 {
+		return fmt.Sprint(count) + " " + plural[0]
+	}
+	return fmt.Sprint(count) + " " + pluralWord(singular)
+}
+
+// pluralWord derives the English plural of a word, keeping its case.
+func pluralWord(word string) string {
+	lower := strings.ToLower(word)
+	if len(lower) == 0 || uncountables[lower] {
+		return word
+	}
+
+	var p string
+	if irr, ok := irregularPlurals[lower]; ok {
+		p = irr
+	} else {
+		switch {
+		case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsAny(lower[len(lower)-2:len(lower)-1], "aeiou"):
+			p = lower[:len(lower)-1] + "ies"
+		case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+			strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+			p = lower + "es"
+		default:
+			p = lower + "s"
+		}
+	}
+
+	if strings.HasPrefix(p, lower) {
+		return word + p[len(lower):]
+	}
+
+	if word[:1] == strings.ToUpper(word[:1]) {
+		return strings.ToUpper(p[:1]) + p[1:]
+	}
+	return p
+}
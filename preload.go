@@ -0,0 +1,104 @@
+package tpl
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+)
+
+type preload struct {
+	href string
+	as   string
+}
+
+// link returns the value of the Link header for this resource.
+func (p preload) link() string {
+	return fmt.Sprintf("<%s>; rel=preload; as=%s", p.href, p.as)
+}
+
+// Preload registers a resource the browser should fetch early and returns the
+// matching <link rel="preload"> tag.
+//
+// Inside your templates:
+//
+//	{{ preload . "/css/app.css" "style" }}
+//
+// When rendering with RenderHTTP, the registered resources are also sent as
+// Link headers.
+func Preload(data PageData, href, as string) template.HTML {
+	p := preload{href: href, as: as}
+	if data.state != nil {
+		data.state.preloads = append(data.state.preloads, p)
+	}
+
+	return template.HTML(fmt.Sprintf(
+		`<link rel="preload" href="%s" as="%s">`,
+		template.HTMLEscapeString(href),
+		template.HTMLEscapeString(as),
+	))
+}
+
+// RenderHTTP renders a view like Render, but buffers the output and adds a
+// Link header for every resource registered via the preload function, and
+// the headers declared via the header function, before writing the page.
+//
+// If the EarlyHints option is set, the resources preloaded by the last render
+// of the same view are sent as a 103 Early Hints response before the view is
+// executed.
+func (templ *Template) RenderHTTP(w http.ResponseWriter, view string, data PageData) error {
+	if config.EarlyHints {
+		if links := templ.earlyHints(view); len(links) > 0 {
+			for _, l := range links {
+				w.Header().Add("Link", l)
+			}
+			w.WriteHeader(http.StatusEarlyHints)
+		}
+	}
+
+	var buf bytes.Buffer
+	state, err := templ.render(&buf, view, "", data)
+	if err != nil {
+		return err
+	}
+
+	var links []string
+	for _, p := range state.preloads {
+		links = append(links, p.link())
+	}
+
+	templ.setEarlyHints(view, links)
+
+	w.Header().Del("Link")
+	for _, l := range links {
+		w.Header().Add("Link", l)
+	}
+
+	for name, values := range state.headers {
+		w.Header()[name] = values
+	}
+
+	if len(w.Header().Get("Content-Type")) == 0 {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+func (templ *Template) earlyHints(view string) []string {
+	templ.mu.RLock()
+	defer templ.mu.RUnlock()
+
+	return templ.hints[view]
+}
+
+func (templ *Template) setEarlyHints(view string, links []string) {
+	templ.mu.Lock()
+	defer templ.mu.Unlock()
+
+	if templ.hints == nil {
+		templ.hints = make(map[string][]string)
+	}
+	templ.hints[view] = links
+}
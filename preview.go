@@ -0,0 +1,155 @@
+package tpl
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"sort"
+	"text/template/parse"
+)
+
+// ChangePreview compares a candidate source of a view or an email with its
+// live version before it's published via UpdateView or UpdateEmail.
+type ChangePreview struct {
+	// Output and LiveOutput are the candidate and live versions rendered
+	// with the sample data.
+	Output     string
+	LiveOutput string
+
+	// Diff is the line diff from LiveOutput to Output.
+	Diff []DiffLine
+
+	// Added, Removed, and Changed are the names of the templates, defined
+	// via {{define}} or {{block}}, that differ from the live version.
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// PreviewChange parses the candidate source of a view or an email in
+// isolation, renders it and the live version with sample data, and returns
+// their differences. The live version keeps being rendered.
+//
+// The sample data of a view is a PageData, other values are used as its
+// Data.
+func (templ *Template) PreviewChange(name string, src []byte, data any) (*ChangePreview, error) {
+	templ.mu.RLock()
+	live, isView := templ.Views[name]
+	if !isView {
+		live = templ.Emails[name]
+	}
+	liveFM := templ.frontMatters[name]
+	templ.mu.RUnlock()
+
+	if live == nil {
+		return nil, errors.New("can't find view or email: " + name)
+	}
+
+	var candidate *template.Template
+	var err error
+	if isView {
+		candidate, err = templ.parseView(name, src)
+	} else {
+		candidate, err = templ.parseEmail(name, src)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	liveSrc, err := templ.liveSource(name)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &ChangePreview{}
+	if err := p.compareDefinitions(name, liveSrc, src, templ.origin(name).funcMap); err != nil {
+		return nil, err
+	}
+
+	var fm frontMatter
+	if isMarkdown(name) {
+		fm, _ = splitFrontMatter(src)
+	}
+
+	p.Output, err = templ.renderPreview(name, candidate, fm, isView, data)
+	if err != nil {
+		return nil, err
+	}
+
+	p.LiveOutput, err = templ.renderPreview(name, live, liveFM, isView, data)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Diff = diffText(p.LiveOutput, p.Output)
+	return p, nil
+}
+
+// compareDefinitions fills the templates added, removed, and changed by the
+// candidate source.
+func (p *ChangePreview) compareDefinitions(name string, liveSrc, src []byte, funcMap map[string]any) error {
+	before, err := parse.Parse(name, string(liveSrc), "", "", funcMap, builtins)
+	if err != nil {
+		return err
+	}
+
+	after, err := parse.Parse(name, string(src), "", "", funcMap, builtins)
+	if err != nil {
+		return err
+	}
+
+	for n, t := range after {
+		if bt, ok := before[n]; !ok {
+			p.Added = append(p.Added, n)
+		} else if bt.Root.String() != t.Root.String() {
+			p.Changed = append(p.Changed, n)
+		}
+	}
+
+	for n := range before {
+		if _, ok := after[n]; !ok {
+			p.Removed = append(p.Removed, n)
+		}
+	}
+
+	sort.Strings(p.Added)
+	sort.Strings(p.Removed)
+	sort.Strings(p.Changed)
+	return nil
+}
+
+// renderPreview renders a view or an email outside of the live Template, so
+// its caches and the AfterRender option are left untouched. fm is the front
+// matter of a markdown view.
+func (templ *Template) renderPreview(name string, t *template.Template, fm frontMatter, isView bool, data any) (string, error) {
+	preview := &Template{
+		FS:        templ.FS,
+		funcMap:   templ.funcMap,
+		partials:  templ.partials,
+		sources:   templ.sources,
+		fsys:      templ.fsys,
+		root:      templ.root,
+		merged:    templ.merged,
+		sandboxes: templ.sandboxes,
+		preview:   true,
+	}
+
+	var buf bytes.Buffer
+	if !isView {
+		preview.Emails = map[string]*template.Template{name: t}
+		err := preview.RenderEmail(&buf, name, data)
+		return buf.String(), err
+	}
+
+	pd, ok := data.(PageData)
+	if !ok {
+		pd = PageData{Data: data}
+	}
+
+	preview.Views = map[string]*template.Template{name: t}
+	if fm != nil {
+		preview.frontMatters = map[string]frontMatter{name: fm}
+	}
+	err := preview.Render(&buf, name, pd)
+	return buf.String(), err
+}
@@ -0,0 +1,68 @@
+package tpl
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// maxQRCodeSize limits the pixels of a QR code, sandboxed templates can't
+// allocate huge images.
+const maxQRCodeSize = 2048
+
+// QRCode encodes a text, i.e. a URL or an otpauth:// URI, to the data URI of
+// a QR code image of size pixels, a PNG by default or an SVG with the "svg"
+// format:
+//
+//	<img src="{{ qrcode .Data.TOTPURI 256 }}" alt="Scan to set up 2FA">
+//	<img src="{{ qrcode .Data.TicketURL 200 "svg" }}" alt="Ticket">
+//
+// The size is at most 2048 pixels.
+func QRCode(text string, size int, format ...string) (template.URL, error) {
+	if size <= 0 || size > maxQRCodeSize {
+		return "", fmt.Errorf("qrcode: the size %d is not between 1 and %d", size, maxQRCodeSize)
+	}
+
+	q, err := qrcode.New(text, qrcode.Medium)
+	if err != nil {
+		return "", err
+	}
+
+	if len(format) > 0 && format[0] == "svg" {
+		svg := qrSVG(q.Bitmap(), size)
+		return template.URL("data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString([]byte(svg))), nil
+	}
+
+	b, err := q.PNG(size)
+	if err != nil {
+		return "", err
+	}
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(b)), nil
+}
+
+// qrSVG draws the dark modules of a QR code bitmap, its quiet zone included,
+// as a single path of horizontal runs.
+func qrSVG(bitmap [][]bool, size int) string {
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+
+			run := 1
+			for x+run < len(row) && row[x+run] {
+				run++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv1h-%dz", x, y, run, run)
+			x += run - 1
+		}
+	}
+
+	n := len(bitmap)
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="%d" height="%d" fill="#fff"/><path d="%s" fill="#000"/></svg>`, size, size, n, n, n, n, path.String())
+}
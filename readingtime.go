@@ -0,0 +1,31 @@
+package tpl
+
+import (
+	"strings"
+)
+
+// defaultWordsPerMinute is the average silent reading speed of adults.
+const defaultWordsPerMinute = 230
+
+// ReadingTime estimates how long it takes to read a text or an HTML content,
+// in minutes rounded up, in the page language, e.g. "5 min read" or
+// "5 min de lecture":
+//
+//	<span>{{ readingtime .Lang .Data.Body }}</span>
+//
+// The reading speed is the WordsPerMinute option. The text comes from the
+// readingtime key of the translation files, with the {count} placeholder and
+// built-in English and French defaults.
+func ReadingTime(lang, content string) string {
+	wpm := config.WordsPerMinute
+	if wpm <= 0 {
+		wpm = defaultWordsPerMinute
+	}
+
+	words := len(strings.Fields(StripTags(content)))
+	minutes := max((words+wpm-1)/wpm, 1)
+
+	text := naturalText(lang, "readingtime")
+	args := []map[string]any{{"count": minutes}}
+	return present("readingtime", replacePlaceholders(localize(text.pluralValue(lang, int64(minutes))), args))
+}
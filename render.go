@@ -55,7 +55,7 @@
 //	  "plural": "optional if plural is needed",
 //	}]
 //
-// There's four different template function helpers:
+// There's five different template function helpers:
 //
 // 1. {{ t .Lang "a unique key" }}
 //
@@ -64,17 +64,26 @@
 // 3. {{ tf .Lang "a formatted" .Data.AnArray }}
 //
 // 4. {{ tpf .Lang "foramtted and pluralized" 2 .Data.AnArray }}
+//
+// 5. {{ tm .Lang "an ICU MessageFormat message" (map "count" 2) }}
 package tpl
 
 import (
+	"bytes"
 	"embed"
 	"errors"
 	"fmt"
 	"html/template"
 	"io"
+	iofs "io/fs"
+	"net/http"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 )
 
 // Template holds the file system and the parsed views.
@@ -82,6 +91,46 @@ type Template struct {
 	FS     embed.FS
 	Views  map[string]*template.Template
 	Emails map[string]*template.Template
+
+	funcMap  map[string]any
+	partials []string
+	sources  map[string][]string
+	fsys     iofs.FS
+	root     string
+
+	// merged holds the origin of the views and emails merged from another
+	// Template.
+	merged map[string]viewOrigin
+
+	// sandboxes holds the Sandbox of the views and emails using templates
+	// from an untrusted source.
+	sandboxes map[string]Sandbox
+
+	mu           sync.RWMutex
+	hints        map[string][]string
+	stacked      map[string]bool
+	instrumented map[string]bool
+	// instrumenting holds a mutex per view being instrumented in DevMode.
+	instrumenting map[string]*sync.Mutex
+	inline        map[string]*template.Template
+
+	// history holds the versions of the views and emails updated at runtime.
+	history map[string]*versionHistory
+
+	// preview is set on the Template rendering a ChangePreview.
+	preview bool
+
+	// baked holds the output of the static views rendered via Bake.
+	baked map[string][]byte
+
+	// frontMatters holds the front matter of the markdown views.
+	frontMatters map[string]frontMatter
+
+	// translations holds the texts of the translation files by language,
+	// added to the catalog of the Template it's merged into.
+	translations map[string][]Text
+
+	stats statsRecorder
 }
 
 // Parse parses and load the layouts, templates, partials, and optionally the
@@ -90,28 +139,79 @@ type Template struct {
 // You should embed the templates in your program and pass the `embed.FS` to the
 // function.
 func Parse(fs embed.FS, funcMap map[string]any) (*Template, error) {
+	return ParseSources(fs, funcMap)
+}
+
+// ParseSources parses the embedded templates like Parse, merged with the files
+// of the sources, i.e. views or emails customers edited and stored in a
+// database or S3. A file from a source replaces the embedded file at the same
+// path, and later sources take precedence over earlier ones.
+func ParseSources(fs embed.FS, funcMap map[string]any, sources ...TemplateSource) (*Template, error) {
+	fsys, err := overlay(fs, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseFS(fs, fsys, config.TemplateRootName, funcMap, false)
+}
+
+// ParseFS parses the templates of a file system like Parse, i.e. an
+// os.DirFS for the tools working on the templates of a program.
+func ParseFS(fsys iofs.FS, funcMap map[string]any) (*Template, error) {
+	return parseFS(asEmbedFS(fsys), fsys, config.TemplateRootName, funcMap, false)
+}
+
+// asEmbedFS returns the file system if it's an embed.FS, for the FS field of
+// the Template.
+func asEmbedFS(fsys iofs.FS) embed.FS {
+	efs, _ := fsys.(embed.FS)
+	return efs
+}
+
+// parseFS parses the templates under root. The translations of a module are
+// kept for Merge, otherwise they replace the catalog.
+func parseFS(embedded embed.FS, fs iofs.FS, root string, funcMap map[string]any, module bool) (*Template, error) {
 	if funcMap == nil {
 		funcMap = make(map[string]any)
 	}
 
 	enhanceFuncMap(funcMap)
 
-	if err := loadTranslations(fs); err != nil {
+	var translations map[string][]Text
+	var err error
+	if module {
+		translations, err = readTranslations(fs, root)
+	} else {
+		translations, err = loadTranslations(fs, root)
+	}
+	if err != nil {
 		return nil, err
 	}
 
-	partials, err := load(fs, config.TemplateRootName, "_partials")
+	partials, err := load(fs, root, "_partials")
 	if err != nil {
 		return nil, err
 	}
 
-	layouts, err := load(fs, config.TemplateRootName)
+	layouts, err := load(fs, root)
 	if err != nil {
 		return nil, err
 	}
 
-	viewsDir := path.Join(config.TemplateRootName, "views")
+	for _, f := range append(layouts, partials...) {
+		if sb, ok := fileSandbox(fs, f.fullPath); ok {
+			if err := checkSandboxFile(fs, f.fullPath, funcMap, sb); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	viewsDir := path.Join(root, "views")
 	views := make(map[string]*template.Template)
+	sandboxes := make(map[string]Sandbox)
+	sources := make(map[string][]string)
+	aliases := make(map[string]string)
+	frontMatters := make(map[string]frontMatter)
 
 	for _, layout := range layouts {
 		layoutView := strings.TrimSuffix(layout.name, filepath.Ext(layout.name))
@@ -122,32 +222,62 @@ func Parse(fs embed.FS, funcMap map[string]any) (*Template, error) {
 		}
 
 		for _, view := range pages {
-			viewName := fmt.Sprintf(layoutView+"/%s", view.name)
+			viewName := ViewName(layoutView, view.name)
+
+			alias := strings.TrimSuffix(viewName, filepath.Ext(viewName))
+			if other, ok := aliases[alias]; ok {
+				return nil, fmt.Errorf("view %s conflicts with %s, view names must be unique without their extension", viewName, other)
+			}
+			aliases[alias] = viewName
 
 			tf := template.New(layout.name).Funcs(funcMap)
 
-			patterns := []string{
-				layout.fullPath,
-				view.fullPath,
+			patterns := []string{layout.fullPath}
+
+			// a lite variant shares the blocks of its main view
+			if main, ok := mainOfLite(view.name); ok {
+				mf, found := findFile(pages, main)
+				if !found {
+					return nil, fmt.Errorf("lite variant %s has no view %s", viewName, main)
+				}
+				patterns = append(patterns, mf.fullPath)
 			}
 
+			patterns = append(patterns, view.fullPath)
+
 			patterns = append(patterns, getPaths(partials)...)
 
-			t, err := tf.ParseFS(
-				fs,
-				patterns...,
-			)
+			t, fm, err := parsePatterns(tf, fs, patterns)
 			if err != nil {
 				return nil, err
 			}
 
+			if fm != nil {
+				frontMatters[viewName] = fm
+			}
+
+			if err := wrapESI(t, funcMap); err != nil {
+				return nil, err
+			}
+
 			views[viewName] = t
+			sources[viewName] = patterns
+
+			if sb, ok := fileSandbox(fs, view.fullPath); ok {
+				if err := checkSandboxFile(fs, view.fullPath, funcMap, sb); err != nil {
+					return nil, err
+				}
+			}
+
+			if sb, ok := fileSandbox(fs, patterns...); ok {
+				sandboxes[viewName] = sb
+			}
 		}
 	}
 
 	emails := make(map[string]*template.Template)
 
-	emailFiles, err := load(fs, config.TemplateRootName, "emails")
+	emailFiles, err := load(fs, root, "emails")
 	if err != nil {
 		return nil, err
 	}
@@ -158,10 +288,44 @@ func Parse(fs embed.FS, funcMap map[string]any) (*Template, error) {
 			return nil, err
 		}
 
+		if sb, ok := fileSandbox(fs, ef.fullPath); ok {
+			if err := checkSandboxFile(fs, ef.fullPath, funcMap, sb); err != nil {
+				return nil, err
+			}
+			sandboxes[ef.name] = sb
+		}
+
 		emails[ef.name] = t
 	}
 
-	templ := &Template{FS: fs, Views: views, Emails: emails}
+	baked, err := loadBaked(fs, root)
+	if err != nil {
+		return nil, err
+	}
+
+	templ := &Template{
+		FS:       embedded,
+		fsys:     fs,
+		root:     root,
+		Views:    views,
+		Emails:   emails,
+		funcMap:  funcMap,
+		partials: getNames(partials),
+		sources:  sources,
+
+		sandboxes: sandboxes,
+		baked:     baked,
+
+		frontMatters: frontMatters,
+		translations: translations,
+	}
+
+	if config.Strict {
+		if issues := templ.Lint(); len(issues) > 0 {
+			return nil, lintError(issues)
+		}
+	}
+
 	return templ, nil
 }
 
@@ -170,7 +334,7 @@ type file struct {
 	fullPath string
 }
 
-func load(fs embed.FS, dir ...string) ([]file, error) {
+func load(fs iofs.FS, dir ...string) ([]file, error) {
 	var files []file
 
 	fullDir := path.Join(dir...)
@@ -180,7 +344,7 @@ func load(fs embed.FS, dir ...string) ([]file, error) {
 	}
 
 	//TODO: might be an idea to un-hardcode the paths and have options
-	allFiles, err := fs.ReadDir(fullDir)
+	allFiles, err := iofs.ReadDir(fs, fullDir)
 	if err != nil {
 		return nil, err
 	}
@@ -196,6 +360,23 @@ func load(fs embed.FS, dir ...string) ([]file, error) {
 	return files, nil
 }
 
+func findFile(files []file, name string) (file, bool) {
+	for _, f := range files {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return file{}, false
+}
+
+func getNames(files []file) []string {
+	var names []string
+	for _, f := range files {
+		names = append(names, f.name)
+	}
+	return names
+}
+
 func getPaths(files []file) []string {
 	var p []string
 	for _, f := range files {
@@ -211,26 +392,241 @@ type PageData struct {
 
 	XSRFToken string
 
+	CurrentURL string
+
+	// Lite renders the lite variant of the view, i.e. dashboard.lite.html,
+	// when it exists. See WantsLite.
+	Lite bool
+
 	Title       string
 	CurrentUser any
 	Data        any
 	Extra       any
 
 	Env string
+
+	// unbaked executes the view even when it has baked bytes, set by Bake.
+	unbaked bool
+
+	// oob marks the fragments as out-of-band swaps, set by RenderBlocks for
+	// its secondary blocks.
+	oob bool
+
+	state *renderState
+}
+
+// renderState holds what template functions collect while a view is being
+// rendered.
+type renderState struct {
+	// view is the name of the view being rendered.
+	view string
+	// block is the name of the block being rendered via RenderBlock.
+	block string
+
+	preloads []preload
+	stacks   map[string][]string
+	headers  http.Header
+
+	// flush and done are used by RangeChunked to flush the output and stop
+	// when the execution ends, err holds the error of its iterators.
+	flush   *flushWriter
+	done    chan struct{}
+	endOnce sync.Once
+	errMu   sync.Mutex
+	err     error
+}
+
+func newRenderState() *renderState {
+	return &renderState{done: make(chan struct{})}
+}
+
+// end stops the iterators of RangeChunked once the execution is done.
+func (s *renderState) end() {
+	s.endOnce.Do(func() { close(s.done) })
+}
+
+// fail records the first error of an iterator of RangeChunked.
+func (s *renderState) fail(err error) {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// failure returns the error of an iterator of RangeChunked.
+func (s *renderState) failure() error {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+
+	return s.err
+}
+
+// ViewName returns the name of a view as used by Render from its layout and
+// page names. The extension of the layout is removed and .html is added to the
+// page if it has none:
+//
+//	tpl.ViewName("app", "dashboard")           // app/dashboard.html
+//	tpl.ViewName("app.html", "dashboard.html") // app/dashboard.html
+func ViewName(layout, page string) string {
+	layout = strings.TrimSuffix(layout, path.Ext(layout))
+	if len(path.Ext(page)) == 0 {
+		page += ".html"
+	}
+	return layout + "/" + page
 }
 
 // Render renders a template from a [layout]/[page.html].
 //
+// When the Locale of the data is empty, it's set to the Locale of the Lang in
+// the Languages registry option.
+//
 // The layout should not have the .html, so if you have 2 layouts one name
 // layout.html and one named app.html, a template named "dashboard.html" in the
-// app layout would be named: app/dashboard.html.
+// app layout would be named: app/dashboard.html. The .html extension of the
+// page may be omitted, "app/dashboard" renders the same view. Use ViewName to
+// build the name from its layout and page.
 func (templ *Template) Render(w io.Writer, view string, data PageData) error {
-	v, ok := templ.Views[view]
+	_, err := templ.render(w, view, "", data)
+	return err
+}
+
+// RenderBlock renders only one block of a view, for instance the "content"
+// block or any template defined via {{define "name"}} in the view, layout, or
+// partials.
+//
+// This is handy to respond to htmx or Turbo requests that only need a fragment
+// of the page.
+func (templ *Template) RenderBlock(w io.Writer, view, block string, data PageData) error {
+	_, err := templ.render(w, view, block, data)
+	return err
+}
+
+// RenderBlocks renders the first block like RenderBlock, followed by the
+// others, whose fragments are swapped out-of-band by htmx:
+//
+//	templ.RenderBlocks(w, "app/cart.html", data, "cart", "cart-count")
+func (templ *Template) RenderBlocks(w io.Writer, view string, data PageData, blocks ...string) error {
+	if len(blocks) == 0 {
+		return errors.New("no block to render for view: " + view)
+	}
+
+	for i, block := range blocks {
+		data.oob = i > 0
+		if _, err := templ.render(w, view, block, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupView returns a view by its name, with or without its .html or .md
+// extension.
+func (templ *Template) lookupView(view string) (string, *template.Template, bool) {
+	templ.mu.RLock()
+	defer templ.mu.RUnlock()
+
+	if v, ok := templ.Views[view]; ok {
+		return view, v, true
+	}
+
+	if len(path.Ext(view)) == 0 {
+		for _, ext := range []string{".html", ".md"} {
+			if v, ok := templ.Views[view+ext]; ok {
+				return view + ext, v, true
+			}
+		}
+	}
+	return view, nil, false
+}
+
+func (templ *Template) render(w io.Writer, view, block string, data PageData) (state *renderState, err error) {
+	view, v, ok := templ.lookupView(view)
 	if !ok {
-		return errors.New("can't find view: " + view)
+		return nil, errors.New("can't find view: " + view)
+	}
+
+	if data.Lite {
+		templ.mu.RLock()
+		if lv, found := templ.Views[liteName(view)]; found {
+			view, v = liteName(view), lv
+		}
+		templ.mu.RUnlock()
+	}
+
+	defer func(version int, start time.Time) {
+		templ.afterRender(view, version, start, data, err)
+	}(templ.version(view), time.Now())
+
+	if len(data.Locale) == 0 && len(data.Lang) > 0 {
+		if lc, ok := languageConfig(data.Lang); ok {
+			data.Locale = lc.Locale
+		}
+	}
+
+	templ.applyFrontMatter(view, &data)
+
+	data.state = newRenderState()
+	data.state.view = view
+	data.state.block = block
+	defer data.state.end()
+
+	if b, ok := templ.bakedView(data.Lang, view); ok && len(block) == 0 && !templ.preview && !data.unbaked {
+		_, err := w.Write(b)
+		return data.state, err
 	}
 
-	return v.Execute(w, data)
+	execData := func(out io.Writer, data PageData) error {
+		if len(block) > 0 {
+			return v.ExecuteTemplate(out, block, data)
+		}
+		return v.Execute(out, data)
+	}
+
+	if sb, ok := templ.sandboxes[view]; ok {
+		run := execData
+		execData = func(out io.Writer, data PageData) error {
+			return sb.execute(out, func(out io.Writer) error { return run(out, data) })
+		}
+	}
+
+	exec := func(out io.Writer) error {
+		return execData(out, data)
+	}
+
+	if config.DevMode && !templ.preview {
+		templ.instrument(view, v)
+	}
+
+	out := w
+
+	var buf *bytes.Buffer
+	if templ.usesStack(view, v) {
+		buf = new(bytes.Buffer)
+		out = buf
+	}
+
+	err = exec(chunkedOutput(out, data.state))
+	if err == nil {
+		err = data.state.failure()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if buf != nil {
+		b, err := resolveStacks(v, buf.Bytes(), data)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := w.Write(b); err != nil {
+			return nil, err
+		}
+	}
+
+	return data.state, nil
 }
 
 // RenderEmail renders the email found in the templates/emails directory.
@@ -240,17 +636,69 @@ func (templ *Template) Render(w io.Writer, view string, data PageData) error {
 //
 // Note that this execution does not use the PageData struct, but the data
 // passed directly.
-func (templ *Template) RenderEmail(w io.Writer, email string, data any) error {
+//
+// When the EmailCharset option is "ascii", the non-ASCII characters of HTML
+// emails are written as numeric entities, i.e. é becomes &#233;.
+func (templ *Template) RenderEmail(w io.Writer, email string, data any) (err error) {
+	templ.mu.RLock()
 	e, ok := templ.Emails[email]
+	templ.mu.RUnlock()
 	if !ok {
 		return errors.New("can't find emailw: " + email)
 	}
 
-	return e.Execute(w, data)
+	defer func(version int, start time.Time) {
+		templ.afterRender(email, version, start, data, err)
+	}(templ.version(email), time.Now())
+
+	if pd, ok := data.(PageData); ok {
+		pd.state = newRenderState()
+		defer pd.state.end()
+		data = pd
+	}
+
+	exec := func(out io.Writer) error {
+		return e.Execute(out, data)
+	}
+
+	if sb, ok := templ.sandboxes[email]; ok {
+		run := exec
+		exec = func(out io.Writer) error {
+			return sb.execute(out, run)
+		}
+	}
+
+	ext := strings.ToLower(path.Ext(email))
+	if config.EmailCharset != "ascii" || (ext != ".html" && ext != ".htm") {
+		return exec(w)
+	}
+
+	var buf bytes.Buffer
+	if err := exec(&buf); err != nil {
+		return err
+	}
+
+	_, err = w.Write(asciiEntities(buf.Bytes()))
+	return err
+}
+
+// asciiEntities replaces the non-ASCII characters by numeric HTML entities.
+func asciiEntities(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for _, r := range string(b) {
+		if r < utf8.RuneSelf {
+			out = append(out, byte(r))
+			continue
+		}
+		out = append(out, "&#"...)
+		out = strconv.AppendInt(out, int64(r), 10)
+		out = append(out, ';')
+	}
+	return out
 }
 
 // exists returns whether the given file or directory exists
-func exists(fs embed.FS, path string) bool {
+func exists(fs iofs.FS, path string) bool {
 	f, err := fs.Open(path)
 	if err != nil {
 		return false
@@ -2,12 +2,26 @@ package tpl_test
 
 import (
 	"bytes"
+	"context"
 	"embed"
+	"errors"
+	"expvar"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/dstpierre/tpl"
+	"github.com/dstpierre/tpl/sprigfuncs"
 )
 
 //go:embed testdata/*
@@ -97,3 +111,1241 @@ func TestRenderEmail(t *testing.T) {
 		t.Errorf("can't find func map in body: %s", body)
 	}
 }
+
+func TestRenderHTTPPreload(t *testing.T) {
+	templ := load(t)
+
+	rec := httptest.NewRecorder()
+	if err := templ.RenderHTTP(rec, "app/preload.html", tpl.PageData{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if link := rec.Header().Get("Link"); link != "</css/app.css>; rel=preload; as=style" {
+		t.Errorf("unexpected Link header: %s", link)
+	}
+
+	body := rec.Body.String()
+	if !strings.Contains(body, `<link rel="preload" href="/css/app.css" as="style">`) {
+		t.Errorf("can't find preload link tag in body: %s", body)
+	}
+}
+
+func TestRenderHTTPHeaders(t *testing.T) {
+	templ := load(t)
+
+	rec := httptest.NewRecorder()
+	rec.Header().Set("X-Robots-Tag", "all")
+	if err := templ.RenderHTTP(rec, "app/preload.html", tpl.PageData{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := rec.Header().Values("X-Robots-Tag"); len(v) != 1 || v[0] != "noindex" {
+		t.Errorf("expected the header declared by the view, got %v", v)
+	}
+}
+
+func TestRenderBlockFragment(t *testing.T) {
+	templ := load(t)
+
+	var buf bytes.Buffer
+	if err := templ.Render(&buf, "app/fragment.html", tpl.PageData{}); err != nil {
+		t.Fatal(err)
+	}
+
+	full := buf.String()
+	if !strings.Contains(full, `<div id="cart-items" style="view-transition-name: cart-items">`) {
+		t.Errorf("can't find fragment attributes in full render: %s", full)
+	}
+
+	buf.Reset()
+	if err := templ.RenderBlock(&buf, "app/fragment.html", "cart", tpl.PageData{}); err != nil {
+		t.Fatal(err)
+	}
+
+	partial := buf.String()
+	if partial != `<div id="cart-items" style="view-transition-name: cart-items">3 items</div>` {
+		t.Errorf("unexpected partial render: %s", partial)
+	}
+
+	buf.Reset()
+	if err := templ.RenderBlocks(&buf, "app/fragment.html", tpl.PageData{}, "cart", "count"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<div id="cart-items" style="view-transition-name: cart-items">3 items</div>` +
+		`<span id="cart-count" style="view-transition-name: cart-count" hx-swap-oob="true">3</span>`
+	if s := buf.String(); s != want {
+		t.Errorf("expected only the secondary block out-of-band, got %s", s)
+	}
+}
+
+func TestPushStack(t *testing.T) {
+	templ := load(t)
+
+	body := render(t, templ, "stacked/chart.html")
+	if !strings.Contains(body, `<head>
+    <link rel="stylesheet" href="/css/chart.css">
+  </head>`) {
+		t.Errorf("can't find pushed head tag: %s", body)
+	} else if strings.Count(body, `<script src="/js/chart.js"></script>`) != 1 {
+		t.Errorf("expected script to be pushed once: %s", body)
+	}
+}
+
+func TestViewName(t *testing.T) {
+	templ := load(t)
+
+	if name := tpl.ViewName("app", "dashboard"); name != "app/dashboard.html" {
+		t.Errorf("expected app/dashboard.html got %s", name)
+	} else if _, ok := templ.Views[name]; !ok {
+		t.Errorf("can't find view %s", name)
+	}
+
+	body := render(t, templ, "app/dashboard")
+	if !strings.Contains(body, "<h1>Dashboard</h1>") {
+		t.Errorf("can't render view without extension: %s", body)
+	}
+}
+
+func TestCompletionData(t *testing.T) {
+	templ := load(t)
+
+	data := templ.CompletionData()
+	if len(data.Views) != len(templ.Views) {
+		t.Errorf("expected %d views got %v", len(templ.Views), data.Views)
+	} else if len(data.Partials) != 2 || data.Partials[0].Name != "nav" {
+		t.Errorf("expected nav partial got %v", data.Partials)
+	}
+
+	found := false
+	for _, f := range data.Funcs {
+		if f.Name == "t" {
+			found = f.Signature == "func(string, string, ...map[string]interface {}) string"
+		}
+	}
+	if !found {
+		t.Errorf("can't find t func signature in %v", data.Funcs)
+	}
+}
+
+func TestDevModeSourceComments(t *testing.T) {
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", DevMode: true})
+
+	templ, err := tpl.Parse(fsTest, fmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := render(t, templ, "app/dashboard.html")
+	if !strings.Contains(body, `<!-- tpl:begin "nav" nav.html:1 -->`) {
+		t.Errorf("can't find nav begin comment: %s", body)
+	} else if !strings.Contains(body, `<!-- tpl:end "nav" -->`) {
+		t.Errorf("can't find nav end comment: %s", body)
+	} else if strings.Contains(body, "<title><!--") {
+		t.Errorf("comment added inside title: %s", body)
+	}
+
+	rec := httptest.NewRecorder()
+	if err := templ.RenderHTTP(rec, "app/preload.html", tpl.PageData{}); err != nil {
+		t.Fatal(err)
+	} else if links := rec.Header().Values("Link"); len(links) != 1 {
+		t.Errorf("expected one Link header on the first DevMode render, got %q", links)
+	}
+
+	templ, err = tpl.ParseSources(fsTest, fmap, tpl.MapSource{
+		"views/app/once.html": `{{ define "content" }}<p>call {{ .Data.Next }}</p>{{ end }}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &counter{}
+	var buf bytes.Buffer
+	if err := templ.Render(&buf, "app/once.html", tpl.PageData{Data: c}); err != nil {
+		t.Fatal(err)
+	} else if c.n != 1 || !strings.Contains(buf.String(), "call 1") {
+		t.Errorf("expected the view executed once, got %d calls: %s", c.n, buf.String())
+	}
+}
+
+// counter counts the calls of its Next method.
+type counter struct{ n int }
+
+func (c *counter) Next() int {
+	c.n++
+	return c.n
+}
+
+func TestValidate(t *testing.T) {
+	templ := load(t)
+
+	data := tpl.PageData{Lang: "fr", Locale: "fr-CA", Data: pagedata{Date: time.Now()}}
+	if err := templ.Validate("app/i18n.html", data); err != nil {
+		t.Errorf("expected i18n view to be valid: %v", err)
+	}
+
+	if err := templ.Validate("app/missing.html", tpl.PageData{Lang: "fr"}); err == nil {
+		t.Error("expected missing translation error")
+	} else if !strings.Contains(err.Error(), "fr:does-not-exist") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := templ.Validate("app/dashboard.html", map[string]any{}); err == nil {
+		t.Error("expected missing key error")
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	templ := load(t)
+
+	report := templ.HealthCheck()
+	if report.OK() {
+		t.Fatal("expected the health check to fail")
+	}
+
+	if len(report.MissingTemplates) != 1 || report.MissingTemplates[0].Name != "does-not-exist" {
+		t.Errorf("unexpected missing templates: %v", report.MissingTemplates)
+	} else if len(report.MissingTranslations) != 1 || report.MissingTranslations[0].View != "app/missing.html" {
+		t.Errorf("unexpected missing translations: %v", report.MissingTranslations)
+	} else if len(report.EmailsWithoutLanguage) != 0 {
+		t.Errorf("unexpected emails without language: %v", report.EmailsWithoutLanguage)
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	templ := load(t)
+
+	if langs := templ.Languages(); len(langs) != 2 || langs[0] != "en" || langs[1] != "fr" {
+		t.Errorf("expected [en fr] got %v", langs)
+	}
+}
+
+func TestRenderAllLangs(t *testing.T) {
+	templ := load(t)
+
+	data := tpl.PageData{Locale: "fr-CA", Data: pagedata{Date: time.Now(), Amount: 1234.56}}
+	pages, err := templ.RenderAllLangs("app/i18n.html", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(pages["en"]), "<h1>Hello world</h1>") {
+		t.Errorf("can't find English heading: %s", pages["en"])
+	} else if !strings.Contains(string(pages["fr"]), "<em>1234.56 $</em>") {
+		t.Errorf("expected fr-CA locale to be kept: %s", pages["fr"])
+	}
+}
+
+func TestLanguageRegistry(t *testing.T) {
+	templ := load(t)
+
+	tpl.Set(tpl.Option{
+		TemplateRootName: "testdata",
+		Languages: []tpl.LanguageConfig{
+			{Code: "fr", Locale: "fr-CA", Fallbacks: []string{"en"}},
+			{Code: "en", Locale: "en-US"},
+		},
+	})
+
+	if langs := templ.Languages(); len(langs) != 2 || langs[0] != "fr" {
+		t.Errorf("expected registry languages got %v", langs)
+	} else if s := tpl.Translate("fr", "only-in-english"); s != "Not translated yet" {
+		t.Errorf("expected fallback to English got %s", s)
+	}
+
+	pages, err := templ.RenderAllLangs("app/i18n.html", tpl.PageData{Data: pagedata{Date: time.Now(), Amount: 1234.56}})
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(string(pages["fr"]), "<em>1234.56 $</em>") {
+		t.Errorf("expected fr-CA locale from registry: %s", pages["fr"])
+	}
+}
+
+func TestRenderEmailASCIICharset(t *testing.T) {
+	load(t)
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", EmailCharset: "ascii"})
+
+	templ, err := tpl.Parse(fsTest, fmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := templ.RenderEmail(&buf, "welcome_fr.html", map[string]string{"Name": "Zoé"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if body := buf.String(); body != "<p>Bienvenue Zo&#233;, votre compte est cr&#233;&#233;.</p>\n" {
+		t.Errorf("unexpected email body: %s", body)
+	}
+}
+
+func TestRenderInline(t *testing.T) {
+	templ := load(t)
+
+	src := `<p>{{ t .Lang "hello-world" }} {{ .Data }}</p>`
+	for i := 0; i < 2; i++ {
+		var buf bytes.Buffer
+		if err := templ.RenderInline(&buf, src, tpl.PageData{Lang: "fr", Data: "<b>"}); err != nil {
+			t.Fatal(err)
+		} else if body := buf.String(); body != "<p>Allo tout le monde &lt;b&gt;</p>" {
+			t.Errorf("unexpected inline render: %s", body)
+		}
+	}
+}
+
+func TestSprigFuncs(t *testing.T) {
+	tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	funcs := map[string]any{
+		"abc":  fmap["abc"],
+		"trim": func(s string) string { return "mine" },
+	}
+	templ, err := tpl.Parse(fsTest, sprigfuncs.Add(funcs))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := `{{ upper "abc" }} {{ default "none" .Data }} {{ trim " x " }} {{ date "en-US" .Extra }}`
+	d := time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	if err := templ.RenderInline(&buf, src, tpl.PageData{Extra: d}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "ABC none mine " + tpl.FormatDate("en-US", d)
+	if buf.String() != expected {
+		t.Errorf("expected %q got %q", expected, buf.String())
+	}
+}
+
+func TestFuncMapKeepsUserFuncs(t *testing.T) {
+	tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	funcs := map[string]any{
+		"abc":   fmap["abc"],
+		"add":   func(a, b string) string { return a + b },
+		"first": func() string { return "mine" },
+	}
+	templ, err := tpl.Parse(fsTest, funcs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := templ.RenderInline(&buf, `{{ add "a" "b" }} {{ first }} {{ mul 2 3 }}`, tpl.PageData{}); err != nil {
+		t.Fatal(err)
+	} else if buf.String() != "ab mine 6" {
+		t.Errorf("expected the user funcs kept, got %q", buf.String())
+	}
+}
+
+func TestParseSources(t *testing.T) {
+	tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	src := tpl.MapSource{
+		"views/app/dashboard.html": `{{define "content"}}<h1>Customer dashboard</h1>{{end}}`,
+		"views/app/custom.html":    `{{define "content"}}<p>{{ t .Lang "custom" }}</p>{{end}}`,
+		"translations/fr.json":     `[{"key": "custom", "value": "personnalisé"}]`,
+	}
+
+	templ, err := tpl.ParseSources(fsTest, fmap, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := render(t, templ, "app/dashboard.html"); !strings.Contains(s, "Customer dashboard") {
+		t.Errorf("source view did not replace the embedded one: %s", s)
+	}
+
+	s := render(t, templ, "app/custom.html")
+	if !strings.Contains(s, "personnalisé") || !strings.Contains(s, "<html>") {
+		t.Errorf("source view not rendered with layout and translation: %s", s)
+	}
+
+	if _, ok := templ.Views["app/i18n.html"]; !ok {
+		t.Error("embedded views should still be loaded")
+	}
+
+	for _, name := range []string{"../secrets/x.html", "views/../../x.html", "/views/app/x.html", "."} {
+		if _, err := tpl.ParseSources(fsTest, fmap, tpl.MapSource{name: "x"}); !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("expected %s rejected, got %v", name, err)
+		}
+	}
+}
+
+func TestSandbox(t *testing.T) {
+	tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	funcs := map[string]any{
+		"abc":   func() string { return "from custom func map" },
+		"sleep": func() string { time.Sleep(50 * time.Millisecond); return "" },
+	}
+
+	denied := tpl.MapSource{"emails/custom_en.html": `{{ abc }}`}
+	if _, err := tpl.ParseSources(fsTest, funcs, tpl.Untrusted(denied, tpl.Sandbox{})); err == nil {
+		t.Error("expected an error for a function not allowed in the sandbox")
+	}
+
+	src := tpl.MapSource{
+		"emails/custom_en.html": `{{ range .Items }}{{ . }}{{ end }}`,
+		"emails/slow_en.html":   `{{ sleep }}{{ sleep }}done`,
+		"views/app/custom.html": `{{define "content"}}<p>{{ t .Lang "hello-world" }}</p>{{end}}`,
+	}
+	sb := tpl.Sandbox{
+		Funcs:     append(tpl.SafeFuncs, "sleep"),
+		MaxOutput: 10,
+		Timeout:   20 * time.Millisecond,
+	}
+
+	templ, err := tpl.ParseSources(fsTest, funcs, tpl.Untrusted(src, sb))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := templ.RenderEmail(&buf, "custom_en.html", map[string]any{"Items": []string{"a", "b"}}); err != nil {
+		t.Fatal(err)
+	} else if buf.String() != "ab" {
+		t.Errorf("expected ab got %s", buf.String())
+	}
+
+	buf.Reset()
+	err = templ.RenderEmail(&buf, "custom_en.html", map[string]any{"Items": strings.Split("abcdefghijkl", "")})
+	if !errors.Is(err, tpl.ErrSandboxOutput) {
+		t.Errorf("expected the output limit error got %v", err)
+	} else if buf.Len() > 0 {
+		t.Errorf("nothing should be written when the limit is exceeded: %s", buf.String())
+	}
+
+	if err := templ.RenderEmail(&buf, "slow_en.html", nil); !errors.Is(err, tpl.ErrSandboxTimeout) {
+		t.Errorf("expected the timeout error got %v", err)
+	}
+
+	// the layout of the untrusted view is larger than the output limit
+	if err := templ.Render(&buf, "app/custom", tpl.PageData{Lang: "en"}); !errors.Is(err, tpl.ErrSandboxOutput) {
+		t.Errorf("expected the sandbox to apply to views got %v", err)
+	}
+
+	if s := render(t, templ, "app/dashboard"); !strings.Contains(s, "Dashboard") {
+		t.Errorf("trusted views should not be sandboxed: %s", s)
+	}
+
+	md := tpl.MapSource{"views/app/evil.md": "# Hi\n\n<script>alert(1)</script>\n\n<p onclick=\"x()\">text</p>\n"}
+	templ, err = tpl.ParseSources(fsTest, funcs, tpl.Untrusted(md, tpl.Sandbox{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := render(t, templ, "app/evil"); !strings.Contains(s, "Hi</h1>") || strings.Contains(s, "<script>alert") || strings.Contains(s, "onclick") {
+		t.Errorf("expected the HTML of an untrusted markdown view sanitized: %s", s)
+	}
+
+	if _, err := templ.UpdateView("app/evil.md", []byte("<script>alert(2)</script>")); err != nil {
+		t.Fatal(err)
+	} else if s := render(t, templ, "app/evil"); strings.Contains(s, "<script>alert") {
+		t.Errorf("expected an updated untrusted markdown view sanitized: %s", s)
+	}
+}
+
+func TestUpdateViewRollback(t *testing.T) {
+	var infos []tpl.RenderInfo
+	tpl.Set(tpl.Option{
+		TemplateRootName: "testdata",
+		KeepVersions:     2,
+		AfterRender:      func(ri tpl.RenderInfo) { infos = append(infos, ri) },
+	})
+	defer tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	templ, err := tpl.Parse(fsTest, fmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	view := "app/dashboard.html"
+
+	if _, err := templ.UpdateView(view, []byte(`{{define "content"}}{{ end }`)); err == nil {
+		t.Error("expected a parse error for a broken edit")
+	}
+
+	v, err := templ.UpdateView(view, []byte(`{{define "content"}}<h1>Edited</h1>{{end}}`))
+	if err != nil {
+		t.Fatal(err)
+	} else if v != 2 {
+		t.Errorf("expected version 2 got %d", v)
+	}
+
+	if s := render(t, templ, view); !strings.Contains(s, "Edited") || !strings.Contains(s, "<html>") {
+		t.Errorf("expected the edited view in its layout: %s", s)
+	}
+
+	if err := templ.Rollback(view, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := render(t, templ, view); !strings.Contains(s, "Dashboard") {
+		t.Errorf("expected the original view after rollback: %s", s)
+	}
+
+	if len(infos) != 2 || infos[0].Version != 2 || infos[1].Version != 1 || infos[1].Name != view {
+		t.Errorf("unexpected render infos: %+v", infos)
+	}
+
+	if v, _ := templ.UpdateView(view, []byte(`{{define "content"}}v3{{end}}`)); v != 3 {
+		t.Errorf("expected version 3 got %d", v)
+	}
+
+	// only the last 2 versions are kept
+	if err := templ.Rollback(view, 1); err == nil {
+		t.Error("expected an error rolling back to a version no longer kept")
+	}
+
+	versions := templ.Versions(view)
+	if len(versions) != 2 || versions[0].Version != 2 || !versions[1].Current {
+		t.Errorf("unexpected versions: %+v", versions)
+	}
+}
+
+func TestPreviewChange(t *testing.T) {
+	templ := load(t)
+
+	view := "app/dashboard.html"
+	src := `{{define "content"}}
+<h1>Dashboard</h1>
+<p>{{.Data.Text}} edited</p>
+{{end}}
+{{define "sidebar"}}<aside></aside>{{end}}`
+
+	p, err := templ.PreviewChange(view, []byte(src), pagedata{Text: "unit-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(p.Output, "unit-test edited") || strings.Contains(p.LiveOutput, "edited") {
+		t.Errorf("unexpected outputs: %s\n---\n%s", p.Output, p.LiveOutput)
+	}
+
+	if len(p.Added) != 1 || p.Added[0] != "sidebar" || len(p.Changed) == 0 || len(p.Removed) != 0 {
+		t.Errorf("unexpected template changes: added %v changed %v removed %v", p.Added, p.Changed, p.Removed)
+	}
+
+	var added []string
+	for _, l := range p.Diff {
+		if l.Op == "+" {
+			added = append(added, l.String())
+		}
+	}
+	if len(added) != 1 || added[0] != "+<p>unit-test edited</p>" {
+		t.Errorf("unexpected diff additions: %v", added)
+	}
+
+	if s := render(t, templ, view); strings.Contains(s, "edited") {
+		t.Error("previewing a change should not publish it")
+	}
+}
+
+func TestAuditSink(t *testing.T) {
+	var events []tpl.AuditEvent
+	tpl.Set(tpl.Option{
+		TemplateRootName: "testdata",
+		AuditSink:        func(e tpl.AuditEvent) { events = append(events, e) },
+	})
+	defer tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	templ, err := tpl.Parse(fsTest, fmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 || events[0].Action != tpl.AuditTranslationsLoad || events[0].Target != "en" {
+		t.Fatalf("expected the translations loads, got %+v", events)
+	}
+	events = nil
+
+	ctx := tpl.WithActor(context.Background(), "alice@example.com")
+
+	if _, err := templ.UpdateViewContext(ctx, "app/dashboard.html", []byte(`{{define "content"}}edited{{end}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := templ.RollbackContext(ctx, "app/dashboard.html", 1); err != nil {
+		t.Fatal(err)
+	}
+	tpl.AddTranslationsContext(ctx, "fr", []tpl.Text{{Key: "audit-b", Value: "b"}, {Key: "audit-a", Value: "a"}})
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events got %+v", events)
+	}
+
+	for _, e := range events {
+		if e.Actor != "alice@example.com" || e.Time.IsZero() {
+			t.Errorf("missing actor or time: %+v", e)
+		}
+	}
+
+	if events[0].Action != tpl.AuditViewUpdate || events[0].Version != 2 {
+		t.Errorf("unexpected update event: %+v", events[0])
+	}
+	if events[1].Action != tpl.AuditRollback || events[1].Version != 1 {
+		t.Errorf("unexpected rollback event: %+v", events[1])
+	}
+	if events[2].Target != "fr" || strings.Join(events[2].Keys, ",") != "audit-a,audit-b" {
+		t.Errorf("unexpected translations event: %+v", events[2])
+	}
+
+	if got := tpl.Translate("fr", "audit-a"); got != "a" {
+		t.Errorf("expected the added translation got %s", got)
+	}
+}
+
+func TestRenderLite(t *testing.T) {
+	templ := load(t)
+
+	data := tpl.PageData{Lite: true, Data: pagedata{Text: "unit-test"}}
+
+	var buf bytes.Buffer
+	if err := templ.Render(&buf, "app/report", data); err != nil {
+		t.Fatal(err)
+	}
+
+	s := buf.String()
+	if !strings.Contains(s, "Report lite") || !strings.Contains(s, "Monthly report") {
+		t.Errorf("expected the lite content with the main view's title: %s", s)
+	}
+
+	if s := render(t, templ, "app/report.html"); strings.Contains(s, "lite") {
+		t.Errorf("the main view should be rendered without Lite: %s", s)
+	}
+
+	buf.Reset()
+	if err := templ.Render(&buf, "app/i18n.html", tpl.PageData{Lite: true, Lang: "en", Data: pagedata{}}); err != nil {
+		t.Errorf("views without a lite variant should render normally: %v", err)
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Save-Data", "on")
+	if !tpl.WantsLite(r) {
+		t.Error("expected Save-Data to ask for lite")
+	}
+}
+
+func TestDiffRenders(t *testing.T) {
+	a := []byte(`<html><body>
+  <main class="a b" id="main">
+    <h1>Hello</h1>
+    <p>First</p>
+    <p>Second</p>
+  </main>
+</body></html>`)
+
+	same := []byte(`<html><body><main id="main" class="b a"><h1>Hello</h1><p>First</p>
+	<p>  Second </p></main><!-- comment --></body></html>`)
+
+	r, err := tpl.DiffRenders(a, same)
+	if err != nil {
+		t.Fatal(err)
+	} else if !r.Equal() {
+		t.Errorf("expected no changes got:\n%s", r)
+	}
+
+	b := []byte(`<html><body><main class="a b" id="content"><h1>Hello</h1><p>First</p>
+	<p>Second</p><footer>New</footer></main></body></html>`)
+
+	r, err = tpl.DiffRenders(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `html/body/main: attribute id "main" -> "content"
+html/body/main/footer: added <footer>
+`
+	if r.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, r)
+	}
+}
+
+func TestRenderParts(t *testing.T) {
+	templ := load(t)
+
+	parts, err := templ.RenderParts("app/report", tpl.PageData{Data: pagedata{Text: "unit-test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(parts) != 3 {
+		t.Errorf("expected title, nav, and content parts, got %d", len(parts))
+	}
+
+	if s := string(parts["title"]); s != "Monthly report" {
+		t.Errorf("unexpected title part %q", s)
+	}
+
+	if s := string(parts["nav"]); !strings.Contains(s, "Main nav here") {
+		t.Errorf("unexpected nav part %q", s)
+	}
+
+	if s := string(parts["content"]); !strings.Contains(s, "unit-test") || strings.Contains(s, "<html>") {
+		t.Errorf("expected only the content block: %q", s)
+	}
+}
+
+func TestESI(t *testing.T) {
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", ESIPartials: []string{"nav"}})
+	defer tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	templ, err := tpl.Parse(fsTest, fmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := templ.Render(&buf, "app/report", tpl.PageData{Lang: "fr", Data: pagedata{Text: "unit-test"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	page := buf.String()
+	if !strings.Contains(page, `<esi:include src="/_esi?block=nav&lang=fr&view=app%2Freport.html"/>`) {
+		t.Errorf("expected an esi:include for the nav partial: %s", page)
+	} else if strings.Contains(page, "Main nav here") {
+		t.Errorf("the nav partial should not be rendered inline: %s", page)
+	}
+
+	buf.Reset()
+	if err := templ.Render(&buf, "app/esi", tpl.PageData{}); err != nil {
+		t.Fatal(err)
+	} else if s := buf.String(); strings.Count(s, "Main nav here") != 1 || strings.Count(s, "esi:include") != 1 {
+		t.Errorf("expected the nav called with a string dot rendered inline: %s", s)
+	}
+
+	h := templ.ESIHandler(func(r *http.Request) tpl.PageData {
+		return tpl.PageData{Data: pagedata{Text: "unit-test"}}
+	})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/_esi?block=nav&lang=fr&view=app%2Freport.html", nil))
+	if body := rec.Body.String(); rec.Code != http.StatusOK || !strings.Contains(body, "Main nav here") || strings.Contains(body, "esi:include") {
+		t.Errorf("expected the nav content, got %d %s", rec.Code, body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/_esi?block=content&view=app%2Freport.html", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected blocks outside of ESIPartials to be not found, got %d", rec.Code)
+	}
+}
+
+func TestOfflineShell(t *testing.T) {
+	templ := load(t)
+
+	assets := fstest.MapFS{
+		"css/app.css": {Data: []byte("body{}")},
+		"js/app.js":   {Data: []byte("console.log(1)")},
+	}
+
+	shell, err := templ.OfflineShell(tpl.OfflineOptions{
+		View:   "app/report.html",
+		Data:   tpl.PageData{Data: pagedata{Text: "offline"}},
+		Assets: assets,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Contains(shell.HTML, []byte("offline")) {
+		t.Errorf("expected the rendered shell: %s", shell.HTML)
+	}
+
+	if len(shell.Precache) != 3 {
+		t.Fatalf("expected the shell and 2 assets, got %v", shell.Precache)
+	} else if shell.Precache[0].URL != "/offline" || shell.Precache[1].URL != "/static/css/app.css" {
+		t.Errorf("unexpected precache URLs %v", shell.Precache)
+	}
+
+	rev := shell.Precache[1].Revision
+	assets["css/app.css"] = &fstest.MapFile{Data: []byte("body{color:red}")}
+	changed, err := templ.OfflineShell(tpl.OfflineOptions{View: "app/report.html", Data: tpl.PageData{Data: pagedata{}}, Assets: assets})
+	if err != nil {
+		t.Fatal(err)
+	} else if changed.Precache[1].Revision == rev {
+		t.Error("expected the revision to change with the content")
+	}
+
+	if sw := string(shell.ServiceWorker()); !strings.Contains(sw, `"url":"/static/js/app.js"`) || !strings.Contains(sw, `const SHELL = "/offline"`) {
+		t.Errorf("unexpected service worker:\n%s", sw)
+	}
+}
+
+func TestStats(t *testing.T) {
+	templ := load(t)
+
+	render(t, templ, "app/report.html")
+	render(t, templ, "app/report.html")
+
+	if err := templ.Render(io.Discard, "app/broken.html", tpl.PageData{}); err == nil {
+		t.Fatal("expected the broken view to fail")
+	}
+
+	s := templ.Stats()
+	if s.Renders != 3 || s.Errors != 1 {
+		t.Errorf("expected 3 renders and 1 error, got %d and %d", s.Renders, s.Errors)
+	}
+
+	if rs := s.Templates["app/report.html"]; rs.Renders != 2 || rs.AverageDuration() <= 0 || rs.MaxDuration < rs.AverageDuration() {
+		t.Errorf("unexpected report stats %+v", rs)
+	}
+
+	if s.CacheHits != 1 || s.CacheMisses != 2 {
+		t.Errorf("expected 1 cache hit and 2 misses, got %d and %d", s.CacheHits, s.CacheMisses)
+	}
+
+	templ.PublishExpvar("tpl-test")
+	if v := expvar.Get("tpl-test"); v == nil || !strings.Contains(v.String(), `"app/report.html"`) {
+		t.Errorf("expected the stats published via expvar, got %v", v)
+	}
+}
+
+func TestSlowRenderLog(t *testing.T) {
+	var logs bytes.Buffer
+	tpl.Set(tpl.Option{
+		TemplateRootName:    "testdata",
+		SlowRenderThreshold: time.Nanosecond,
+		Logger:              slog.New(slog.NewTextHandler(&logs, nil)),
+	})
+	defer tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	templ, err := tpl.Parse(fsTest, fmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := templ.Render(io.Discard, "app/report.html", tpl.PageData{Lang: "fr", Data: pagedata{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := logs.String(); !strings.Contains(s, `msg="slow template render" view=app/report.html`) || !strings.Contains(s, "data=tpl_test.pagedata lang=fr") {
+		t.Errorf("expected a slow render entry, got %s", s)
+	}
+
+	logs.Reset()
+	templ.Render(io.Discard, "app/broken.html", tpl.PageData{})
+	if s := logs.String(); !strings.Contains(s, "level=ERROR") || !strings.Contains(s, "view=app/broken.html") {
+		t.Errorf("expected a failed render entry, got %s", s)
+	}
+}
+
+func TestRenderCached(t *testing.T) {
+	cache := &tpl.MemoryCache{}
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", Cache: cache})
+	defer tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	templ, err := tpl.Parse(fsTest, fmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, text := range []string{"first", "second"} {
+		var buf bytes.Buffer
+		if err := templ.RenderCached(&buf, "app/report", "en", time.Minute, tpl.PageData{Data: pagedata{Text: text}}); err != nil {
+			t.Fatal(err)
+		} else if !strings.Contains(buf.String(), "first") {
+			t.Errorf("expected the cached output of the first render: %s", buf.String())
+		}
+	}
+
+	if _, err := templ.UpdateView("app/report.html", []byte(`{{define "content"}}updated {{.Data.Text}}{{end}}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := templ.RenderCached(&buf, "app/report.html", "en", time.Minute, tpl.PageData{Data: pagedata{Text: "third"}}); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(buf.String(), "updated third") {
+		t.Errorf("expected a new render after the update: %s", buf.String())
+	}
+
+	cache.Set("short", []byte("v"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok := cache.Get("short"); ok {
+		t.Error("expected the entry to expire")
+	}
+
+	keys := &keyCache{}
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", Cache: keys})
+	buf.Reset()
+	if err := templ.RenderCached(&buf, "app/terms", "en", time.Minute, tpl.PageData{}); err != nil {
+		t.Fatal(err)
+	} else if len(keys.set) != 1 || !strings.HasPrefix(keys.set[0], "tpl:app/terms.md:") {
+		t.Errorf("expected the markdown view in the cache key, got %q", keys.set)
+	}
+}
+
+// keyCache records the keys it's asked to store.
+type keyCache struct {
+	set []string
+}
+
+func (c *keyCache) Get(key string) ([]byte, bool)                   { return nil, false }
+func (c *keyCache) Set(key string, value []byte, ttl time.Duration) { c.set = append(c.set, key) }
+func (c *keyCache) Delete(key string)                               {}
+
+func BenchmarkRenderLoop(b *testing.B) {
+	tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+	templ, err := tpl.Parse(fsTest, fmap)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	data := tpl.PageData{Lang: "fr", Data: make([]int, 1000)}
+	src := `{{ range .Data }}<td>{{ t $.Lang "hello-world" }}</td>{{ end }}`
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := templ.RenderInline(io.Discard, src, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestBake(t *testing.T) {
+	if runtime.GOOS == "js" {
+		t.Skip("baking writes files")
+	}
+
+	tpl.Set(tpl.Option{TemplateRootName: "testdata", StaticViews: []string{"app/report"}})
+	defer tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	templ, err := tpl.Parse(fsTest, fmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := templ.Bake(dir, tpl.PageData{Data: pagedata{Text: "baked"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "baked", "fr", "app", "report.html"))
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Contains(b, []byte("baked")) {
+		t.Errorf("unexpected baked view %s", b)
+	}
+
+	templ, err = tpl.ParseSources(fsTest, fmap, tpl.MapSource{"baked/fr/app/report.html": "from the oven"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := templ.Render(&buf, "app/report", tpl.PageData{Lang: "fr", Data: pagedata{}}); err != nil {
+		t.Fatal(err)
+	} else if buf.String() != "from the oven" {
+		t.Errorf("expected the baked bytes, got %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := templ.Render(&buf, "app/report", tpl.PageData{Lang: "en", Data: pagedata{Text: "live"}}); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(buf.String(), "live") {
+		t.Errorf("expected the view executed without a baked version, got %s", buf.String())
+	}
+
+	if err := templ.Bake(dir, tpl.PageData{Data: pagedata{Text: "rebaked"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err = os.ReadFile(filepath.Join(dir, "baked", "fr", "app", "report.html"))
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Contains(b, []byte("rebaked")) {
+		t.Errorf("expected Bake to execute the view, got %s", b)
+	}
+
+	tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+	templ, err = tpl.ParseSources(fsTest, fmap, tpl.MapSource{"baked/fr/app/report.html": "from the oven"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf.Reset()
+	if err := templ.Render(&buf, "app/report", tpl.PageData{Lang: "fr", Data: pagedata{Text: "live"}}); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(buf.String(), "live") {
+		t.Errorf("expected a view not in StaticViews executed, got %s", buf.String())
+	}
+}
+
+// flushRecorder records the size of the output at each flush.
+type flushRecorder struct {
+	bytes.Buffer
+	flushes []int
+}
+
+func (f *flushRecorder) Flush() { f.flushes = append(f.flushes, f.Len()) }
+
+func TestRangeChunked(t *testing.T) {
+	templ := load(t)
+
+	seq := func(yield func(int) bool) {
+		for i := 0; i < 10; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+
+	var out flushRecorder
+	src := `{{ range rangechunked . .Data 4 }}{{ . }};{{ end }}`
+	if err := templ.RenderInline(&out, src, tpl.PageData{Data: seq}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := out.String(); got != "0;1;2;3;4;5;6;7;8;9;" {
+		t.Errorf("unexpected output %q", got)
+	}
+
+	if len(out.flushes) != 2 {
+		t.Errorf("expected a flush every 4 rows, got %v", out.flushes)
+	}
+
+	src = `{{ range rangechunked . .Data 2 }}{{ if eq . 3 }}{{ template "missing" }}{{ end }}{{ end }}`
+	if err := templ.RenderInline(io.Discard, src, tpl.PageData{Data: []int{1, 2, 3, 4, 5}}); err == nil {
+		t.Error("expected the execution error")
+	}
+
+	stopped := make(chan struct{})
+	endless := func(yield func(int) bool) {
+		defer close(stopped)
+		for i := 0; yield(i); i++ {
+		}
+	}
+
+	src = `{{ range rangechunked . .Data 2 }}{{ if eq . 3 }}{{ len 3 }}{{ end }}{{ end }}`
+	if err := templ.RenderInline(io.Discard, src, tpl.PageData{Data: endless}); err == nil {
+		t.Error("expected the execution error")
+	}
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Error("expected the iterator stopped once the render ended")
+	}
+
+	panics := func(yield func(int) bool) {
+		yield(1)
+		panic("boom")
+	}
+
+	src = `{{ range rangechunked . .Data 2 }}{{ . }}{{ end }}`
+	if err := templ.RenderInline(io.Discard, src, tpl.PageData{Data: panics}); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("expected the panic returned as an error, got %v", err)
+	}
+}
+
+func TestMarkdownView(t *testing.T) {
+	templ := load(t)
+
+	var buf bytes.Buffer
+	if err := templ.Render(&buf, "app/terms.md", tpl.PageData{}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := buf.String()
+	if !strings.Contains(s, `<main><h1 id="terms">Terms</h1>`) || !strings.Contains(s, "<strong>responsibly</strong>, {{ not an action }}.") {
+		t.Errorf("expected the markdown in the content block: %s", s)
+	} else if !strings.Contains(s, "Main nav here") {
+		t.Errorf("expected the layout and partials: %s", s)
+	}
+
+	buf.Reset()
+	if _, err := templ.UpdateView("app/terms.md", []byte("Updated")); err != nil {
+		t.Fatal(err)
+	} else if err := templ.Render(&buf, "app/terms.md", tpl.PageData{}); err != nil {
+		t.Fatal(err)
+	} else if s := buf.String(); !strings.Contains(s, "<p>Updated</p>") {
+		t.Errorf("expected the updated markdown: %s", s)
+	}
+}
+
+func TestMarkdownViewFrontMatter(t *testing.T) {
+	templ := load(t)
+
+	var buf bytes.Buffer
+	if err := templ.Render(&buf, "docs/post.md", tpl.PageData{}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "<h1>First post</h1><p>Dominic</p><p>Hello <em>world</em></p>\n\n"
+	if s := buf.String(); s != expected {
+		t.Errorf("expected %q got %q", expected, s)
+	}
+
+	buf.Reset()
+	if err := templ.Render(&buf, "docs/post.md", tpl.PageData{Title: "Override"}); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(buf.String(), "<h1>Override</h1>") {
+		t.Errorf("expected the page title to win: %s", buf.String())
+	}
+
+	p, err := templ.PreviewChange("docs/post.md", []byte("---\ntitle: Second post\n---\nHello"), tpl.PageData{})
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(p.Output, "<h1>Second post</h1>") || !strings.Contains(p.LiveOutput, "<h1>First post</h1>") {
+		t.Errorf("expected the front matter of each version: %s\n---\n%s", p.Output, p.LiveOutput)
+	}
+}
+
+func TestSitemapAndFeed(t *testing.T) {
+	defer tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+	tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	templ, err := tpl.ParseSources(fsTest, fmap, tpl.MapSource{
+		"views/docs/launch.md":  "---\ntitle: Launch\npath: /blog/launch\ndate: 2024-05-01\ndescription: We're live.\n---\n# Launch\n",
+		"views/docs/bonjour.md": "---\ntitle: Bonjour\nlang: fr\ndate: 2024-06-01\n---\n# Bonjour\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := templ.Sitemap(&buf, "https://example.com/"); err != nil {
+		t.Fatal(err)
+	}
+
+	s := buf.String()
+	if !strings.Contains(s, "<loc>https://example.com/fr/blog/launch</loc>") {
+		t.Errorf("expected the page in french: %s", s)
+	} else if !strings.Contains(s, "<lastmod>2024-03-04</lastmod>") {
+		t.Errorf("expected the updated date of the terms as lastmod: %s", s)
+	} else if !strings.Contains(s, `<xhtml:link rel="alternate" hreflang="en" href="https://example.com/en/blog/launch"></xhtml:link>`) {
+		t.Errorf("expected the english alternate: %s", s)
+	} else if strings.Contains(s, "/en/bonjour") {
+		t.Errorf("expected the french only page in french only: %s", s)
+	}
+
+	buf.Reset()
+	if err := templ.Feed(&buf, "https://example.com", "fr", "Blog"); err != nil {
+		t.Fatal(err)
+	}
+
+	s = buf.String()
+	if i, j := strings.Index(s, "/fr/bonjour"), strings.Index(s, "/fr/blog/launch"); i < 0 || j < 0 || i > j {
+		t.Errorf("expected the dated pages newest first: %s", s)
+	} else if strings.Contains(s, "/fr/terms") {
+		t.Errorf("expected the pages without a date left out: %s", s)
+	} else if !strings.Contains(s, "<description>We&#39;re live.</description>") {
+		t.Errorf("expected the description of the page: %s", s)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	defer tpl.Set(tpl.Option{TemplateRootName: "testdata"})
+
+	templ := load(t)
+
+	auth, err := tpl.ParseModule(fsTest, "testdata/authmod", nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if got := tpl.Translate("en", "hello-world"); got != "Hello world" {
+		t.Errorf("expected the catalog untouched by ParseModule, got %s", got)
+	}
+
+	if err := templ.Merge(auth); err == nil || !strings.Contains(err.Error(), "en:hello-world") {
+		t.Fatalf("expected the translation conflict, got %v", err)
+	} else if _, ok := templ.Views["auth/login.html"]; ok {
+		t.Fatal("expected nothing merged on conflict")
+	}
+
+	if err := templ.Merge(auth, tpl.MergeKeep); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := templ.Render(&buf, "auth/login.html", tpl.PageData{Lang: "en"}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<div class="auth"><h1>Sign in</h1><p>Hello world</p></div>` + "\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := templ.RenderEmail(&buf, "reset_en.txt", map[string]string{"Link": "/reset"}); err != nil {
+		t.Fatal(err)
+	} else if buf.String() != "Reset your password: /reset\n" {
+		t.Errorf("unexpected email %q", buf.String())
+	}
+
+	if err := templ.Validate("auth/login.html", tpl.PageData{Lang: "en"}); err != nil {
+		t.Errorf("expected the merged view to validate: %v", err)
+	}
+
+	src := []byte(`{{define "content"}}<h1>{{ t .Lang "login-title" }}</h1>{{end}}`)
+	if _, err := templ.PreviewChange("auth/login.html", src, tpl.PageData{Lang: "en"}); err != nil {
+		t.Errorf("expected a preview of the merged view: %v", err)
+	} else if _, err := templ.UpdateView("auth/login.html", src); err != nil {
+		t.Errorf("expected the merged view updated: %v", err)
+	} else if _, err := templ.UpdateEmail("reset_en.txt", []byte("Reset: {{ .Link }}")); err != nil {
+		t.Errorf("expected the merged email updated: %v", err)
+	}
+
+	buf.Reset()
+	if err := templ.Render(&buf, "auth/login.html", tpl.PageData{Lang: "en"}); err != nil {
+		t.Fatal(err)
+	} else if buf.String() != `<div class="auth"><h1>Sign in</h1></div>`+"\n" {
+		t.Errorf("expected the updated view with the module layout, got %q", buf.String())
+	}
+
+	if err := templ.Merge(auth, tpl.MergeReplace); err != nil {
+		t.Fatal(err)
+	} else if got := tpl.Translate("en", "hello-world"); got != "Hello from auth" {
+		t.Errorf("expected the merged translation, got %s", got)
+	}
+
+	done := make(chan bool)
+	go func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 500; i++ {
+			wg.Add(2)
+			go func() { defer wg.Done(); templ.Merge(auth, tpl.MergeReplace) }()
+			go func() { defer wg.Done(); auth.Merge(templ, tpl.MergeKeep) }()
+		}
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("concurrent merges of two Templates into each other deadlocked")
+	}
+}
+
+func TestRouteManifest(t *testing.T) {
+	routes, err := tpl.RouteManifest("testdata/routes")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := tpl.RouteManifestJSON(routes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []tpl.RouteView{
+		{Handler: "report", View: "app/report.html", Pos: "testdata/routes/main.go:38"},
+		{Pattern: "/", Handler: "home", View: "app/dashboard.html", Data: "map[string]any", Pos: "testdata/routes/main.go:29"},
+		{Method: "GET", Pattern: "/invoices/{id}", Handler: "invoice", View: "app/invoice.html", Data: "*invoice", Pos: "testdata/routes/main.go:34"},
+		{Method: "POST", Pattern: "/login", Handler: "main.func@22", View: "auth/login.html", Pos: "testdata/routes/main.go:23"},
+	}
+	if len(routes) != len(expected) {
+		t.Fatalf("expected %d routes got %s", len(expected), b)
+	}
+
+	for i, r := range routes {
+		if r != expected[i] {
+			t.Errorf("expected %+v got %+v", expected[i], r)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package tpl
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// The kinds of a MarkupChange.
+const (
+	MarkupAdded   = "added"
+	MarkupRemoved = "removed"
+	MarkupText    = "text"
+	MarkupAttr    = "attr"
+)
+
+// MarkupChange is a difference between two renders.
+type MarkupChange struct {
+	// Path locates the element, i.e. html/body/main/p[2].
+	Path string
+	// Kind is MarkupAdded, MarkupRemoved, MarkupText, or MarkupAttr.
+	Kind string
+	// Name is the attribute name of a MarkupAttr change.
+	Name   string
+	Before string
+	After  string
+}
+
+// String returns a one-line description of the change.
+func (c MarkupChange) String() string {
+	switch c.Kind {
+	case MarkupAdded:
+		return fmt.Sprintf("%s: added %s", c.Path, c.After)
+	case MarkupRemoved:
+		return fmt.Sprintf("%s: removed %s", c.Path, c.Before)
+	case MarkupAttr:
+		return fmt.Sprintf("%s: attribute %s %q -> %q", c.Path, c.Name, c.Before, c.After)
+	}
+	return fmt.Sprintf("%s: text %q -> %q", c.Path, c.Before, c.After)
+}
+
+// DiffReport lists the markup changes between two renders.
+type DiffReport struct {
+	Changes []MarkupChange
+}
+
+// Equal reports whether the renders have the same markup.
+func (r DiffReport) Equal() bool {
+	return len(r.Changes) == 0
+}
+
+// String returns one change per line.
+func (r DiffReport) String() string {
+	var sb strings.Builder
+	for _, c := range r.Changes {
+		sb.WriteString(c.String())
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// DiffRenders compares two renders of a view by their DOM, so visual
+// regression pipelines flag markup changes beyond byte equality. Whitespace
+// between elements, the whitespace inside texts, the order of attributes, and
+// comments are ignored:
+//
+//	report, err := tpl.DiffRenders(before, after)
+//	if !report.Equal() {
+//		t.Error(report)
+//	}
+func DiffRenders(a, b []byte) (DiffReport, error) {
+	da, err := html.Parse(bytes.NewReader(a))
+	if err != nil {
+		return DiffReport{}, err
+	}
+
+	db, err := html.Parse(bytes.NewReader(b))
+	if err != nil {
+		return DiffReport{}, err
+	}
+
+	var r DiffReport
+	r.diffChildren("", domChildren(da), domChildren(db))
+	return r, nil
+}
+
+// domChildren returns the children of n that matter for a diff, the
+// elements and the non-blank texts.
+func domChildren(n *html.Node) []*html.Node {
+	var nodes []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch c.Type {
+		case html.ElementNode:
+			nodes = append(nodes, c)
+		case html.TextNode:
+			if len(domText(c)) > 0 {
+				nodes = append(nodes, c)
+			}
+		}
+	}
+	return nodes
+}
+
+// domText returns the text of a node with its whitespace collapsed.
+func domText(n *html.Node) string {
+	return strings.Join(strings.Fields(n.Data), " ")
+}
+
+// domKey identifies the nodes that are compared with one another.
+func domKey(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return "#text"
+	}
+	return n.Data
+}
+
+// domLabel describes a node in an added or removed change.
+func domLabel(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return fmt.Sprintf("text %q", domText(n))
+	}
+	return "<" + n.Data + ">"
+}
+
+// childPaths returns the path of each node, the tag name followed by its
+// position among the siblings with the same tag when there are many.
+func childPaths(parent string, nodes []*html.Node) []string {
+	count := make(map[string]int)
+	for _, n := range nodes {
+		count[domKey(n)]++
+	}
+
+	seen := make(map[string]int)
+	paths := make([]string, len(nodes))
+	for i, n := range nodes {
+		k := domKey(n)
+		seen[k]++
+
+		p := k
+		if count[k] > 1 {
+			p = fmt.Sprintf("%s[%d]", k, seen[k])
+		}
+		if len(parent) > 0 {
+			p = parent + "/" + p
+		}
+		paths[i] = p
+	}
+	return paths
+}
+
+// diffChildren aligns the children on their longest common subsequence of
+// keys, compares the matching ones, and reports the others as added or
+// removed.
+func (r *DiffReport) diffChildren(parent string, a, b []*html.Node) {
+	pa, pb := childPaths(parent, a), childPaths(parent, b)
+
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if domKey(a[i]) == domKey(b[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && domKey(a[i]) == domKey(b[j]):
+			r.diffNode(pb[j], a[i], b[j])
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			r.Changes = append(r.Changes, MarkupChange{Path: pa[i], Kind: MarkupRemoved, Before: domLabel(a[i])})
+			i++
+		default:
+			r.Changes = append(r.Changes, MarkupChange{Path: pb[j], Kind: MarkupAdded, After: domLabel(b[j])})
+			j++
+		}
+	}
+}
+
+func (r *DiffReport) diffNode(p string, a, b *html.Node) {
+	if a.Type == html.TextNode {
+		if ta, tb := domText(a), domText(b); ta != tb {
+			r.Changes = append(r.Changes, MarkupChange{Path: p, Kind: MarkupText, Before: ta, After: tb})
+		}
+		return
+	}
+
+	before, after := domAttrs(a), domAttrs(b)
+
+	names := make([]string, 0, len(before)+len(after))
+	for k := range before {
+		names = append(names, k)
+	}
+	for k := range after {
+		if _, ok := before[k]; !ok {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+
+	for _, k := range names {
+		if before[k] != after[k] {
+			r.Changes = append(r.Changes, MarkupChange{Path: p, Kind: MarkupAttr, Name: k, Before: before[k], After: after[k]})
+		}
+	}
+
+	r.diffChildren(p, domChildren(a), domChildren(b))
+}
+
+// domAttrs returns the attributes of an element, the classes are sorted as
+// their order doesn't matter either.
+func domAttrs(n *html.Node) map[string]string {
+	attrs := make(map[string]string, len(n.Attr))
+	for _, a := range n.Attr {
+		key := a.Key
+		if len(a.Namespace) > 0 {
+			key = a.Namespace + ":" + key
+		}
+
+		v := a.Val
+		if key == "class" {
+			classes := strings.Fields(v)
+			sort.Strings(classes)
+			v = strings.Join(classes, " ")
+		}
+		attrs[key] = v
+	}
+	return attrs
+}
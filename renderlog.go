@@ -0,0 +1,38 @@
+package tpl
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// logRender logs, via slog, the renders slower than the SlowRenderThreshold
+// option and the failed ones, with the view, duration, data type, and
+// language.
+func logRender(name string, d time.Duration, data any, err error) {
+	if config.SlowRenderThreshold <= 0 || (err == nil && d < config.SlowRenderThreshold) {
+		return
+	}
+
+	attrs := []any{slog.String("view", name), slog.Duration("duration", d)}
+	if pd, ok := data.(PageData); ok {
+		attrs = append(attrs, slog.String("data", fmt.Sprintf("%T", pd.Data)), slog.String("lang", pd.Lang))
+	} else {
+		attrs = append(attrs, slog.String("data", fmt.Sprintf("%T", data)))
+	}
+
+	logger := configLogger()
+	if err != nil {
+		logger.Error("template render failed", append(attrs, slog.Any("err", err))...)
+		return
+	}
+	logger.Warn("slow template render", attrs...)
+}
+
+// configLogger returns the Logger option, slog.Default() if nil.
+func configLogger() *slog.Logger {
+	if config.Logger != nil {
+		return config.Logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,296 @@
+package tpl
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RouteView maps a URL of your app to a view it renders and the type of the
+// Data of its PageData.
+type RouteView struct {
+	Method  string `json:"method,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+	Handler string `json:"handler"`
+	View    string `json:"view"`
+	Data    string `json:"data,omitempty"`
+
+	// Pos is the file and line of the render call.
+	Pos string `json:"pos"`
+}
+
+// renderArgs holds the index of the view and data arguments of the render
+// methods of a Template.
+var renderArgs = map[string][2]int{
+	"Render":         {1, 2},
+	"RenderHTTP":     {1, 2},
+	"RenderBlock":    {1, 3},
+	"RenderCached":   {1, 4},
+	"RenderParts":    {0, 1},
+	"RenderAllLangs": {0, 1},
+}
+
+// routeMethods holds the methods registering a route of net/http, chi, and
+// echo, with the HTTP method of the route if they imply one.
+var routeMethods = map[string]string{
+	"Handle": "", "HandleFunc": "",
+	"Get": "GET", "Post": "POST", "Put": "PUT", "Patch": "PATCH", "Delete": "DELETE", "Head": "HEAD", "Options": "OPTIONS",
+	"GET": "GET", "POST": "POST", "PUT": "PUT", "PATCH": "PATCH", "DELETE": "DELETE", "HEAD": "HEAD", "OPTIONS": "OPTIONS",
+}
+
+// RouteManifest scans the Go sources of dir and its sub directories for the
+// views they render and the routes of their handlers, i.e. to document which
+// URL renders which view with which data:
+//
+//	routes, err := tpl.RouteManifest(".")
+//	b, err := tpl.RouteManifestJSON(routes)
+//
+// Routes registered via net/http's Handle and HandleFunc, chi's Get, Post,
+// etc., and echo's GET, POST, etc. are paired with the handler by name, or
+// with the function literal they register. The view must be a string literal
+// and the Data a composite literal, possibly assigned to a variable in the
+// handler, for them to be found. Views rendered by a handler with no detected
+// route have no Pattern.
+func RouteManifest(dir string) ([]RouteView, error) {
+	fset := token.NewFileSet()
+
+	type route struct{ method, pattern string }
+	routes := make(map[string][]route)
+	var renders []RouteView
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if name := d.Name(); p != dir && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !strings.HasSuffix(p, ".go") || strings.HasSuffix(p, "_test.go") {
+			return nil
+		}
+
+		f, err := parser.ParseFile(fset, p, nil, 0)
+		if err != nil {
+			return err
+		}
+
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+
+			handler := fn.Name.Name
+			renders = append(renders, findRenders(fset, fn.Body, handler)...)
+
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok || len(call.Args) < 2 {
+					return true
+				}
+
+				sel, ok := call.Fun.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+
+				method, ok := routeMethods[sel.Sel.Name]
+				if !ok {
+					return true
+				}
+
+				pattern, ok := stringLit(call.Args[0])
+				if !ok {
+					return true
+				}
+
+				// net/http patterns may start with the method, i.e. "GET /{id}"
+				if m, rest, found := strings.Cut(pattern, " "); found && method == "" && strings.ToUpper(m) == m {
+					method, pattern = m, strings.TrimSpace(rest)
+				}
+
+				h := call.Args[1]
+				if conv, ok := h.(*ast.CallExpr); ok && len(conv.Args) == 1 {
+					h = conv.Args[0]
+				}
+
+				var name string
+				switch h := h.(type) {
+				case *ast.Ident:
+					name = h.Name
+				case *ast.SelectorExpr:
+					name = h.Sel.Name
+				case *ast.FuncLit:
+					name = fmt.Sprintf("%s.func@%d", handler, fset.Position(h.Pos()).Line)
+					renders = append(renders, findRenders(fset, h.Body, name)...)
+				default:
+					return true
+				}
+
+				routes[name] = append(routes[name], route{method: method, pattern: pattern})
+				return true
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest []RouteView
+	for _, r := range renders {
+		found := routes[r.Handler]
+		if len(found) == 0 {
+			manifest = append(manifest, r)
+			continue
+		}
+
+		for _, rt := range found {
+			r.Method, r.Pattern = rt.method, rt.pattern
+			manifest = append(manifest, r)
+		}
+	}
+
+	sort.SliceStable(manifest, func(i, j int) bool {
+		if manifest[i].Pattern != manifest[j].Pattern {
+			return manifest[i].Pattern < manifest[j].Pattern
+		}
+		if manifest[i].Method != manifest[j].Method {
+			return manifest[i].Method < manifest[j].Method
+		}
+		return manifest[i].View < manifest[j].View
+	})
+	return manifest, nil
+}
+
+// RouteManifestJSON returns the indented JSON of a manifest, i.e. to commit
+// it next to your templates.
+func RouteManifestJSON(routes []RouteView) ([]byte, error) {
+	return json.MarshalIndent(routes, "", "  ")
+}
+
+// findRenders returns the views rendered in a function body, the function
+// literals it contains excluded.
+func findRenders(fset *token.FileSet, body *ast.BlockStmt, handler string) []RouteView {
+	vars := make(map[string]ast.Expr)
+
+	var renders []RouteView
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.AssignStmt:
+			for i, lhs := range n.Lhs {
+				if id, ok := lhs.(*ast.Ident); ok && len(n.Lhs) == len(n.Rhs) {
+					vars[id.Name] = n.Rhs[i]
+				}
+			}
+		case *ast.ValueSpec:
+			for i, id := range n.Names {
+				if i < len(n.Values) {
+					vars[id.Name] = n.Values[i]
+				} else if n.Type != nil {
+					vars[id.Name] = &ast.CompositeLit{Type: n.Type}
+				}
+			}
+		case *ast.CallExpr:
+			sel, ok := n.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+
+			args, ok := renderArgs[sel.Sel.Name]
+			if !ok || len(n.Args) <= args[1] {
+				return true
+			}
+
+			view, ok := stringLit(n.Args[args[0]])
+			if !ok {
+				return true
+			}
+
+			pos := fset.Position(n.Pos())
+			renders = append(renders, RouteView{
+				Handler: handler,
+				View:    view,
+				Data:    dataType(n.Args[args[1]], vars),
+				Pos:     filepath.ToSlash(pos.Filename) + ":" + strconv.Itoa(pos.Line),
+			})
+		}
+		return true
+	})
+	return renders
+}
+
+// dataType returns the type of the Data field of a PageData expression, the
+// variables resolved to the expression assigned to them.
+func dataType(e ast.Expr, vars map[string]ast.Expr) string {
+	lit, ok := resolve(e, vars).(*ast.CompositeLit)
+	if !ok || lit.Type == nil || !strings.HasSuffix(types.ExprString(lit.Type), "PageData") {
+		return ""
+	}
+
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+
+		if key, ok := kv.Key.(*ast.Ident); !ok || key.Name != "Data" {
+			continue
+		}
+
+		switch v := resolve(kv.Value, vars).(type) {
+		case *ast.CompositeLit:
+			if v.Type != nil {
+				return types.ExprString(v.Type)
+			}
+		case *ast.UnaryExpr:
+			if v.Op == token.AND {
+				if cl, ok := resolve(v.X, vars).(*ast.CompositeLit); ok && cl.Type != nil {
+					return "*" + types.ExprString(cl.Type)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func resolve(e ast.Expr, vars map[string]ast.Expr) ast.Expr {
+	for i := 0; i < 8; i++ {
+		id, ok := e.(*ast.Ident)
+		if !ok {
+			return e
+		}
+
+		v, found := vars[id.Name]
+		if !found {
+			return e
+		}
+		e = v
+	}
+	return e
+}
+
+func stringLit(e ast.Expr) (string, bool) {
+	lit, ok := e.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
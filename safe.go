@@ -0,0 +1,37 @@
+package tpl
+
+import "html/template"
+
+// SafeHTML marks a trusted string as HTML, it's output unescaped, i.e. for
+// markup your team edits in a CMS:
+//
+//	{{ safehtml .Data.Banner }}
+//
+// Never pass it content from users, see Sanitize. The safe funcs are not
+// available to sandboxed templates.
+func SafeHTML(s string) template.HTML {
+	return template.HTML(s)
+}
+
+// SafeJS marks a trusted string as a JavaScript expression, i.e. an inline
+// config object:
+//
+//	<script>const config = {{ safejs .Data.ConfigJSON }};</script>
+func SafeJS(s string) template.JS {
+	return template.JS(s)
+}
+
+// SafeCSS marks a trusted string as CSS, i.e. a style declaration:
+//
+//	<div style="{{ safecss .Data.Style }}">
+func SafeCSS(s string) template.CSS {
+	return template.CSS(s)
+}
+
+// SafeURL marks a trusted string as a URL, its scheme isn't checked, i.e. for
+// a tel: or a custom app scheme link:
+//
+//	<a href="{{ safeurl .Data.AppLink }}">
+func SafeURL(s string) template.URL {
+	return template.URL(s)
+}
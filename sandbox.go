@@ -0,0 +1,195 @@
+package tpl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"text/template/parse"
+	"time"
+)
+
+var (
+	// ErrSandboxOutput is returned when a sandboxed template writes more than
+	// its MaxOutput.
+	ErrSandboxOutput = errors.New("sandboxed template output exceeds the limit")
+	// ErrSandboxTimeout is returned when a sandboxed template runs longer
+	// than its Timeout.
+	ErrSandboxTimeout = errors.New("sandboxed template execution timed out")
+)
+
+// SafeFuncs are the functions sandboxed templates may call when the Funcs of
+// the Sandbox are nil. Notably, call, iterate, and the functions of your
+// funcmap are not allowed.
+var SafeFuncs = []string{
+	"and", "or", "not", "len", "index", "slice", "print", "printf", "println",
+	"html", "js", "urlquery", "eq", "ne", "lt", "le", "gt", "ge",
+	"t", "tp", "tf", "tfp", "tm", "tselect",
+	"shortdate", "currency", "accounting", "centscurrency", "centsnumber",
+	"number", "ordinal", "percent", "percentvalue", "unit", "abbrevnum",
+	"intword", "intcomma", "filesize",
+	"date", "time", "datetime", "monthname", "weekdayname", "localtime",
+	"naturaltime", "naturalday", "timesince", "timeuntil", "humanduration",
+	"readingtime",
+	"phone", "address", "countryname", "langname", "dir", "bidi", "langurl",
+	"autolink", "nl2br", "highlight", "emojify", "striptags", "wordwrap",
+	"pluralize", "mask", "maskemail", "maskcard", "initials", "truncate",
+	"excerpt", "markdown", "sanitize", "toc", "highlightcode", "add", "sub", "mul", "div", "mod", "min", "max", "round",
+	"ceil", "floor", "first", "last", "reverse", "uniq", "sortby", "groupby",
+	"get", "dig", "merge", "keys", "urljoin", "queryset", "querydel", "urlencode",
+	"json", "jsonscript", "class", "attrs", "qrcode", "map",
+}
+
+// builtins are the functions of text/template, needed to parse a file on its
+// own.
+var builtins = map[string]any{
+	"and": nil, "or": nil, "not": nil, "len": nil, "index": nil, "slice": nil,
+	"print": nil, "printf": nil, "println": nil, "html": nil, "js": nil,
+	"urlquery": nil, "call": nil, "eq": nil, "ne": nil, "lt": nil, "le": nil,
+	"gt": nil, "ge": nil,
+}
+
+// Sandbox restricts what the templates of an untrusted source, like emails
+// your customers edit, can do.
+type Sandbox struct {
+	// Funcs are the functions the templates may call, SafeFuncs if nil.
+	Funcs []string
+	// MaxOutput is the maximum size of a render in bytes, 1 MiB if 0.
+	MaxOutput int
+	// Timeout is how long a render waits for the execution, 2 seconds if 0.
+	// It's not a hard limit: Go can't stop a running execution, it's stopped
+	// at its next write. A loop that doesn't write, or a slow function, keeps
+	// running in the background until it ends.
+	Timeout time.Duration
+}
+
+// Untrusted marks the templates of a source as user-authored. Parsing fails
+// if they call a function the Sandbox does not allow, and the views and
+// emails using them are rendered within the Sandbox limits:
+//
+//	templ, err := tpl.ParseSources(fs, fmap, tpl.Untrusted(customerEmails, tpl.Sandbox{}))
+func Untrusted(src TemplateSource, sb Sandbox) TemplateSource {
+	return untrustedSource{TemplateSource: src, sandbox: sb}
+}
+
+type untrustedSource struct {
+	TemplateSource
+	sandbox Sandbox
+}
+
+// fileSandbox returns the Sandbox of the first untrusted file of paths.
+func fileSandbox(fsys fs.FS, paths ...string) (Sandbox, bool) {
+	o, ok := fsys.(*overlayFS)
+	if !ok {
+		return Sandbox{}, false
+	}
+
+	for _, p := range paths {
+		if sb, ok := o.sandboxes[p]; ok {
+			return sb, true
+		}
+	}
+	return Sandbox{}, false
+}
+
+// checkSandboxFile returns an error if the file calls a function the Sandbox
+// does not allow.
+func checkSandboxFile(fsys fs.FS, fullPath string, funcMap map[string]any, sb Sandbox) error {
+	b, err := fs.ReadFile(fsys, fullPath)
+	if err != nil {
+		return err
+	}
+
+	return checkSandbox(fullPath, b, funcMap, sb)
+}
+
+// checkSandbox returns an error if the template source calls a function the
+// Sandbox does not allow. A markdown view has no actions, its HTML is
+// sanitized when it's converted.
+func checkSandbox(name string, src []byte, funcMap map[string]any, sb Sandbox) error {
+	if isMarkdown(name) {
+		return nil
+	}
+
+	trees, err := parse.Parse(name, string(src), "", "", funcMap, builtins)
+	if err != nil {
+		return err
+	}
+
+	allowed := sb.Funcs
+	if allowed == nil {
+		allowed = SafeFuncs
+	}
+
+	ok := make(map[string]bool)
+	for _, name := range allowed {
+		ok[name] = true
+	}
+
+	var denied string
+	for _, tree := range trees {
+		walkNodes(tree.Root, func(n parse.Node) {
+			if id, isIdent := n.(*parse.IdentifierNode); isIdent && !ok[id.Ident] && len(denied) == 0 {
+				denied = id.Ident
+			}
+		})
+	}
+
+	if len(denied) > 0 {
+		return fmt.Errorf("%s: function %s is not allowed in sandboxed templates", name, denied)
+	}
+	return nil
+}
+
+// execute runs exec within the limits of the Sandbox. The output is buffered
+// and written to w only when the execution succeeds. On timeout, the
+// goroutine of exec is left running until its next write fails or it ends.
+func (sb Sandbox) execute(w io.Writer, exec func(io.Writer) error) error {
+	timeout := sb.Timeout
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+
+	max := sb.MaxOutput
+	if max <= 0 {
+		max = 1 << 20
+	}
+
+	sw := &sandboxWriter{max: max, deadline: time.Now().Add(timeout)}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- exec(sw)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(sw.buf.Bytes())
+		return err
+	case <-time.After(timeout):
+		// the execution stops at its next write
+		return ErrSandboxTimeout
+	}
+}
+
+// sandboxWriter buffers the output of a sandboxed execution and fails once
+// the output is too large or the deadline has passed.
+type sandboxWriter struct {
+	buf      bytes.Buffer
+	max      int
+	deadline time.Time
+}
+
+func (sw *sandboxWriter) Write(p []byte) (int, error) {
+	if time.Now().After(sw.deadline) {
+		return 0, ErrSandboxTimeout
+	}
+	if sw.buf.Len()+len(p) > sw.max {
+		return 0, ErrSandboxOutput
+	}
+	return sw.buf.Write(p)
+}
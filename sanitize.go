@@ -0,0 +1,38 @@
+package tpl
+
+import (
+	"html/template"
+	"sync"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+var (
+	ugcPolicy     *bluemonday.Policy
+	ugcPolicyOnce sync.Once
+)
+
+// sanitizeHTML applies the Sanitizer option, bluemonday's user generated
+// content policy if nil.
+func sanitizeHTML(s string) string {
+	if config.Sanitizer != nil {
+		return config.Sanitizer(s)
+	}
+
+	ugcPolicyOnce.Do(func() {
+		ugcPolicy = bluemonday.UGCPolicy()
+	})
+	return ugcPolicy.Sanitize(s)
+}
+
+// Sanitize returns the untrusted HTML, like rich text from a WYSIWYG editor,
+// safe to output unescaped:
+//
+//	<div class="comment">{{ sanitize .Data.Body }}</div>
+//
+// The formatting, links, lists, tables, and images are kept while scripts,
+// styles, and event handlers are removed. Set the Sanitizer option to use
+// another policy.
+func Sanitize(s string) template.HTML {
+	return template.HTML(sanitizeHTML(s))
+}
@@ -0,0 +1,105 @@
+package tpl
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// iterable reports whether v is an iterator, a func(yield func(T) bool)
+// like iter.Seq, a slice, or an array.
+func iterable(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		return true
+	case reflect.Func:
+		t := v.Type()
+		if t.NumIn() != 1 || t.NumOut() != 0 {
+			return false
+		}
+
+		yt := t.In(0)
+		return yt.Kind() == reflect.Func && yt.NumIn() == 1 && yt.NumOut() == 1 && yt.Out(0).Kind() == reflect.Bool
+	}
+	return false
+}
+
+// eachValue calls fn for each value of an iterator, a slice, or an array
+// until fn returns false.
+func eachValue(seq any, fn func(v any) bool) error {
+	rv := reflect.ValueOf(seq)
+	if !iterable(rv) {
+		return fmt.Errorf("%T is not an iterator or a slice", seq)
+	}
+
+	if rv.Kind() == reflect.Func {
+		yield := reflect.MakeFunc(rv.Type().In(0), func(args []reflect.Value) []reflect.Value {
+			return []reflect.Value{reflect.ValueOf(fn(args[0].Interface()))}
+		})
+		rv.Call([]reflect.Value{yield})
+		return nil
+	}
+
+	for i := 0; i < rv.Len(); i++ {
+		if !fn(rv.Index(i).Interface()) {
+			break
+		}
+	}
+	return nil
+}
+
+// Collect materializes an iterator, i.e. an iter.Seq passed in the Data by a
+// handler streaming rows from the database, into a slice. Slices are copied
+// as is:
+//
+//	{{ $rows := collect .Data.Rows }}
+func Collect(seq any) ([]any, error) {
+	var values []any
+	err := eachValue(seq, func(v any) bool {
+		values = append(values, v)
+		return true
+	})
+	return values, err
+}
+
+// Take returns the first n values of an iterator or a slice, the iteration
+// stops after them:
+//
+//	{{ range take 10 .Data.Rows }}
+func Take(n int, seq any) ([]any, error) {
+	values := make([]any, 0, max(n, 0))
+	if n <= 0 {
+		return values, nil
+	}
+
+	err := eachValue(seq, func(v any) bool {
+		values = append(values, v)
+		return len(values) < n
+	})
+	return values, err
+}
+
+// Chunk groups the values of an iterator or a slice by n, i.e. for the rows
+// of a grid:
+//
+//	{{ range chunk 3 .Data.Products }}<div class="row">{{ range . }}...{{ end }}</div>{{ end }}
+func Chunk(n int, seq any) ([][]any, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("chunk size must be positive: %d", n)
+	}
+
+	var chunks [][]any
+	var current []any
+	err := eachValue(seq, func(v any) bool {
+		current = append(current, v)
+		if len(current) == n {
+			chunks = append(chunks, current)
+			current = nil
+		}
+		return true
+	})
+
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks, err
+}
@@ -0,0 +1,167 @@
+package tpl
+
+import (
+	"bytes"
+	"embed"
+	"io/fs"
+	"path"
+	"sort"
+	"time"
+)
+
+// TemplateSource provides templates stored outside of the embedded file
+// system, like customer-editable views or emails saved in a database or S3.
+type TemplateSource interface {
+	// Templates returns the content of the files keyed by their path
+	// relative to the template root, i.e. views/app/dashboard.html,
+	// emails/welcome_fr.html, or translations/fr.json.
+	Templates() (map[string][]byte, error)
+}
+
+// MapSource is a TemplateSource of in-memory templates keyed by their path
+// relative to the template root.
+type MapSource map[string]string
+
+// Templates returns the templates of the map.
+func (m MapSource) Templates() (map[string][]byte, error) {
+	files := make(map[string][]byte, len(m))
+	for name, content := range m {
+		files[name] = []byte(content)
+	}
+	return files, nil
+}
+
+// overlayFS serves the files of the sources and falls back to the embedded
+// file system.
+type overlayFS struct {
+	base  fs.FS
+	files map[string][]byte
+	dirs  map[string]bool
+
+	// sandboxes holds the Sandbox of the files from untrusted sources.
+	sandboxes map[string]Sandbox
+}
+
+func overlay(base embed.FS, sources []TemplateSource) (fs.FS, error) {
+	if len(sources) == 0 {
+		return base, nil
+	}
+
+	o := &overlayFS{
+		base:      base,
+		files:     make(map[string][]byte),
+		dirs:      make(map[string]bool),
+		sandboxes: make(map[string]Sandbox),
+	}
+
+	for _, src := range sources {
+		files, err := src.Templates()
+		if err != nil {
+			return nil, err
+		}
+
+		for name, b := range files {
+			// a name with .. segments could replace a file outside of the
+			// root once joined
+			full := path.Join(config.TemplateRootName, name)
+			if !fs.ValidPath(name) || name == "." || !fs.ValidPath(full) {
+				return nil, &fs.PathError{Op: "overlay", Path: name, Err: fs.ErrInvalid}
+			}
+
+			o.files[full] = b
+			if u, ok := src.(untrustedSource); ok {
+				o.sandboxes[full] = u.sandbox
+			} else {
+				delete(o.sandboxes, full)
+			}
+			for dir := path.Dir(full); dir != "."; dir = path.Dir(dir) {
+				o.dirs[dir] = true
+			}
+		}
+	}
+
+	return o, nil
+}
+
+func (o *overlayFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if b, ok := o.files[name]; ok {
+		return &memFile{info: memInfo{name: path.Base(name), size: int64(len(b))}, r: bytes.NewReader(b)}, nil
+	}
+
+	if o.dirs[name] {
+		return &memFile{info: memInfo{name: path.Base(name), dir: true}, r: bytes.NewReader(nil)}, nil
+	}
+
+	return o.base.Open(name)
+}
+
+// ReadDir merges the entries of the embedded file system with the ones of
+// the sources.
+func (o *overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(o.base, name)
+	if err != nil && !o.dirs[name] {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		seen[e.Name()] = true
+	}
+
+	for full, b := range o.files {
+		if path.Dir(full) == name && !seen[path.Base(full)] {
+			entries = append(entries, memInfo{name: path.Base(full), size: int64(len(b))})
+			seen[path.Base(full)] = true
+		}
+	}
+
+	for dir := range o.dirs {
+		if path.Dir(dir) == name && !seen[path.Base(dir)] {
+			entries = append(entries, memInfo{name: path.Base(dir), dir: true})
+			seen[path.Base(dir)] = true
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+type memFile struct {
+	info memInfo
+	r    *bytes.Reader
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memFile) Read(b []byte) (int, error) {
+	if f.info.dir {
+		return 0, &fs.PathError{Op: "read", Path: f.info.name, Err: fs.ErrInvalid}
+	}
+	return f.r.Read(b)
+}
+func (f *memFile) Close() error { return nil }
+
+// memInfo describes an in-memory file or directory as both a fs.FileInfo
+// and a fs.DirEntry.
+type memInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (i memInfo) Name() string { return i.name }
+func (i memInfo) Size() int64  { return i.size }
+func (i memInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+func (i memInfo) ModTime() time.Time         { return time.Time{} }
+func (i memInfo) IsDir() bool                { return i.dir }
+func (i memInfo) Sys() any                   { return nil }
+func (i memInfo) Type() fs.FileMode          { return i.Mode().Type() }
+func (i memInfo) Info() (fs.FileInfo, error) { return i, nil }
@@ -0,0 +1,136 @@
+package tpl
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"sync"
+	"text/template/parse"
+)
+
+// instrument adds, in DevMode, HTML comments around the output of every
+// template of a view with the file and line it comes from:
+//
+//	<!-- tpl:begin "nav" nav.html:1 -->
+//	<p>Main nav here</p>
+//	<!-- tpl:end "nav" -->
+//
+// The comments are added once the view has been escaped by html/template so
+// they are not stripped. Templates executed outside of an HTML text context,
+// for instance inside a <title> or an attribute, are left untouched.
+func (templ *Template) instrument(view string, v *template.Template) {
+	done := func() bool {
+		templ.mu.RLock()
+		defer templ.mu.RUnlock()
+
+		return templ.instrumented[view]
+	}
+
+	if done() {
+		return
+	}
+
+	// only the renders of the view being instrumented are blocked
+	mu := templ.instrumentLock(view)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if done() {
+		return
+	}
+
+	// html/template escapes the templates on their first execution. The view
+	// is escaped through a template calling it in a branch that never runs,
+	// none of its actions are executed.
+	entry, err := v.New(instrumentEntry).Parse(`{{ if false }}{{ template "` + v.Name() + `" . }}{{ end }}`)
+	if err != nil {
+		return
+	} else if err := entry.Execute(io.Discard, nil); err != nil {
+		return
+	}
+
+	for _, t := range v.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil || strings.Contains(t.Name(), "$htmltemplate_") || t.Name() == instrumentEntry {
+			continue
+		} else if isBlankList(t.Tree.Root) || startsWithDoctype(t.Tree.Root) {
+			continue
+		}
+
+		loc, _ := t.Tree.ErrorContext(t.Tree.Root)
+		if len(loc) == 0 || strings.HasPrefix(loc, ":") {
+			loc = t.Tree.ParseName + loc
+		}
+
+		begin := fmt.Sprintf("<!-- tpl:begin %q %s -->", t.Name(), sourceLocation(loc))
+		end := fmt.Sprintf("<!-- tpl:end %q -->", t.Name())
+
+		nodes := []parse.Node{&parse.TextNode{NodeType: parse.NodeText, Text: []byte(begin)}}
+		nodes = append(nodes, t.Tree.Root.Nodes...)
+		nodes = append(nodes, &parse.TextNode{NodeType: parse.NodeText, Text: []byte(end)})
+		t.Tree.Root.Nodes = nodes
+	}
+
+	templ.mu.Lock()
+	defer templ.mu.Unlock()
+
+	if templ.instrumented == nil {
+		templ.instrumented = make(map[string]bool)
+	}
+	templ.instrumented[view] = true
+}
+
+// instrumentEntry is the name of the template escaping a view to instrument.
+const instrumentEntry = "tpl:instrument"
+
+// instrumentLock returns the mutex of a view being instrumented.
+func (templ *Template) instrumentLock(view string) *sync.Mutex {
+	templ.mu.Lock()
+	defer templ.mu.Unlock()
+
+	if templ.instrumenting == nil {
+		templ.instrumenting = make(map[string]*sync.Mutex)
+	}
+
+	mu, ok := templ.instrumenting[view]
+	if !ok {
+		mu = new(sync.Mutex)
+		templ.instrumenting[view] = mu
+	}
+	return mu
+}
+
+// sourceLocation keeps the file and line of a "file:line:col" location.
+func sourceLocation(loc string) string {
+	parts := strings.Split(loc, ":")
+	if len(parts) >= 2 {
+		return parts[0] + ":" + parts[1]
+	}
+	return loc
+}
+
+func isBlankList(l *parse.ListNode) bool {
+	for _, n := range l.Nodes {
+		text, ok := n.(*parse.TextNode)
+		if !ok || len(bytes.TrimSpace(text.Text)) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// startsWithDoctype prevents adding a comment before the doctype, which would
+// switch browsers to quirks mode.
+func startsWithDoctype(l *parse.ListNode) bool {
+	if len(l.Nodes) == 0 {
+		return false
+	}
+
+	text, ok := l.Nodes[0].(*parse.TextNode)
+	if !ok {
+		return false
+	}
+
+	return bytes.HasPrefix(bytes.ToLower(bytes.TrimSpace(text.Text)), []byte("<!doctype"))
+}
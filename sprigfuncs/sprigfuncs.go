@@ -0,0 +1,33 @@
+// Package sprigfuncs adds the functions of sprig, i.e. upper, default, or
+// dict, to the func map passed to tpl.Parse, for programs migrating from a
+// sprig-based stack. It's a package of its own so sprig and its dependencies
+// are only built into the programs using it.
+package sprigfuncs
+
+import (
+	"github.com/Masterminds/sprig/v3"
+	"github.com/dstpierre/tpl"
+)
+
+// Add adds the sprig functions to fmap, except the ones fmap or tpl define:
+// yours and tpl's win on conflicts, i.e. date is tpl's. It returns fmap, a
+// new map if it's nil:
+//
+//	templ, err := tpl.Parse(fs, sprigfuncs.Add(fmap))
+func Add(fmap map[string]any) map[string]any {
+	if fmap == nil {
+		fmap = make(map[string]any)
+	}
+
+	own := tpl.FuncMap()
+	for name, fn := range sprig.HtmlFuncMap() {
+		if _, ok := own[name]; ok {
+			continue
+		}
+
+		if _, ok := fmap[name]; !ok {
+			fmap[name] = fn
+		}
+	}
+	return fmap
+}
@@ -0,0 +1,92 @@
+package tpl
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+)
+
+var stackMarker = regexp.MustCompile(`<!--tpl:stack:([^>]*)-->`)
+
+// Push adds a template to a named stack that the layout outputs with the stack
+// function. Views and partials can push their own assets to the layout's
+// regions without defining one big head block per view:
+//
+//	{{define "chart-js"}}<script src="/js/chart.js"></script>{{end}}
+//	{{ push . "scripts" "chart-js" }}
+//
+// A template is only pushed once per stack even if the partial pushing it is
+// rendered multiple times.
+func Push(data PageData, stack, name string) string {
+	if data.state == nil {
+		return ""
+	}
+
+	if data.state.stacks == nil {
+		data.state.stacks = make(map[string][]string)
+	}
+
+	for _, s := range data.state.stacks[stack] {
+		if s == name {
+			return ""
+		}
+	}
+
+	data.state.stacks[stack] = append(data.state.stacks[stack], name)
+	return ""
+}
+
+// Stack outputs all templates pushed to a named stack, wherever they were
+// pushed during the render:
+//
+//	<head>{{ stack . "head" }}</head>
+//	<body>... {{ stack . "scripts" }}</body>
+func Stack(data PageData, stack string) template.HTML {
+	return template.HTML(fmt.Sprintf("<!--tpl:stack:%s-->", template.HTMLEscapeString(stack)))
+}
+
+// resolveStacks replaces the stack markers in the output with the execution of
+// the templates pushed to them.
+func resolveStacks(v *template.Template, out []byte, data PageData) ([]byte, error) {
+	var execErr error
+
+	b := stackMarker.ReplaceAllFunc(out, func(m []byte) []byte {
+		name := html.UnescapeString(string(stackMarker.FindSubmatch(m)[1]))
+
+		var buf bytes.Buffer
+		for _, t := range data.state.stacks[name] {
+			if err := v.ExecuteTemplate(&buf, t, data); err != nil && execErr == nil {
+				execErr = err
+			}
+		}
+		return buf.Bytes()
+	})
+
+	return b, execErr
+}
+
+// usesStack reports whether a view outputs a stack, in which case its render
+// needs to be buffered.
+func (templ *Template) usesStack(view string, v *template.Template) bool {
+	templ.mu.RLock()
+	stacked, ok := templ.stacked[view]
+	templ.mu.RUnlock()
+
+	templ.stats.cache(ok)
+	if ok {
+		return stacked
+	}
+
+	stacked = usesFunc(v, "stack")
+
+	templ.mu.Lock()
+	defer templ.mu.Unlock()
+
+	if templ.stacked == nil {
+		templ.stacked = make(map[string]bool)
+	}
+	templ.stacked[view] = stacked
+	return stacked
+}
@@ -0,0 +1,113 @@
+package tpl
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// RenderStats are the render counts and durations of a view or an email.
+type RenderStats struct {
+	Renders       int64
+	Errors        int64
+	TotalDuration time.Duration
+	MaxDuration   time.Duration
+}
+
+// AverageDuration returns the mean duration of a render.
+func (s RenderStats) AverageDuration() time.Duration {
+	if s.Renders == 0 {
+		return 0
+	}
+	return s.TotalDuration / time.Duration(s.Renders)
+}
+
+// Stats are the metrics of a Template since it was parsed.
+type Stats struct {
+	Renders int64
+	Errors  int64
+	// Templates holds the stats of each view and email by name.
+	Templates map[string]RenderStats
+	// CacheHits and CacheMisses count the lookups of RenderCached, of the
+	// parsed inline templates, and of the per-view analysis, like the use of
+	// stack.
+	CacheHits   int64
+	CacheMisses int64
+}
+
+// CacheHitRate returns the ratio of cache lookups that were hits, between 0
+// and 1.
+func (s Stats) CacheHitRate() float64 {
+	if total := s.CacheHits + s.CacheMisses; total > 0 {
+		return float64(s.CacheHits) / float64(total)
+	}
+	return 0
+}
+
+// statsRecorder accumulates the Stats of a Template.
+type statsRecorder struct {
+	mu        sync.Mutex
+	templates map[string]RenderStats
+	hits      int64
+	misses    int64
+}
+
+func (r *statsRecorder) render(name string, d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.templates == nil {
+		r.templates = make(map[string]RenderStats)
+	}
+
+	s := r.templates[name]
+	s.Renders++
+	if err != nil {
+		s.Errors++
+	}
+	s.TotalDuration += d
+	s.MaxDuration = max(s.MaxDuration, d)
+	r.templates[name] = s
+}
+
+func (r *statsRecorder) cache(hit bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if hit {
+		r.hits++
+	} else {
+		r.misses++
+	}
+}
+
+// Stats returns the render counts and durations of the views and emails, and
+// the cache hits and misses.
+func (templ *Template) Stats() Stats {
+	r := &templ.stats
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := Stats{
+		Templates:   make(map[string]RenderStats, len(r.templates)),
+		CacheHits:   r.hits,
+		CacheMisses: r.misses,
+	}
+	for name, ts := range r.templates {
+		s.Templates[name] = ts
+		s.Renders += ts.Renders
+		s.Errors += ts.Errors
+	}
+	return s
+}
+
+// PublishExpvar publishes the Stats under name via expvar, so they're
+// visible at /debug/vars without a metrics stack. Like expvar.Publish, it
+// panics if the name is already in use:
+//
+//	templ.PublishExpvar("templates")
+func (templ *Template) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return templ.Stats()
+	}))
+}
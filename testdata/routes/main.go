@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/dstpierre/tpl"
+)
+
+var templ *tpl.Template
+
+type server struct{}
+
+type invoice struct{ ID int }
+
+func main() {
+	s := server{}
+
+	http.HandleFunc("GET /invoices/{id}", s.invoice)
+	http.Handle("/", http.HandlerFunc(home))
+
+	r := chiRouter()
+	r.Post("/login", func(w http.ResponseWriter, r *http.Request) {
+		templ.Render(w, "auth/login.html", tpl.PageData{})
+	})
+}
+
+func home(w http.ResponseWriter, r *http.Request) {
+	data := tpl.PageData{Title: "Home", Data: map[string]any{}}
+	templ.Render(w, "app/dashboard.html", data)
+}
+
+func (s server) invoice(w http.ResponseWriter, r *http.Request) {
+	inv := invoice{ID: 1}
+	templ.RenderHTTP(w, "app/invoice.html", tpl.PageData{Data: &inv})
+}
+
+func report(w http.ResponseWriter, r *http.Request) {
+	templ.RenderBlock(w, "app/report.html", "content", tpl.PageData{})
+}
@@ -0,0 +1,290 @@
+package tpl
+
+import (
+	"html/template"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+var autolinkPattern = regexp.MustCompile(
+	`(?i)(https?://[^\s<>"]+|www\.[^\s<>"]+)` +
+		`|([a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,})` +
+		`|(\+?\d[\d\s().-]{5,}\d)`,
+)
+
+var (
+	// datePrefix matches the ISO and slashed dates, i.e. 2024-01-02.
+	datePrefix = regexp.MustCompile(`^\d{4}[-/.]\d{1,2}[-/.]\d{1,2}`)
+
+	// dottedPhone matches the phone numbers written with dots, 555.123.4567,
+	// other dotted numbers are versions or IP addresses.
+	dottedPhone = regexp.MustCompile(`^(\(\d{3}\)\s?|\d{3}[.\s-])\d{3}[.\s-]\d{4}$`)
+)
+
+// Autolink escapes a plain text and wraps the URLs, email addresses, and
+// phone numbers it contains in links:
+//
+//	{{ autolink .Data.Notes }}
+//
+// Phone numbers need at least 7 digits to be linked.
+func Autolink(s string) template.HTML {
+	var sb strings.Builder
+
+	last := 0
+	for _, m := range autolinkPattern.FindAllStringSubmatchIndex(s, -1) {
+		start, end := m[0], m[1]
+		match := s[start:end]
+
+		var href string
+		switch {
+		case m[2] >= 0:
+			trimmed := strings.TrimRight(match, ".,;:!?)'")
+			end = start + len(trimmed)
+			match = trimmed
+
+			href = match
+			if !strings.Contains(strings.ToLower(match[:min(len(match), 8)]), "://") {
+				href = "http://" + match
+			}
+		case m[4] >= 0:
+			href = "mailto:" + match
+		default:
+			digits := phoneDigits(match)
+			if len(strings.TrimPrefix(digits, "+")) < 7 || !isPhone(match) {
+				continue
+			}
+			href = "tel:" + digits
+		}
+
+		sb.WriteString(template.HTMLEscapeString(s[last:start]))
+		sb.WriteString(`<a href="`)
+		sb.WriteString(template.HTMLEscapeString(href))
+		sb.WriteString(`"`)
+		if strings.HasPrefix(href, "http") {
+			sb.WriteString(` rel="nofollow noopener"`)
+		}
+		sb.WriteString(`>`)
+		sb.WriteString(template.HTMLEscapeString(match))
+		sb.WriteString(`</a>`)
+		last = end
+	}
+	sb.WriteString(template.HTMLEscapeString(s[last:]))
+
+	return template.HTML(sb.String())
+}
+
+// isPhone tells the phone numbers from the dates and versions matched by the
+// phone pattern. A number with a leading + is always a phone number.
+func isPhone(s string) bool {
+	if strings.HasPrefix(s, "+") {
+		return true
+	}
+
+	if datePrefix.MatchString(s) {
+		return false
+	}
+	return !strings.Contains(s, ".") || dottedPhone.MatchString(s)
+}
+
+// phoneDigits keeps the digits and the leading + of a phone number.
+func phoneDigits(s string) string {
+	var sb strings.Builder
+	for i, r := range s {
+		if (r >= '0' && r <= '9') || (r == '+' && i == 0) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// Nl2br escapes a plain text and converts its line breaks to <br> tags, i.e.
+// for comments or addresses typed in a textarea:
+//
+//	<p>{{ nl2br .Data.Comment }}</p>
+func Nl2br(s string) template.HTML {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = template.HTMLEscapeString(s)
+	return template.HTML(strings.ReplaceAll(s, "\n", "<br>\n"))
+}
+
+// Highlight escapes a plain text and wraps the case-insensitive matches of
+// the words of a query in <mark> tags, for search results pages:
+//
+//	<p>{{ highlight .Title $.Data.Query }}</p>
+//
+// The longest words are matched first, the text is only escaped when the query
+// is empty.
+func Highlight(text, query string) template.HTML {
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return template.HTML(template.HTMLEscapeString(text))
+	}
+
+	sort.Slice(words, func(i, j int) bool { return len(words[i]) > len(words[j]) })
+	for i, w := range words {
+		words[i] = regexp.QuoteMeta(w)
+	}
+	re := regexp.MustCompile("(?i)" + strings.Join(words, "|"))
+
+	var sb strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringIndex(text, -1) {
+		sb.WriteString(template.HTMLEscapeString(text[last:m[0]]))
+		sb.WriteString("<mark>")
+		sb.WriteString(template.HTMLEscapeString(text[m[0]:m[1]]))
+		sb.WriteString("</mark>")
+		last = m[1]
+	}
+	sb.WriteString(template.HTMLEscapeString(text[last:]))
+	return template.HTML(sb.String())
+}
+
+// StripTags removes the markup of an HTML text and returns its text, i.e. for
+// an excerpt or the plain text of an email. The content of the script and
+// style elements is removed and the entities are decoded, the result is
+// escaped when rendered:
+//
+//	<meta name="description" content="{{ striptags .Data.Body }}">
+func StripTags(s string) string {
+	var sb strings.Builder
+
+	z := html.NewTokenizer(strings.NewReader(s))
+	skip := ""
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return sb.String()
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			if tag := string(name); tag == "script" || tag == "style" {
+				skip = tag
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if string(name) == skip {
+				skip = ""
+			}
+		case html.TextToken:
+			if skip == "" {
+				sb.Write(z.Text())
+			}
+		}
+	}
+}
+
+// WordWrap wraps a plain text at width characters, i.e. for the body of a
+// plain text email. Lines are broken between words, the words longer than
+// width are kept whole, and the existing line breaks are kept:
+//
+//	{{ wordwrap 72 .Data.Body }}
+func WordWrap(width int, s string) string {
+	if width <= 0 {
+		return s
+	}
+
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		var sb strings.Builder
+		n := 0
+		for _, word := range strings.Fields(line) {
+			l := utf8.RuneCountInString(word)
+			if n > 0 && n+1+l > width {
+				sb.WriteByte('\n')
+				n = 0
+			} else if n > 0 {
+				sb.WriteByte(' ')
+				n++
+			}
+			sb.WriteString(word)
+			n += l
+		}
+		lines[i] = sb.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// excerptLength is the length of an excerpt, the one of a meta description.
+const excerptLength = 160
+
+var entityEnd = regexp.MustCompile(`&#?[0-9A-Za-z]+;$`)
+
+// Truncate cuts a text to at most n characters at a word boundary and appends
+// an ellipsis, i.e. for card previews:
+//
+//	{{ truncate 80 .Data.Title }}
+//
+// An HTML entity like &amp; counts as one character and is never split. A
+// word longer than n is cut.
+func Truncate(n int, s string) string {
+	if n <= 0 {
+		return ""
+	}
+
+	// ends holds the byte offset after each character, entities included
+	var ends []int
+	for i := 0; i < len(s); {
+		size := 1
+		if s[i] == '&' {
+			if semi := strings.IndexByte(s[i:min(len(s), i+12)], ';'); semi > 1 && !strings.ContainsAny(s[i+1:i+semi], " &") {
+				size = semi + 1
+			}
+		}
+		if size == 1 {
+			_, size = utf8.DecodeRuneInString(s[i:])
+		}
+		i += size
+		ends = append(ends, i)
+
+		if len(ends) > n {
+			break
+		}
+	}
+
+	if len(ends) <= n {
+		return s
+	}
+
+	cut := ends[n-1]
+	if !atWordBoundary(s[cut:]) {
+		if i := strings.LastIndexFunc(s[:cut], unicode.IsSpace); i > 0 {
+			cut = i
+		}
+	}
+
+	t := s[:cut]
+	for len(t) > 0 && !entityEnd.MatchString(t) {
+		r, size := utf8.DecodeLastRuneInString(t)
+		if !unicode.IsSpace(r) && !unicode.IsPunct(r) {
+			break
+		}
+		t = t[:len(t)-size]
+	}
+	return t + "…"
+}
+
+// atWordBoundary returns whether the rest of a text starts between words.
+func atWordBoundary(rest string) bool {
+	r, _ := utf8.DecodeRuneInString(rest)
+	return unicode.IsSpace(r) || (unicode.IsPunct(r) && r != '&')
+}
+
+// Excerpt returns the plain text of an HTML content, its whitespace collapsed
+// and truncated at a word boundary to n characters, 160 by default, i.e. for
+// list pages or meta descriptions:
+//
+//	<p>{{ excerpt .Data.Body }}</p>
+//	<meta name="description" content="{{ excerpt .Data.Body 120 }}">
+func Excerpt(s string, n ...int) string {
+	length := excerptLength
+	if len(n) > 0 {
+		length = n[0]
+	}
+
+	text := strings.Join(strings.Fields(StripTags(s)), " ")
+	return Truncate(length, text)
+}
@@ -0,0 +1,60 @@
+package tpl
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// locations caches the loaded IANA time zones, nil for the unknown ones.
+var locations sync.Map
+
+// loadLocation returns the IANA time zone of a name, i.e. America/Toronto.
+// An empty or unknown name uses the Timezone option, and nil is returned when
+// there's no zone to convert to.
+func loadLocation(name string) *time.Location {
+	if len(name) == 0 {
+		name = config.Timezone
+	}
+	if len(name) == 0 {
+		return nil
+	}
+
+	if loc, ok := locations.Load(name); ok {
+		return fallbackLocation(name, loc.(*time.Location))
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		// the unknown zones are cached as nil, they're logged once
+		slog.Warn("loading time zone", "name", name, "ERR", err)
+		loc = nil
+	}
+
+	locations.Store(name, loc)
+	return fallbackLocation(name, loc)
+}
+
+// fallbackLocation returns the Timezone option for an unknown zone.
+func fallbackLocation(name string, loc *time.Location) *time.Location {
+	if loc == nil && name != config.Timezone {
+		return loadLocation(config.Timezone)
+	}
+	return loc
+}
+
+// inZone converts a time to the IANA time zone of a name, see loadLocation.
+func inZone(t time.Time, name string) time.Time {
+	if loc := loadLocation(name); loc != nil {
+		return t.In(loc)
+	}
+	return t
+}
+
+// LocalTime converts a time to the viewer's time zone, the Timezone of the
+// PageData, falling back to the Timezone option, before it's formatted:
+//
+//	{{ datetime .Locale (localtime . .Data.CreatedAt) "short" }}
+func LocalTime(data PageData, t time.Time) time.Time {
+	return inZone(t, data.Timezone)
+}
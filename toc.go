@@ -0,0 +1,148 @@
+package tpl
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Heading is an entry of a table of contents, an h2 with its h3 Children.
+type Heading struct {
+	ID       string
+	Text     string
+	Level    int
+	Children []Heading
+}
+
+// TOC returns the table of contents of a content, its h2 and h3 headings, to
+// build the sidebar of a documentation page:
+//
+//	<nav>
+//	{{ range toc .Data.Body }}
+//		<a href="#{{ .ID }}">{{ .Text }}</a>
+//		{{ range .Children }}<a href="#{{ .ID }}">{{ .Text }}</a>{{ end }}
+//	{{ end }}
+//	</nav>
+//
+// A template.HTML is rendered HTML and a string is Markdown. The headings
+// without an id get the one a markdown view generates, i.e. "getting-started"
+// for Getting started, add it to your HTML headings for the links to work.
+func TOC(content any) []Heading {
+	var src string
+	switch v := content.(type) {
+	case template.HTML:
+		src = string(v)
+	case string:
+		var buf bytes.Buffer
+		if err := viewRenderer.Convert([]byte(v), &buf); err != nil {
+			return nil
+		}
+		src = buf.String()
+	default:
+		return nil
+	}
+
+	ids := make(map[string]bool)
+	var headings []Heading
+	var cur *Heading
+
+	z := html.NewTokenizer(strings.NewReader(src))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+
+		if tt == html.EndTagToken && cur != nil {
+			if name, _ := z.TagName(); headingLevel(string(name)) == cur.Level {
+				headings = appendHeading(headings, cur, ids)
+				cur = nil
+			}
+			continue
+		}
+
+		if tt == html.TextToken && cur != nil {
+			cur.Text += string(z.Text())
+			continue
+		}
+
+		if tt != html.StartTagToken {
+			continue
+		}
+
+		name, hasAttr := z.TagName()
+		var id string
+		for hasAttr {
+			var k, v []byte
+			k, v, hasAttr = z.TagAttr()
+			if string(k) == "id" {
+				id = string(v)
+				ids[id] = true
+			}
+		}
+
+		if level := headingLevel(string(name)); level == 2 || level == 3 {
+			cur = &Heading{ID: id, Level: level}
+		}
+	}
+	return headings
+}
+
+// appendHeading adds an h2 to the headings or an h3 to the last h2, the id
+// generated if it has none.
+func appendHeading(headings []Heading, h *Heading, ids map[string]bool) []Heading {
+	h.Text = strings.Join(strings.Fields(h.Text), " ")
+	if len(h.ID) == 0 {
+		h.ID = headingID(h.Text, ids)
+	}
+
+	if h.Level == 3 && len(headings) > 0 && headings[len(headings)-1].Level == 2 {
+		last := &headings[len(headings)-1]
+		last.Children = append(last.Children, *h)
+		return headings
+	}
+	return append(headings, *h)
+}
+
+func headingLevel(tag string) int {
+	if len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6' {
+		return int(tag[1] - '0')
+	}
+	return 0
+}
+
+// headingID returns the id goldmark generates for a heading: its lower-cased
+// ASCII letters and digits, the spaces and dashes as dashes, and a number
+// suffix when it's already used.
+func headingID(text string, ids map[string]bool) string {
+	var sb strings.Builder
+	for _, r := range text {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			sb.WriteRune(r + 'a' - 'A')
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			sb.WriteRune(r)
+		case r == ' ' || r == '-' || r == '_':
+			sb.WriteByte('-')
+		}
+	}
+
+	id := sb.String()
+	if len(id) == 0 {
+		id = "heading"
+	}
+
+	if ids[id] {
+		for i := 1; ; i++ {
+			if next := fmt.Sprintf("%s-%d", id, i); !ids[next] {
+				id = next
+				break
+			}
+		}
+	}
+	ids[id] = true
+	return id
+}
@@ -0,0 +1,101 @@
+package tplcmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/dstpierre/tpl"
+)
+
+func runCompare(args []string, out output, cfg Config) error {
+	fset := out.flags("compare")
+	if err := fset.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if fset.NArg() != 2 {
+		fmt.Fprintln(out.stderr, "tpl compare: needs the before and after renders, files or directories")
+		return errUsage
+	}
+
+	before, after := fset.Arg(0), fset.Arg(1)
+	files, err := comparedFiles(before, after)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for _, rel := range files {
+		a, errA := os.ReadFile(filepath.Join(before, rel))
+		b, errB := os.ReadFile(filepath.Join(after, rel))
+		switch {
+		case errA != nil && errB != nil:
+			return errA
+		case errA != nil:
+			fmt.Fprintf(out.stdout, "%s: added\n", rel)
+			changed = true
+			continue
+		case errB != nil:
+			fmt.Fprintf(out.stdout, "%s: removed\n", rel)
+			changed = true
+			continue
+		}
+
+		report, err := tpl.DiffRenders(a, b)
+		if err != nil {
+			return fmt.Errorf("%s: %w", rel, err)
+		}
+
+		if !report.Equal() {
+			changed = true
+			fmt.Fprintf(out.stdout, "%s:\n", rel)
+			for _, c := range report.Changes {
+				fmt.Fprintf(out.stdout, "\t%s\n", c)
+			}
+		}
+	}
+
+	if changed {
+		return errFailed
+	}
+	return nil
+}
+
+// comparedFiles returns the files to compare relative to before and after,
+// "." for two files, or the union of the files of two directories.
+func comparedFiles(before, after string) ([]string, error) {
+	info, err := os.Stat(before)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return []string{"."}, nil
+	}
+
+	seen := make(map[string]bool)
+	for _, dir := range []string{before, after} {
+		err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+
+			rel, err := filepath.Rel(dir, p)
+			seen[rel] = true
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var files []string
+	for rel := range seen {
+		files = append(files, rel)
+	}
+	sort.Strings(files)
+	return files, nil
+}
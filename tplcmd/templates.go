@@ -0,0 +1,166 @@
+package tplcmd
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"text/template/parse"
+
+	"github.com/dstpierre/tpl"
+)
+
+// builtins are the funcs of text/template.
+var builtins = []string{
+	"and", "or", "not", "len", "index", "slice", "print", "printf", "println",
+	"html", "js", "urlquery", "call", "eq", "ne", "lt", "le", "gt", "ge",
+}
+
+// templateFlags holds where the templates of the program are.
+type templateFlags struct {
+	dir  string
+	root string
+}
+
+func (tf *templateFlags) register(fset *flag.FlagSet) {
+	fset.StringVar(&tf.dir, "dir", ".", "directory of the program")
+	fset.StringVar(&tf.root, "root", "templates", "templates directory, relative to -dir, like the TemplateRootName option")
+}
+
+// parse parses the templates with the options set by the command. The funcs
+// that are neither tpl's nor in the FuncMap of the Config are replaced by
+// stubs failing when they're executed, so the templates can be inspected
+// without them. It returns the names of the stubs.
+func (tf templateFlags) parse(opts tpl.Option, cfg Config) (*tpl.Template, map[string]bool, error) {
+	opts.TemplateRootName = tf.root
+	tpl.Set(opts)
+
+	fsys := os.DirFS(tf.dir)
+
+	funcs := make(map[string]any)
+	for name, fn := range cfg.FuncMap {
+		funcs[name] = fn
+	}
+
+	known := tpl.FuncMap()
+	for _, name := range builtins {
+		known[name] = nil
+	}
+	for name := range funcs {
+		known[name] = nil
+	}
+
+	stubs, err := unknownFuncs(fsys, tf.root, known)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for name := range stubs {
+		funcs[name] = stub(name)
+	}
+
+	templ, err := tpl.ParseFS(fsys, funcs)
+	return templ, stubs, err
+}
+
+func stub(name string) func(...any) (string, error) {
+	return func(...any) (string, error) {
+		return "", fmt.Errorf("%s is not in the FuncMap of the tpl command", name)
+	}
+}
+
+// unknownFuncs returns the funcs called by the templates under root that
+// aren't known.
+func unknownFuncs(fsys fs.FS, root string, known map[string]any) (map[string]bool, error) {
+	unknown := make(map[string]bool)
+	err := fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if name := d.Name(); p != root && (name == "translations" || name == "baked") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if ext := strings.ToLower(path.Ext(p)); ext == ".md" || ext == ".json" {
+			return nil
+		}
+
+		b, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+
+		t := parse.New(p)
+		t.Mode = parse.SkipFuncCheck
+		trees := make(map[string]*parse.Tree)
+		if _, err := t.Parse(string(b), "", "", trees); err != nil {
+			return err
+		}
+
+		for _, tree := range trees {
+			walkNodes(tree.Root, func(n parse.Node) {
+				if id, ok := n.(*parse.IdentifierNode); ok {
+					if _, found := known[id.Ident]; !found {
+						unknown[id.Ident] = true
+					}
+				}
+			})
+		}
+		return nil
+	})
+	return unknown, err
+}
+
+// walkNodes calls fn for n and every node beneath it.
+func walkNodes(n parse.Node, fn func(parse.Node)) {
+	if n == nil {
+		return
+	}
+
+	fn(n)
+
+	switch x := n.(type) {
+	case *parse.ListNode:
+		if x == nil {
+			return
+		}
+		for _, c := range x.Nodes {
+			walkNodes(c, fn)
+		}
+	case *parse.ActionNode:
+		walkNodes(x.Pipe, fn)
+	case *parse.PipeNode:
+		if x == nil {
+			return
+		}
+		for _, c := range x.Cmds {
+			walkNodes(c, fn)
+		}
+	case *parse.CommandNode:
+		for _, a := range x.Args {
+			walkNodes(a, fn)
+		}
+	case *parse.ChainNode:
+		walkNodes(x.Node, fn)
+	case *parse.IfNode:
+		walkBranch(&x.BranchNode, fn)
+	case *parse.RangeNode:
+		walkBranch(&x.BranchNode, fn)
+	case *parse.WithNode:
+		walkBranch(&x.BranchNode, fn)
+	case *parse.TemplateNode:
+		walkNodes(x.Pipe, fn)
+	}
+}
+
+func walkBranch(b *parse.BranchNode, fn func(parse.Node)) {
+	walkNodes(b.Pipe, fn)
+	walkNodes(b.List, fn)
+	walkNodes(b.ElseList, fn)
+}
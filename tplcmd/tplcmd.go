@@ -0,0 +1,215 @@
+// Package tplcmd implements the tpl command, which works on the templates and
+// the Go sources of a program:
+//
+//	go run github.com/dstpierre/tpl/cmd/tpl routes .
+//
+// A program with its own funcs or lint rules runs the commands from a small
+// main package of its own, so its templates parse with its funcs and tpl lint
+// runs its rules:
+//
+//	func main() {
+//		tplcmd.Main(tplcmd.Config{FuncMap: app.FuncMap(), LintRules: app.LintRules})
+//	}
+package tplcmd
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dstpierre/tpl"
+)
+
+// Config holds what the commands can't find in the files of a program.
+type Config struct {
+	// FuncMap holds the funcs of your program passed to tpl.Parse.
+	FuncMap map[string]any
+	// LintRules are the rules of tpl lint, with the ones registered via
+	// tpl.RegisterLintRule.
+	LintRules []tpl.LintRule
+}
+
+// command is a sub command of tpl.
+type command struct {
+	usage string
+	help  string
+	run   func(args []string, out output, cfg Config) error
+}
+
+var commands = map[string]command{
+	"bake":     {"bake [-dir .] [-root templates] view...", "renders the static views in every language to the baked directory of the templates", runBake},
+	"compare":  {"compare before after", "compares the markup of two renders, or of the files of two directories, and fails if it changed", runCompare},
+	"lint":     {"lint [-dir .] [-root templates]", "runs the lint rules on the templates and fails if they report issues", runLint},
+	"lsp-data": {"lsp-data [-dir .] [-root templates]", "prints the JSON completion data of the templates for editor plugins", runLSPData},
+	"routes":   {"routes [dir]", "prints the JSON manifest of the routes of dir and the views they render", runRoutes},
+}
+
+// output holds where the commands write their results and their errors.
+type output struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// flags returns the flag set of a command, printing its errors to stderr.
+func (out output) flags(name string) *flag.FlagSet {
+	fset := flag.NewFlagSet("tpl "+name, flag.ContinueOnError)
+	fset.SetOutput(out.stderr)
+	return fset
+}
+
+// errFailed is returned by the commands that printed why they failed, i.e.
+// lint issues.
+var errFailed = errors.New("failed")
+
+// errUsage is returned when the arguments of a command are invalid, the flag
+// package printed why.
+var errUsage = errors.New("usage")
+
+// Main runs the command of os.Args and exits with its status.
+func Main(cfg Config) {
+	os.Exit(Run(os.Args[1:], os.Stdout, os.Stderr, cfg))
+}
+
+// Run runs the command of args and returns its exit status: 0 on success, 1
+// when it failed, and 2 for a usage error.
+func Run(args []string, stdout, stderr io.Writer, cfg Config) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "tpl: unknown command %s\n", args[0])
+		usage(stderr)
+		return 2
+	}
+
+	err := cmd.run(args[1:], output{stdout: stdout, stderr: stderr}, cfg)
+	if errors.Is(err, flag.ErrHelp) || errors.Is(err, errUsage) {
+		return 2
+	} else if errors.Is(err, errFailed) {
+		return 1
+	} else if err != nil {
+		fmt.Fprintf(stderr, "tpl %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString("usage: tpl <command> [arguments]\n\ncommands:\n")
+	for _, name := range names {
+		fmt.Fprintf(&sb, "  %s\n      %s\n", commands[name].usage, commands[name].help)
+	}
+	io.WriteString(w, sb.String())
+}
+
+func runRoutes(args []string, out output, cfg Config) error {
+	fset := out.flags("routes")
+	if err := fset.Parse(args); err != nil {
+		return errUsage
+	}
+
+	dir := "."
+	if fset.NArg() > 0 {
+		dir = fset.Arg(0)
+	}
+
+	routes, err := tpl.RouteManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	b, err := tpl.RouteManifestJSON(routes)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(out.stdout, "%s\n", b)
+	return err
+}
+
+func runBake(args []string, out output, cfg Config) error {
+	var tf templateFlags
+	fset := out.flags("bake")
+	tf.register(fset)
+	if err := fset.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if fset.NArg() == 0 {
+		fmt.Fprintln(out.stderr, "tpl bake: no views to bake, i.e. tpl bake app/landing.html")
+		return errUsage
+	}
+
+	templ, _, err := tf.parse(tpl.Option{StaticViews: fset.Args()}, cfg)
+	if err != nil {
+		return err
+	}
+
+	return templ.Bake(filepath.Join(tf.dir, filepath.FromSlash(tf.root)), tpl.PageData{})
+}
+
+func runLSPData(args []string, out output, cfg Config) error {
+	var tf templateFlags
+	fset := out.flags("lsp-data")
+	tf.register(fset)
+	if err := fset.Parse(args); err != nil {
+		return errUsage
+	}
+
+	templ, stubs, err := tf.parse(tpl.Option{}, cfg)
+	if err != nil {
+		return err
+	}
+
+	// the signature of the funcs of the program isn't known without them
+	data := templ.CompletionData()
+	for i, f := range data.Funcs {
+		if stubs[f.Name] {
+			data.Funcs[i].Signature = ""
+		}
+	}
+
+	enc := json.NewEncoder(out.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
+
+func runLint(args []string, out output, cfg Config) error {
+	var tf templateFlags
+	fset := out.flags("lint")
+	tf.register(fset)
+	if err := fset.Parse(args); err != nil {
+		return errUsage
+	}
+
+	templ, _, err := tf.parse(tpl.Option{LintRules: cfg.LintRules}, cfg)
+	if err != nil {
+		return err
+	}
+
+	issues := templ.Lint()
+	for _, issue := range issues {
+		fmt.Fprintln(out.stdout, issue)
+	}
+
+	if len(issues) > 0 {
+		return errFailed
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package tplcmd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"text/template/parse"
+
+	"github.com/dstpierre/tpl"
+	"github.com/dstpierre/tpl/tplcmd"
+)
+
+func run(t *testing.T, cfg tplcmd.Config, args ...string) (string, string, int) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	code := tplcmd.Run(args, &stdout, &stderr, cfg)
+	return stdout.String(), stderr.String(), code
+}
+
+func TestUsage(t *testing.T) {
+	if _, stderr, code := run(t, tplcmd.Config{}); code != 2 || len(stderr) == 0 {
+		t.Errorf("expected the usage and status 2, got %d %q", code, stderr)
+	}
+
+	if _, _, code := run(t, tplcmd.Config{}, "nope"); code != 2 {
+		t.Errorf("expected status 2 for an unknown command, got %d", code)
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	stdout, stderr, code := run(t, tplcmd.Config{}, "routes", "../testdata/routes")
+	if code != 0 {
+		t.Fatalf("unexpected status %d: %s", code, stderr)
+	}
+
+	var routes []tpl.RouteView
+	if err := json.Unmarshal([]byte(stdout), &routes); err != nil {
+		t.Fatal(err)
+	} else if len(routes) == 0 || routes[0].View != "app/report.html" {
+		t.Errorf("unexpected routes %+v", routes)
+	}
+}
+
+// writeTemplates writes a templates directory with a landing page calling the
+// brand func of the program.
+func writeTemplates(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"templates/app.html":                      `<html>{{ block "content" . }}{{ end }}</html>`,
+		"templates/views/app/landing.html":        `{{ define "content" }}<h1>{{ t .Lang "title" }} {{ brand }}</h1>{{ end }}`,
+		"templates/translations/en.json":          `[{"key": "title", "value": "Welcome"}]`,
+		"templates/translations/fr.json":          `[{"key": "title", "value": "Bienvenue"}]`,
+		"templates/emails/verify_en.txt":          `Verify {{ .Link }}`,
+		"templates/_partials/unused.html":         `{{ define "unused" }}{{ end }}`,
+		"templates/views/app/terms.md":            "# Terms\n",
+		"templates/views/app/dashboard.html":      `{{ define "content" }}{{ .Data.Name }}{{ end }}`,
+		"templates/views/app/styled.html":         `{{ define "content" }}<p style="color: red">x</p>{{ end }}`,
+		"templates/views/app/dashboard.lite.html": `{{ define "content" }}{{ .Data.Name }}{{ end }}`,
+	}
+
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestBake(t *testing.T) {
+	if runtime.GOOS == "js" {
+		t.Skip("baking writes files")
+	}
+	defer tpl.Set(tpl.Option{})
+
+	dir := writeTemplates(t)
+
+	_, stderr, code := run(t, tplcmd.Config{}, "bake", "-dir", dir, "app/landing")
+	if code != 1 || !strings.Contains(stderr, "brand is not in the FuncMap") {
+		t.Errorf("expected the unknown func reported, got %d %q", code, stderr)
+	}
+
+	cfg := tplcmd.Config{FuncMap: map[string]any{"brand": func() string { return "Acme" }}}
+	if _, stderr, code := run(t, cfg, "bake", "-dir", dir, "app/landing"); code != 0 {
+		t.Fatalf("unexpected status %d: %s", code, stderr)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "templates", "baked", "fr", "app", "landing.html"))
+	if err != nil {
+		t.Fatal(err)
+	} else if string(b) != "<html><h1>Bienvenue Acme</h1></html>" {
+		t.Errorf("unexpected baked view %q", b)
+	}
+
+	if _, _, code := run(t, cfg, "bake", "-dir", dir); code != 2 {
+		t.Errorf("expected a usage error without views, got %d", code)
+	}
+}
+
+func TestLSPData(t *testing.T) {
+	defer tpl.Set(tpl.Option{})
+
+	dir := writeTemplates(t)
+
+	stdout, stderr, code := run(t, tplcmd.Config{}, "lsp-data", "-dir", dir)
+	if code != 0 {
+		t.Fatalf("unexpected status %d: %s", code, stderr)
+	}
+
+	var data tpl.CompletionData
+	if err := json.Unmarshal([]byte(stdout), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Contains(data.Views, "app/landing.html") || !slices.Contains(data.TranslationKeys, "title") {
+		t.Errorf("expected the views and translation keys, got %+v", data)
+	}
+
+	i := slices.IndexFunc(data.Funcs, func(f tpl.CompletionFunc) bool { return f.Name == "brand" })
+	if i < 0 || len(data.Funcs[i].Signature) > 0 {
+		t.Errorf("expected the brand func without a signature, got %+v", data.Funcs)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	before, after := t.TempDir(), t.TempDir()
+	files := map[string]string{
+		filepath.Join(before, "same.html"):    `<p class="a b">Hi</p>`,
+		filepath.Join(after, "same.html"):     "<p class=\"b a\">\n  Hi\n</p>",
+		filepath.Join(before, "changed.html"): `<p>Hi</p>`,
+		filepath.Join(after, "changed.html"):  `<p>Hello</p>`,
+		filepath.Join(after, "new.html"):      `<p>New</p>`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if stdout, _, code := run(t, tplcmd.Config{}, "compare", filepath.Join(before, "same.html"), filepath.Join(after, "same.html")); code != 0 {
+		t.Errorf("expected the same markup, got %d %s", code, stdout)
+	}
+
+	stdout, stderr, code := run(t, tplcmd.Config{}, "compare", before, after)
+	if code != 1 {
+		t.Fatalf("expected status 1, got %d %s", code, stderr)
+	}
+
+	if !strings.Contains(stdout, "changed.html:\n\t") || !strings.Contains(stdout, `"Hi" -> "Hello"`) {
+		t.Errorf("expected the changed text, got %s", stdout)
+	} else if !strings.Contains(stdout, "new.html: added") || strings.Contains(stdout, "same.html") {
+		t.Errorf("expected the added file only, got %s", stdout)
+	}
+}
+
+type noStyleRule struct{}
+
+func (noStyleRule) Name() string { return "no-inline-style" }
+
+func (r noStyleRule) Check(tree *parse.Tree, file tpl.LintFile) []tpl.LintIssue {
+	var issues []tpl.LintIssue
+	for _, n := range tree.Root.Nodes {
+		if text, ok := n.(*parse.TextNode); ok && strings.Contains(string(text.Text), "style=") {
+			issues = append(issues, tpl.Issue(r, tree, n, "use a class"))
+		}
+	}
+	return issues
+}
+
+func TestLint(t *testing.T) {
+	defer tpl.Set(tpl.Option{})
+
+	dir := writeTemplates(t)
+
+	if stdout, stderr, code := run(t, tplcmd.Config{}, "lint", "-dir", dir); code != 0 {
+		t.Errorf("expected no issues without rules, got %d %s %s", code, stdout, stderr)
+	}
+
+	cfg := tplcmd.Config{LintRules: []tpl.LintRule{noStyleRule{}}}
+	stdout, stderr, code := run(t, cfg, "lint", "-dir", dir)
+	if code != 1 {
+		t.Fatalf("expected status 1, got %d %s", code, stderr)
+	} else if stdout != "styled.html:1: use a class (no-inline-style)\n" {
+		t.Errorf("unexpected issues %q", stdout)
+	}
+}
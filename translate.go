@@ -1,91 +1,424 @@
 package tpl
 
 import (
-	"embed"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"log/slog"
+	"math"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
 type Text struct {
 	Key         string `json:"key"`
 	Value       string `json:"value"`
 	PluralValue string `json:"plural"`
+
+	// Plurals holds values selected by number, each key is either an exact
+	// number "0", an inclusive range "2..4", an open range "5..", or "other".
+	// Overlapping ranges are tried from the lowest lower bound.
+	Plurals map[string]string `json:"plurals,omitempty"`
+
+	// Variants holds select forms of the value, e.g. male, female, neutral.
+	Variants map[string]string `json:"variants,omitempty"`
+
+	// Description explains to translators where and how the text is used.
+	Description string `json:"description,omitempty"`
+	// Comment is a free-form note for translators, e.g. a length limit.
+	Comment string `json:"comment,omitempty"`
 }
 
-var messages map[string]Text
+// messages holds the catalog, the texts of each language by key.
+var messages map[string]map[string]Text
+
+// languages holds the languages of the loaded translation files.
+var languages []string
 
-func loadTranslations(fs embed.FS) error {
-	messages = make(map[string]Text)
+// catalogMu guards messages and languages, translations can be added at
+// runtime via AddTranslations.
+var catalogMu sync.RWMutex
 
-	files, err := load(fs, config.TemplateRootName, "translations")
+// loadTranslations replaces the catalog with the translation files under
+// root and returns their texts by language.
+func loadTranslations(fsys fs.FS, root string) (map[string][]Text, error) {
+	catalogMu.Lock()
+	messages = make(map[string]map[string]Text)
+	languages = nil
+	catalogMu.Unlock()
+
+	translations, err := readTranslations(fsys, root)
+	if err != nil {
+		return nil, err
+	}
+
+	langs := make([]string, 0, len(translations))
+	for lang := range translations {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	for _, lang := range langs {
+		msgs := translations[lang]
+
+		catalogMu.Lock()
+		fillTranslations(lang, msgs)
+		catalogMu.Unlock()
+
+		audit(context.Background(), AuditTranslationsLoad, lang, 0, textKeys(msgs))
+	}
+
+	return translations, nil
+}
+
+// readTranslations returns the texts of the translation files under root by
+// language, without adding them to the catalog.
+func readTranslations(fsys fs.FS, root string) (map[string][]Text, error) {
+	files, err := load(fsys, root, "translations")
 	if err != nil {
 		slog.Warn("loading translation files", "ERR", err)
-		return nil
+		return nil, nil
 	}
 
+	translations := make(map[string][]Text)
 	for _, file := range files {
 		var msgs []Text
-		b, err := fs.ReadFile(file.fullPath)
+		b, err := fs.ReadFile(fsys, file.fullPath)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		if err := json.Unmarshal(b, &msgs); err != nil {
-			return err
+			return nil, err
 		}
 
-		fillTranslations(file.name, msgs)
+		lang := strings.TrimSuffix(file.name, filepath.Ext(file.name))
+		translations[lang] = msgs
+	}
+
+	return translations, nil
+}
+
+// AddTranslations adds or replaces translations of a language at runtime,
+// i.e. after a translator edited them in your app.
+func AddTranslations(lang string, msgs []Text) {
+	AddTranslationsContext(context.Background(), lang, msgs)
+}
+
+// AddTranslationsContext is like AddTranslations, the actor set on the
+// context via WithActor is passed to the AuditSink option.
+func AddTranslationsContext(ctx context.Context, lang string, msgs []Text) {
+	catalogMu.Lock()
+	if messages == nil {
+		messages = make(map[string]map[string]Text)
 	}
+	fillTranslations(lang, msgs)
+	catalogMu.Unlock()
 
-	return nil
+	audit(ctx, AuditTranslationsAdd, lang, 0, textKeys(msgs))
 }
 
-func fillTranslations(name string, msgs []Text) {
-	lang := strings.TrimSuffix(name, filepath.Ext(name))
+// fillTranslations adds the messages of a language to the catalog, callers
+// must hold catalogMu.
+//
+// The texts of the language are copied rather than modified, a Translator
+// reads the ones it was bound to without locking.
+func fillTranslations(lang string, msgs []Text) {
+	if i := sort.SearchStrings(languages, lang); i == len(languages) || languages[i] != lang {
+		languages = append(languages, "")
+		copy(languages[i+1:], languages[i:])
+		languages[i] = lang
+	}
+
+	texts := make(map[string]Text, len(messages[lang])+len(msgs))
+	for k, v := range messages[lang] {
+		texts[k] = v
+	}
 
 	for _, msg := range msgs {
-		key := fmt.Sprintf("%s_%s", lang, msg.Key)
-		messages[key] = msg
+		texts[msg.Key] = msg
 	}
+	messages[lang] = texts
 }
 
 // GetMessageFromKey returns the Text structure for a giving language and key.
+//
+// If the key is missing and the language has Fallbacks in the Languages
+// registry, the fallback languages are used in order.
 func GetMessageFromKey(lang, key string) Text {
-	k := fmt.Sprintf("%s_%s", lang, key)
+	if v, ok := findMessage(lang, key); ok {
+		return v
+	}
+
+	return Text{Key: key, Value: "not found"}
+}
 
-	v, ok := messages[k]
-	if !ok {
-		return Text{Key: key, Value: "not found"}
+// findMessage looks up a key in a language, then in its fallback languages.
+func findMessage(lang, key string) (Text, bool) {
+	if v, ok := lookupMessage(lang, key); ok {
+		return v, true
+	}
+
+	if lc, found := languageConfig(lang); found {
+		for _, fb := range lc.Fallbacks {
+			if v, ok := lookupMessage(fb, key); ok {
+				return v, true
+			}
+		}
 	}
 
-	return v
+	return Text{}, false
+}
+
+func lookupMessage(lang, key string) (Text, bool) {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+
+	v, ok := messages[lang][key]
+	return v, ok
 }
 
 // Translate returns the proper value based on language and key.
-func Translate(lang, key string) string {
-	return GetMessageFromKey(lang, key).Value
+//
+// The value may contain named placeholders like {name} that are replaced by
+// the matching entry of an optional map argument:
+//
+//	{{ t .Lang "welcome" (map "name" .Data.Name) }}
+func Translate(lang, key string, args ...map[string]any) string {
+	return present(key, replacePlaceholders(localize(GetMessageFromKey(lang, key).Value), args))
 }
 
 // TranslatePlural returns the proper version based on language, key, and number
-func TranslatePlural(lang, key string, num int64) string {
+func TranslatePlural(lang, key string, num int64, args ...map[string]any) string {
+	msg := GetMessageFromKey(lang, key)
+	return present(key, replacePlaceholders(localize(msg.pluralValue(lang, num)), args))
+}
+
+// TranslateSelect returns the variant of the value for a language and key,
+// falling back to the "other" variant, then to the value.
+//
+//	{{ tselect .Lang "invited_you" .Data.Gender }}
+func TranslateSelect(lang, key, variant string, args ...map[string]any) string {
 	msg := GetMessageFromKey(lang, key)
-	if num > 1 && len(msg.PluralValue) > 0 {
-		return msg.PluralValue
+	if v, ok := msg.Variants[variant]; ok {
+		return present(key, replacePlaceholders(localize(v), args))
 	}
-	return msg.Value
+	if v, ok := msg.Variants["other"]; ok {
+		return present(key, replacePlaceholders(localize(v), args))
+	}
+	return present(key, replacePlaceholders(localize(msg.Value), args))
 }
 
 // TranslateFormat returns the formatted text based on language and key
 func TranslateFormat(lang, key string, values []any) string {
-	return fmt.Sprintf(GetMessageFromKey(lang, key).Value, values...)
+	return present(key, fmt.Sprintf(localize(GetMessageFromKey(lang, key).Value), values...))
 }
 
 // TranslateFormatPlural returns the proper formatted text based on language,
 // key, and number.
 func TranslateFormatPlural(lang, key string, num int64, values []any) string {
-	s := TranslatePlural(lang, key, num)
-	return fmt.Sprintf(s, values...)
+	s := GetMessageFromKey(lang, key).pluralValue(lang, num)
+	return present(key, fmt.Sprintf(localize(s), values...))
+}
+
+// pluralValue returns the value to use for a number.
+func (t Text) pluralValue(lang string, num int64) string {
+	if v, ok := t.pluralRange(num); ok {
+		return v
+	}
+	if isPlural(lang, num) && len(t.PluralValue) > 0 {
+		return t.PluralValue
+	}
+	return t.Value
+}
+
+// localize applies the options on the catalog value of a key before its
+// placeholders are substituted, so pseudo-localization leaves the values of
+// the arguments as they are.
+func localize(s string) string {
+	if config.PseudoLocalize {
+		s = pseudoAccent(s)
+	}
+	return s
+}
+
+// present applies the output options, like pseudo-localization, to the
+// translated text of a key.
+func present(key, s string) string {
+	if config.PseudoLocalize {
+		s = pseudoPad(s)
+	}
+	if config.DevMode && config.ShowTranslationKeys {
+		s += " [" + key + "]"
+	}
+	return s
+}
+
+var pseudoAccents = map[rune]rune{
+	'a': 'á', 'b': 'ƀ', 'c': 'ç', 'd': 'ð', 'e': 'é', 'f': 'ƒ', 'g': 'ĝ',
+	'h': 'ĥ', 'i': 'î', 'j': 'ĵ', 'k': 'ķ', 'l': 'ļ', 'm': 'ɱ', 'n': 'ñ',
+	'o': 'ö', 'p': 'þ', 'q': 'ǫ', 'r': 'ŕ', 's': 'š', 't': 'ţ', 'u': 'û',
+	'v': 'ṽ', 'w': 'ŵ', 'x': 'ẋ', 'y': 'ý', 'z': 'ž',
+	'A': 'Å', 'B': 'Ɓ', 'C': 'Ç', 'D': 'Ð', 'E': 'É', 'F': 'Ƒ', 'G': 'Ĝ',
+	'H': 'Ĥ', 'I': 'Î', 'J': 'Ĵ', 'K': 'Ķ', 'L': 'Ļ', 'M': 'Ṁ', 'N': 'Ñ',
+	'O': 'Ö', 'P': 'Þ', 'Q': 'Ǫ', 'R': 'Ŕ', 'S': 'Š', 'T': 'Ţ', 'U': 'Û',
+	'V': 'Ṽ', 'W': 'Ŵ', 'X': 'Ẋ', 'Y': 'Ý', 'Z': 'Ž',
+}
+
+// pseudoAccent accents the letters of a catalog value, except in its {name}
+// placeholders and fmt verbs.
+func pseudoAccent(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '{':
+			if loc := placeholder.FindStringIndex(s[i:]); loc != nil && loc[0] == 0 {
+				sb.WriteString(s[i : i+loc[1]])
+				i += loc[1]
+				continue
+			}
+		case '%':
+			j := i + 1
+			for j < len(s) && !isVerb(s[j]) {
+				j++
+			}
+			j = min(j+1, len(s))
+			sb.WriteString(s[i:j])
+			i = j
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		sb.WriteString(accentLetters(string(r)))
+		i += size
+	}
+	return sb.String()
+}
+
+// isVerb reports whether c ends a fmt verb.
+func isVerb(c byte) bool {
+	return c == '%' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// accentLetters replaces the ASCII letters of s by accented ones.
+func accentLetters(s string) string {
+	return strings.Map(func(r rune) rune {
+		if a, ok := pseudoAccents[r]; ok {
+			return a
+		}
+		return r
+	}, s)
+}
+
+// pseudoPad pads a pseudo-localized text by about a third of its length and
+// wraps it in brackets so truncation is easy to spot:
+//
+//	Hello world -> ⟦Ĥéļļö ŵöŕļð····⟧
+func pseudoPad(s string) string {
+	n := utf8.RuneCountInString(s)
+	return "⟦" + s + strings.Repeat("·", (n+2)/3) + "⟧"
+}
+
+// PluralRule reports whether the plural form is used for a number.
+type PluralRule func(num int64) bool
+
+var defaultPluralRules = map[string]PluralRule{
+	"en": func(num int64) bool { return num != 1 && num != -1 },
+	"fr": func(num int64) bool { return num >= 2 || num <= -2 },
+}
+
+// isPlural uses the plural rule of a language, English is used when there's
+// no rule for the language.
+func isPlural(lang string, num int64) bool {
+	if rule, ok := config.PluralRules[lang]; ok {
+		return rule(num)
+	}
+
+	if rule, ok := defaultPluralRules[lang]; ok {
+		return rule(num)
+	}
+
+	return defaultPluralRules["en"](num)
+}
+
+var placeholder = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// replacePlaceholders replaces the {name} placeholders found in s with the
+// values of the maps. Placeholders without a value are kept as-is.
+func replacePlaceholders(s string, args []map[string]any) string {
+	if len(args) == 0 || !strings.Contains(s, "{") {
+		return s
+	}
+
+	return placeholder.ReplaceAllStringFunc(s, func(m string) string {
+		name := m[1 : len(m)-1]
+		for _, a := range args {
+			if v, ok := a[name]; ok {
+				return fmt.Sprint(v)
+			}
+		}
+		return m
+	})
+}
+
+// pluralRange returns the value of the Plurals entry matching num.
+func (t Text) pluralRange(num int64) (string, bool) {
+	if len(t.Plurals) == 0 {
+		return "", false
+	}
+
+	if v, ok := t.Plurals[strconv.FormatInt(num, 10)]; ok {
+		return v, true
+	}
+
+	// the ranges are tried by their lower bound, an open one first, so
+	// "2..4" comes before "10..20"
+	type pluralBounds struct {
+		key      string
+		from, to int64
+	}
+
+	var ranges []pluralBounds
+	for k := range t.Plurals {
+		from, to, ok := strings.Cut(k, "..")
+		if !ok {
+			continue
+		}
+
+		r := pluralBounds{key: k, from: math.MinInt64, to: math.MaxInt64}
+		var err error
+		if len(from) > 0 {
+			if r.from, err = strconv.ParseInt(from, 10, 64); err != nil {
+				continue
+			}
+		}
+		if len(to) > 0 {
+			if r.to, err = strconv.ParseInt(to, 10, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, r)
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		if ranges[i].from != ranges[j].from {
+			return ranges[i].from < ranges[j].from
+		}
+		return ranges[i].key < ranges[j].key
+	})
+
+	for _, r := range ranges {
+		if num >= r.from && num <= r.to {
+			return t.Plurals[r.key], true
+		}
+	}
+
+	v, ok := t.Plurals["other"]
+	return v, ok
 }
@@ -0,0 +1,51 @@
+package tpl
+
+// Translator translates the keys of one language. Its texts, and the ones of
+// the language's Fallbacks, are resolved once, so translating in a loop
+// doesn't lock or look up the catalog of each language again, i.e. when
+// building thousands of rows of an export or a JSON response in Go:
+//
+//	tr := tpl.Bind(lang)
+//	for _, o := range orders {
+//		row := []string{o.ID, tr.T("status-" + o.Status)}
+//	}
+type Translator struct {
+	lang     string
+	catalogs []map[string]Text
+}
+
+// Bind returns the Translator of a language. Translations added afterward
+// via AddTranslations are not seen by it.
+func Bind(lang string) Translator {
+	tr := Translator{lang: lang}
+
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+
+	tr.catalogs = append(tr.catalogs, messages[lang])
+	if lc, found := languageConfig(lang); found {
+		for _, fb := range lc.Fallbacks {
+			tr.catalogs = append(tr.catalogs, messages[fb])
+		}
+	}
+	return tr
+}
+
+func (tr Translator) text(key string) Text {
+	for _, texts := range tr.catalogs {
+		if v, ok := texts[key]; ok {
+			return v
+		}
+	}
+	return Text{Key: key, Value: "not found"}
+}
+
+// T is like Translate for the language of the Translator.
+func (tr Translator) T(key string, args ...map[string]any) string {
+	return present(key, replacePlaceholders(localize(tr.text(key).Value), args))
+}
+
+// TP is like TranslatePlural for the language of the Translator.
+func (tr Translator) TP(key string, num int64, args ...map[string]any) string {
+	return present(key, replacePlaceholders(localize(tr.text(key).pluralValue(tr.lang, num)), args))
+}
@@ -0,0 +1,133 @@
+package tpl
+
+import (
+	"html/template"
+	"text/template/parse"
+)
+
+// usesFunc reports whether any template associated with t calls the named
+// function.
+func usesFunc(t *template.Template, name string) bool {
+	found := false
+	for _, at := range t.Templates() {
+		if at.Tree == nil {
+			continue
+		}
+
+		walkNodes(at.Tree.Root, func(n parse.Node) {
+			if id, ok := n.(*parse.IdentifierNode); ok && id.Ident == name {
+				found = true
+			}
+		})
+
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// walkNodes calls fn for n and every node beneath it.
+func walkNodes(n parse.Node, fn func(parse.Node)) {
+	if n == nil {
+		return
+	}
+
+	fn(n)
+
+	switch x := n.(type) {
+	case *parse.ListNode:
+		if x == nil {
+			return
+		}
+		for _, c := range x.Nodes {
+			walkNodes(c, fn)
+		}
+	case *parse.ActionNode:
+		walkNodes(x.Pipe, fn)
+	case *parse.PipeNode:
+		if x == nil {
+			return
+		}
+		for _, d := range x.Decl {
+			walkNodes(d, fn)
+		}
+		for _, c := range x.Cmds {
+			walkNodes(c, fn)
+		}
+	case *parse.CommandNode:
+		for _, a := range x.Args {
+			walkNodes(a, fn)
+		}
+	case *parse.ChainNode:
+		walkNodes(x.Node, fn)
+	case *parse.IfNode:
+		walkBranch(&x.BranchNode, fn)
+	case *parse.RangeNode:
+		walkBranch(&x.BranchNode, fn)
+	case *parse.WithNode:
+		walkBranch(&x.BranchNode, fn)
+	case *parse.TemplateNode:
+		walkNodes(x.Pipe, fn)
+	}
+}
+
+func walkBranch(b *parse.BranchNode, fn func(parse.Node)) {
+	walkNodes(b.Pipe, fn)
+	walkNodes(b.List, fn)
+	if b.ElseList != nil {
+		walkNodes(b.ElseList, fn)
+	}
+}
+
+// translationFuncs are the funcs taking a language and a key as their first
+// two arguments.
+var translationFuncs = map[string]bool{
+	"t": true, "tp": true, "tf": true, "tfp": true, "tm": true, "tselect": true,
+}
+
+// usedTranslationKeys returns the literal keys passed to the translation funcs
+// in the templates associated with t.
+func usedTranslationKeys(t *template.Template) []string {
+	var keys []string
+	for _, at := range t.Templates() {
+		if at.Tree == nil {
+			continue
+		}
+
+		walkNodes(at.Tree.Root, func(n parse.Node) {
+			cmd, ok := n.(*parse.CommandNode)
+			if !ok || len(cmd.Args) < 3 {
+				return
+			}
+
+			id, ok := cmd.Args[0].(*parse.IdentifierNode)
+			if !ok || !translationFuncs[id.Ident] {
+				return
+			}
+
+			if key, ok := cmd.Args[2].(*parse.StringNode); ok {
+				keys = append(keys, key.Text)
+			}
+		})
+	}
+	return keys
+}
+
+// missingTemplates returns the names of templates invoked via {{template}}
+// that are not defined.
+func missingTemplates(t *template.Template) []string {
+	var names []string
+	for _, at := range t.Templates() {
+		if at.Tree == nil {
+			continue
+		}
+
+		walkNodes(at.Tree.Root, func(n parse.Node) {
+			if tn, ok := n.(*parse.TemplateNode); ok && t.Lookup(tn.Name) == nil {
+				names = append(names, tn.Name)
+			}
+		})
+	}
+	return names
+}
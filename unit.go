@@ -0,0 +1,102 @@
+package tpl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// measureUnit is a unit of distance, weight, or temperature.
+type measureUnit struct {
+	symbol   string
+	imperial bool
+	// to is the unit of the other measurement system and convert the value
+	// to it.
+	to      string
+	convert func(float64) float64
+}
+
+var measureUnits = map[string]measureUnit{
+	"km": {symbol: "km", to: "mi", convert: func(v float64) float64 { return v / 1.609344 }},
+	"m":  {symbol: "m", to: "ft", convert: func(v float64) float64 { return v / 0.3048 }},
+	"cm": {symbol: "cm", to: "in", convert: func(v float64) float64 { return v / 2.54 }},
+	"kg": {symbol: "kg", to: "lb", convert: func(v float64) float64 { return v / 0.45359237 }},
+	"g":  {symbol: "g", to: "oz", convert: func(v float64) float64 { return v / 28.349523125 }},
+	"c":  {symbol: "°C", to: "f", convert: func(v float64) float64 { return v*9/5 + 32 }},
+
+	"mi": {symbol: "mi", imperial: true, to: "km", convert: func(v float64) float64 { return v * 1.609344 }},
+	"ft": {symbol: "ft", imperial: true, to: "m", convert: func(v float64) float64 { return v * 0.3048 }},
+	"in": {symbol: "in", imperial: true, to: "cm", convert: func(v float64) float64 { return v * 2.54 }},
+	"lb": {symbol: "lb", imperial: true, to: "kg", convert: func(v float64) float64 { return v * 0.45359237 }},
+	"oz": {symbol: "oz", imperial: true, to: "g", convert: func(v float64) float64 { return v * 28.349523125 }},
+	"f":  {symbol: "°F", imperial: true, to: "c", convert: func(v float64) float64 { return (v - 32) * 5 / 9 }},
+}
+
+var unitAliases = map[string]string{
+	"celsius":    "c",
+	"°c":         "c",
+	"fahrenheit": "f",
+	"°f":         "f",
+}
+
+// imperialRegions use the imperial units.
+var imperialRegions = map[string]bool{"US": true, "LR": true, "MM": true}
+
+// Unit formats a distance, weight, or temperature with the unit symbol and
+// the number format of the locale, e.g. 5 km, 12 lb, or 21 °C. The units are
+// km, m, cm, kg, g, c (celsius), mi, ft, in, lb, oz, and f (fahrenheit).
+//
+// With the "convert" option, the value is converted to the measurement
+// system of the locale's region, so 5 km is 3.1 mi in en-US:
+//
+//	{{ unit .Locale .Data.Distance "km" "convert" }}
+//
+// Up to 1 decimal is displayed, an optional number sets the exact number of
+// decimals.
+func Unit(locale string, v any, unit string, opts ...any) string {
+	f, ok := toFloat64(v)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+
+	unit = strings.ToLower(unit)
+	if alias, ok := unitAliases[unit]; ok {
+		unit = alias
+	}
+
+	mu, ok := measureUnits[unit]
+	if !ok {
+		return Number(locale, f) + " " + unit
+	}
+
+	decimals := -1
+	for _, o := range opts {
+		if o == "convert" {
+			if mu.imperial != usesImperial(locale) {
+				f = mu.convert(f)
+				mu = measureUnits[mu.to]
+			}
+		} else if d, ok := toFloat64(o); ok {
+			decimals = int(d)
+		}
+	}
+
+	num := Number(locale, f, 1)
+	if decimals >= 0 {
+		num = Number(locale, f, decimals)
+	} else {
+		num = strings.TrimSuffix(num, decimalSeparator(locale)+"0")
+	}
+
+	// English writes temperatures without a space, 21°C
+	if strings.HasPrefix(mu.symbol, "°") && localeLang(locale) == "en" {
+		return num + mu.symbol
+	}
+	return num + " " + mu.symbol
+}
+
+// usesImperial reports whether the region of a locale uses the imperial
+// units.
+func usesImperial(locale string) bool {
+	_, region, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	return imperialRegions[strings.ToUpper(region)]
+}
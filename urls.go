@@ -0,0 +1,85 @@
+package tpl
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// URLJoin appends path segments to a URL or a path, each segment escaped and
+// the slashes between them deduplicated:
+//
+//	<a href="{{ urljoin "/products" .Data.Category .Data.ID }}">
+//
+// The query string and fragment of the base are kept.
+func URLJoin(base string, segments ...any) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return base
+	}
+
+	p := strings.TrimRight(u.Path, "/")
+	for _, seg := range segments {
+		s := strings.Trim(fmt.Sprint(seg), "/")
+		if len(s) == 0 {
+			continue
+		}
+		p += "/" + url.PathEscape(s)
+	}
+
+	if len(p) == 0 && u.Host == "" {
+		p = "/"
+	}
+
+	u.RawPath = p
+	u.Path, _ = url.PathUnescape(p)
+	return u.String()
+}
+
+// QuerySet adds or replaces a query string parameter of a URL, the URL last
+// for pipelines, i.e. for pagination links and sort toggles:
+//
+//	<a href="{{ .CurrentURL | queryset "page" 2 }}">2</a>
+//
+// The parameters are sorted by key.
+func QuerySet(key string, value any, rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	q := u.Query()
+	q.Set(key, fmt.Sprint(value))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// QueryDel removes query string parameters of a URL, i.e. for the links
+// removing a filter chip:
+//
+//	<a href="{{ .CurrentURL | querydel "color" }}">×</a>
+func QueryDel(args ...string) string {
+	if len(args) == 0 {
+		return ""
+	}
+
+	rawURL := args[len(args)-1]
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	q := u.Query()
+	for _, key := range args[:len(args)-1] {
+		q.Del(key)
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// URLEncode escapes a value for a query string parameter:
+//
+//	<a href="/search?q={{ urlencode .Data.Query }}">
+func URLEncode(v any) string {
+	return url.QueryEscape(fmt.Sprint(v))
+}
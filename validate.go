@@ -0,0 +1,102 @@
+package tpl
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Validate executes a view without writing its output, to verify in health
+// checks and tests that it renders with representative data before deploy.
+//
+// The data can be a PageData or the value of its Data field. Unlike Render,
+// a missing map key and a missing translation are errors.
+func (templ *Template) Validate(view string, data any) error {
+	view, _, ok := templ.lookupView(view)
+	templ.mu.RLock()
+	sources := templ.sources[view]
+	templ.mu.RUnlock()
+	if !ok || len(sources) == 0 {
+		return errors.New("can't find view: " + view)
+	}
+
+	pd, ok := data.(PageData)
+	if !ok {
+		pd = PageData{Data: data}
+	}
+	pd.state = newRenderState()
+
+	missing := make(map[string]bool)
+
+	o := templ.origin(view)
+	fmap := make(map[string]any)
+	for k, v := range o.funcMap {
+		fmap[k] = v
+	}
+	addTranslationChecks(fmap, missing)
+
+	tv := template.New(path.Base(sources[0])).Funcs(fmap).Option("missingkey=error")
+	t, _, err := parsePatterns(tv, o.fsys, sources)
+	if err != nil {
+		return err
+	}
+
+	templ.applyFrontMatter(view, &pd)
+	pd.state = newRenderState()
+	defer pd.state.end()
+
+	if err := t.Execute(io.Discard, pd); err != nil {
+		return err
+	}
+
+	if len(missing) > 0 {
+		var keys []string
+		for k := range missing {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		return fmt.Errorf("missing translations in %s: %s", view, strings.Join(keys, ", "))
+	}
+
+	return nil
+}
+
+// addTranslationChecks replaces the translation functions with ones recording
+// the keys missing for the language.
+func addTranslationChecks(fmap map[string]any, missing map[string]bool) {
+	check := func(lang, key string) {
+		if _, ok := lookupMessage(lang, key); !ok {
+			missing[lang+":"+key] = true
+		}
+	}
+
+	fmap["t"] = func(lang, key string, args ...map[string]any) string {
+		check(lang, key)
+		return Translate(lang, key, args...)
+	}
+	fmap["tp"] = func(lang, key string, num int64, args ...map[string]any) string {
+		check(lang, key)
+		return TranslatePlural(lang, key, num, args...)
+	}
+	fmap["tf"] = func(lang, key string, values []any) string {
+		check(lang, key)
+		return TranslateFormat(lang, key, values)
+	}
+	fmap["tfp"] = func(lang, key string, num int64, values []any) string {
+		check(lang, key)
+		return TranslateFormatPlural(lang, key, num, values)
+	}
+	fmap["tm"] = func(lang, key string, args map[string]any) string {
+		check(lang, key)
+		return TranslateMessage(lang, key, args)
+	}
+	fmap["tselect"] = func(lang, key, variant string, args ...map[string]any) string {
+		check(lang, key)
+		return TranslateSelect(lang, key, variant, args...)
+	}
+}
@@ -0,0 +1,369 @@
+package tpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+// defaultKeepVersions is the number of versions kept per view or email when
+// the KeepVersions option is 0.
+const defaultKeepVersions = 10
+
+// RenderInfo describes a completed render, it's passed to the AfterRender
+// option.
+type RenderInfo struct {
+	// Name is the view or email name.
+	Name string
+	// Version is the version of the view or email that was rendered, 1 for
+	// the one loaded by Parse.
+	Version  int
+	Duration time.Duration
+	Err      error
+}
+
+// TemplateVersion is a parsed version of a view or an email.
+type TemplateVersion struct {
+	Version   int
+	CreatedAt time.Time
+	// Current is true for the version being rendered.
+	Current bool
+
+	t   *template.Template
+	src []byte
+}
+
+// versionHistory holds the kept versions of a view or an email, oldest
+// first.
+type versionHistory struct {
+	versions []TemplateVersion
+	current  int
+}
+
+func (h *versionHistory) last() int {
+	return h.versions[len(h.versions)-1].Version
+}
+
+// UpdateView parses a new version of a view from its source, i.e. after a
+// customer edited it, and renders it from now on. The layout and partials of
+// the view are reused. It returns the number of the new version.
+//
+// The last KeepVersions versions are kept so a broken edit can be reverted
+// via Rollback.
+func (templ *Template) UpdateView(name string, src []byte) (int, error) {
+	return templ.UpdateViewContext(context.Background(), name, src)
+}
+
+// UpdateViewContext is like UpdateView, the actor set on the context via
+// WithActor is passed to the AuditSink option.
+func (templ *Template) UpdateViewContext(ctx context.Context, name string, src []byte) (int, error) {
+	t, err := templ.parseView(name, src)
+	if err != nil {
+		return 0, err
+	}
+
+	templ.mu.Lock()
+	v := templ.addVersion(name, t, src)
+	templ.Views[name] = t
+	templ.resetCaches(name)
+	templ.setFrontMatter(name, src)
+	templ.mu.Unlock()
+
+	audit(ctx, AuditViewUpdate, name, v, nil)
+	return v, nil
+}
+
+// parseView parses the source of a view with the layout and partials of the
+// view.
+func (templ *Template) parseView(name string, src []byte) (*template.Template, error) {
+	templ.mu.RLock()
+	patterns := templ.sources[name]
+	sb, untrusted := templ.sandboxes[name]
+	o := templ.originLocked(name)
+	templ.mu.RUnlock()
+
+	if len(patterns) < 2 {
+		return nil, errors.New("can't find view: " + name)
+	}
+
+	i := viewFileIndex(name, patterns)
+	viewPath := patterns[i]
+	if untrusted {
+		if err := checkSandbox(viewPath, src, o.funcMap, sb); err != nil {
+			return nil, err
+		}
+	}
+
+	t, err := template.New(path.Base(patterns[0])).Funcs(o.funcMap).ParseFS(o.fsys, patterns[:i]...)
+	if err != nil {
+		return nil, err
+	}
+
+	if isMarkdown(viewPath) {
+		if _, err := addMarkdown(t, src, untrusted); err != nil {
+			return nil, err
+		}
+	} else if _, err := t.New(path.Base(viewPath)).Parse(string(src)); err != nil {
+		return nil, err
+	}
+
+	if i+1 < len(patterns) {
+		if _, err := t.ParseFS(o.fsys, patterns[i+1:]...); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := wrapESI(t, o.funcMap); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// viewFileIndex returns the index of the file of a view in its parse
+// patterns, after the layout and, for a lite variant, its main view.
+func viewFileIndex(name string, patterns []string) int {
+	for i, p := range patterns[1:] {
+		if path.Base(p) == path.Base(name) {
+			return i + 1
+		}
+	}
+	return 1
+}
+
+// UpdateEmail parses a new version of an email from its source and renders it
+// from now on. It returns the number of the new version.
+func (templ *Template) UpdateEmail(name string, src []byte) (int, error) {
+	return templ.UpdateEmailContext(context.Background(), name, src)
+}
+
+// UpdateEmailContext is like UpdateEmail, the actor set on the context via
+// WithActor is passed to the AuditSink option.
+func (templ *Template) UpdateEmailContext(ctx context.Context, name string, src []byte) (int, error) {
+	t, err := templ.parseEmail(name, src)
+	if err != nil {
+		return 0, err
+	}
+
+	templ.mu.Lock()
+	v := templ.addVersion(name, t, src)
+	templ.Emails[name] = t
+	templ.mu.Unlock()
+
+	audit(ctx, AuditEmailUpdate, name, v, nil)
+	return v, nil
+}
+
+// parseEmail parses the source of an email.
+func (templ *Template) parseEmail(name string, src []byte) (*template.Template, error) {
+	templ.mu.RLock()
+	_, ok := templ.Emails[name]
+	templ.mu.RUnlock()
+	if !ok {
+		return nil, errors.New("can't find email: " + name)
+	}
+
+	o := templ.origin(name)
+	if sb, ok := templ.sandboxes[name]; ok {
+		if err := checkSandbox(name, src, o.funcMap, sb); err != nil {
+			return nil, err
+		}
+	}
+
+	return template.New(name).Funcs(o.funcMap).Parse(string(src))
+}
+
+// Rollback renders a previous version of a view or an email from now on.
+// Updates made after the rollback get a new version number.
+func (templ *Template) Rollback(name string, version int) error {
+	return templ.RollbackContext(context.Background(), name, version)
+}
+
+// RollbackContext is like Rollback, the actor set on the context via
+// WithActor is passed to the AuditSink option.
+func (templ *Template) RollbackContext(ctx context.Context, name string, version int) error {
+	if err := templ.rollback(name, version); err != nil {
+		return err
+	}
+
+	audit(ctx, AuditRollback, name, version, nil)
+	return nil
+}
+
+func (templ *Template) rollback(name string, version int) error {
+	templ.mu.Lock()
+	defer templ.mu.Unlock()
+
+	h, ok := templ.history[name]
+	if !ok {
+		if version == 1 {
+			return nil
+		}
+		return fmt.Errorf("version %d of %s is not available", version, name)
+	}
+
+	for i, tv := range h.versions {
+		if tv.Version != version {
+			continue
+		}
+
+		h.current = i
+		if _, ok := templ.Views[name]; ok {
+			templ.Views[name] = tv.t
+			templ.resetCaches(name)
+			templ.setFrontMatter(name, tv.src)
+		} else {
+			templ.Emails[name] = tv.t
+		}
+		return nil
+	}
+
+	return fmt.Errorf("version %d of %s is not available", version, name)
+}
+
+// Versions returns the kept versions of a view or an email, oldest first.
+func (templ *Template) Versions(name string) []TemplateVersion {
+	templ.mu.RLock()
+	defer templ.mu.RUnlock()
+
+	h, ok := templ.history[name]
+	if !ok {
+		return []TemplateVersion{{Version: 1, Current: true}}
+	}
+
+	versions := make([]TemplateVersion, len(h.versions))
+	for i, tv := range h.versions {
+		versions[i] = TemplateVersion{Version: tv.Version, CreatedAt: tv.CreatedAt, Current: i == h.current}
+	}
+	return versions
+}
+
+// addVersion records a new version and makes it current. The version loaded
+// by Parse is recorded as version 1 on the first update. Callers must hold
+// templ.mu.
+func (templ *Template) addVersion(name string, t *template.Template, src []byte) int {
+	if templ.history == nil {
+		templ.history = make(map[string]*versionHistory)
+	}
+
+	h, ok := templ.history[name]
+	if !ok {
+		orig, isView := templ.Views[name]
+		if !isView {
+			orig = templ.Emails[name]
+		}
+
+		h = &versionHistory{versions: []TemplateVersion{{Version: 1, t: orig}}}
+		templ.history[name] = h
+	}
+
+	v := h.last() + 1
+	h.versions = append(h.versions, TemplateVersion{Version: v, CreatedAt: time.Now(), t: t, src: src})
+
+	keep := config.KeepVersions
+	if keep <= 0 {
+		keep = defaultKeepVersions
+	}
+	if len(h.versions) > keep {
+		h.versions = h.versions[len(h.versions)-keep:]
+	}
+
+	h.current = len(h.versions) - 1
+	return v
+}
+
+// version returns the current version number of a view or an email.
+func (templ *Template) version(name string) int {
+	templ.mu.RLock()
+	defer templ.mu.RUnlock()
+
+	if h, ok := templ.history[name]; ok {
+		return h.versions[h.current].Version
+	}
+	return 1
+}
+
+// liveSource returns the source of the current version of a view or an
+// email.
+func (templ *Template) liveSource(name string) ([]byte, error) {
+	var src []byte
+	templ.mu.RLock()
+	if h, ok := templ.history[name]; ok {
+		src = h.versions[h.current].src
+	}
+	templ.mu.RUnlock()
+
+	if src != nil {
+		return src, nil
+	}
+
+	o := templ.origin(name)
+	if patterns := templ.sources[name]; len(patterns) > 1 {
+		return fs.ReadFile(o.fsys, patterns[viewFileIndex(name, patterns)])
+	}
+	return fs.ReadFile(o.fsys, path.Join(o.root, "emails", name))
+}
+
+// resetCaches removes what was computed from the previous version of a view.
+// Callers must hold templ.mu.
+func (templ *Template) resetCaches(name string) {
+	delete(templ.stacked, name)
+	delete(templ.instrumented, name)
+	delete(templ.hints, name)
+	for key := range templ.baked {
+		if key == name || strings.HasSuffix(key, "/"+name) {
+			delete(templ.baked, key)
+		}
+	}
+}
+
+// setFrontMatter updates the front matter of a markdown view from its
+// source, the file of the view if src is nil. Callers must hold templ.mu.
+func (templ *Template) setFrontMatter(name string, src []byte) {
+	if !isMarkdown(name) {
+		return
+	}
+
+	if src == nil {
+		patterns := templ.sources[name]
+		b, err := fs.ReadFile(templ.originLocked(name).fsys, patterns[viewFileIndex(name, patterns)])
+		if err != nil {
+			return
+		}
+		src = b
+	}
+
+	if templ.frontMatters == nil {
+		templ.frontMatters = make(map[string]frontMatter)
+	}
+
+	fm, _ := splitFrontMatter(src)
+	templ.frontMatters[name] = fm
+}
+
+// afterRender records the render in the Stats, logs it if it's slow or
+// failed, and calls the AfterRender option, if set.
+func (templ *Template) afterRender(name string, version int, start time.Time, data any, err error) {
+	if templ.preview {
+		return
+	}
+
+	d := time.Since(start)
+	templ.stats.render(name, d, err)
+	logRender(name, d, data, err)
+
+	if config.AfterRender == nil {
+		return
+	}
+
+	config.AfterRender(RenderInfo{
+		Name:     name,
+		Version:  version,
+		Duration: d,
+		Err:      err,
+	})
+}